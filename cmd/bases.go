@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func basesCommand() *cobra.Command {
+	const longHelp = `Command bases lists the external base images used in FROM statements, which are not managed by dib.
+
+For each base image, it shows the dib images depending on it, and whether it is pinned by digest.
+With --check, the upstream registries are queried to find out whether a newer tag exists, or whether
+the digest a pinned image points to has changed. Credentials are read from the docker config file.
+
+  ex : dib bases --check -o json
+`
+
+	cmd := &cobra.Command{
+		Use:          "bases",
+		Short:        "List the external base images used by dib images",
+		Long:         longHelp,
+		RunE:         basesAction(false),
+		SilenceUsage: true,
+	}
+	addBasesFlags(cmd)
+	cmd.Flags().Bool("check", false, "Query the upstream registries for newer tags and digests")
+
+	return cmd
+}
+
+func outdatedCommand() *cobra.Command {
+	const longHelp = `Command outdated lists the external base images for which a newer tag exists in the upstream registry,
+or whose pinned digest is not the one the tag currently points to.
+
+  ex : dib outdated
+`
+
+	cmd := &cobra.Command{
+		Use:          "outdated",
+		Short:        "List the external base images having a newer tag or digest upstream",
+		Long:         longHelp,
+		RunE:         basesAction(true),
+		SilenceUsage: true,
+	}
+	addBasesFlags(cmd)
+
+	return cmd
+}
+
+func addBasesFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", dib.ConsoleFormat, "Output format : console|json|yaml")
+	cmd.Flags().StringArray("build-arg", []string{},
+		"`argument=value` to supply to the builder")
+}
+
+func basesAction(outdatedOnly bool) func(cmd *cobra.Command, _ []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		// Bind command flags to viper configuration using snake_case
+		bindPFlagsSnakeCase(cmd.Flags())
+
+		opts := dib.BasesOpts{}
+		err := hydrateOptsFromViper(&opts)
+		if err != nil {
+			return err
+		}
+
+		buildPath := path.Join(workingDir, opts.BuildPath)
+
+		naming := dib.Naming{
+			RegistryURL:       opts.RegistryURL,
+			Template:          opts.NameTemplate,
+			NameFromDirectory: opts.NameFromDirectory,
+		}
+
+		graph, err := dib.GenerateDAG(cmd.Context(), buildPath, naming, opts.HashListFilePath,
+			parseBuildArgs(opts.BuildArg), nil)
+		if err != nil {
+			return fmt.Errorf("cannot generate DAG: %w", err)
+		}
+
+		bases := dib.GetBaseImages(graph)
+
+		if opts.Check || outdatedOnly {
+			dib.CheckBaseImages(bases, registry.NewUpstream(cmd.Context()))
+		}
+
+		if outdatedOnly {
+			bases = dib.FilterOutdatedBaseImages(bases)
+		}
+
+		return dib.RenderBaseImages(bases, opts.Output)
+	}
+}
@@ -14,11 +14,18 @@ import (
 	"github.com/radiofrance/dib/pkg/docker"
 	"github.com/radiofrance/dib/pkg/exec"
 	"github.com/radiofrance/dib/pkg/goss"
+	"github.com/radiofrance/dib/pkg/lint"
 	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/metrics"
 	"github.com/radiofrance/dib/pkg/preflight"
 	"github.com/radiofrance/dib/pkg/ratelimit"
 	"github.com/radiofrance/dib/pkg/registry"
 	"github.com/radiofrance/dib/pkg/report"
+	"github.com/radiofrance/dib/pkg/sbom"
+	"github.com/radiofrance/dib/pkg/signing"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/tracing"
+	"github.com/radiofrance/dib/pkg/trivy"
 	"github.com/radiofrance/dib/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -30,6 +37,9 @@ var supportedBackends = []string{
 
 var supportedTestsRunners = []string{
 	types.TestRunnerGoss,
+	types.TestRunnerTrivy,
+	types.TestRunnerStructureTest,
+	types.TestRunnerLint,
 }
 
 var enabledTestsRunner []string
@@ -75,12 +85,18 @@ Otherwise, dib will create a new tag based on the previous tag.`
 		"List of test runners to exclude during the test phase.")
 	cmd.Flags().String("reports-dir", "reports",
 		"Path to the directory where the reports are generated.")
+	cmd.Flags().String("summary-file", "",
+		"Path to a file where a Markdown summary of the build is written (e.g. to post as a merge-request note). "+
+			"Defaults to $GITHUB_STEP_SUMMARY when running in GitHub Actions.")
 	cmd.Flags().Bool("release", false,
 		"Enable release mode to tag all images with extra tags found in the `dib.extra-tags` Dockerfile labels.")
 	cmd.Flags().Bool("local-only", false,
 		"Build Docker images locally. If this flag is not set, the build will be performed in Kubernetes.")
 	cmd.Flags().Bool("push", false,
 		"Push the images to the registry after building them.")
+	cmd.Flags().StringSlice("extra-registry-urls", []string{},
+		"List of registries the images are pushed to and tagged in, in addition to the registry URL. "+
+			"Only the registry URL is checked for existing images.")
 	cmd.Flags().StringP("backend", "b", types.BuildKitBackend,
 		fmt.Sprintf("Build Backend used to run image builds. Supported backends: %v", supportedBackends))
 	cmd.Flags().Int("rate-limit", 1,
@@ -96,7 +112,10 @@ func buildAction(cmd *cobra.Command, _ []string) error {
 	bindPFlagsSnakeCase(cmd.Flags())
 
 	opts := dib.BuildOpts{}
-	hydrateOptsFromViper(&opts)
+	err := hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
 
 	if opts.Backend == types.BuildKitBackend {
 		if opts.LocalOnly {
@@ -113,26 +132,21 @@ func buildAction(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
-	buildArgs := map[string]string{}
+	buildArgs := parseBuildArgs(opts.BuildArg)
 
-	for _, arg := range opts.BuildArg {
-		key, val, hasVal := strings.Cut(arg, "=")
-		if hasVal {
-			buildArgs[key] = os.ExpandEnv(val)
-		} else {
-			// check if the env is set in the local environment and use that value if it is
-			if val, present := os.LookupEnv(key); present {
-				buildArgs[key] = os.ExpandEnv(val)
-			} else {
-				// Avoid masking default build arg value from Dockerfile if environment variable is not set
-				// https://github.com/moby/moby/issues/24101
-				logger.Debugf("ignoring unset build arg %q", key)
-				delete(buildArgs, key)
-			}
-		}
+	shutdownTracing, err := tracing.Setup(cmd.Context(), opts.Tracing, version)
+	if err != nil {
+		return fmt.Errorf("cannot set up tracing: %w", err)
 	}
 
-	err := doBuild(cmd.Context(), opts, buildArgs)
+	defer func() {
+		err := shutdownTracing(context.WithoutCancel(cmd.Context()))
+		if err != nil {
+			logger.Warnf("cannot export traces: %v", err)
+		}
+	}()
+
+	err = doBuild(cmd.Context(), opts, buildArgs)
 	if err != nil {
 		return fmt.Errorf("build failed: %w", err)
 	}
@@ -142,7 +156,20 @@ func buildAction(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
-func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]string) error {
+func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]string) (err error) {
+	ctx, span := tracing.Start(ctx, "dib build", tracing.AttributeBackend.String(opts.Backend))
+	defer func() { tracing.End(span, err) }()
+
+	buildMetrics := metrics.New()
+	if opts.Metrics.Enabled() {
+		defer func() {
+			err := buildMetrics.Export(opts.Metrics)
+			if err != nil {
+				logger.Warnf("cannot export metrics: %v", err)
+			}
+		}()
+	}
+
 	checkRequirements(opts)
 
 	buildPath := path.Join(workingDir, opts.BuildPath)
@@ -150,11 +177,20 @@ func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]strin
 
 	logger.Debugf("Generate DAG")
 
-	graph, err := dib.GenerateDAG(buildPath, opts.RegistryURL, opts.HashListFilePath, buildArgs)
+	naming := dib.Naming{
+		RegistryURL:       opts.RegistryURL,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	graph, err := dib.GenerateDAG(ctx, buildPath, naming, opts.HashListFilePath, buildArgs,
+		upstreamRegistry(ctx, opts.ResolveBaseDigests))
 	if err != nil {
 		return fmt.Errorf("cannot generate DAG: %w", err)
 	}
 
+	dib.SetExtraRegistries(graph, opts.ExtraRegistryURLs)
+
 	logger.Debugf("Generate DAG -- Done")
 
 	dibBuilder := dib.Builder{
@@ -164,12 +200,40 @@ func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]strin
 		BuildOpts:   opts,
 	}
 
+	if opts.SBOM.Enabled {
+		dibBuilder.SBOMProvider = sbom.NewManager(ctx, opts.SBOM,
+			exec.NewShellExecutor(workingDir, os.Environ()), opts.DryRun)
+	}
+
+	if opts.Signing.Verify.Enabled {
+		publicKeys, err := signing.LoadPublicKeys(opts.Signing.Verify.PublicKeys)
+		if err != nil {
+			return err
+		}
+
+		dibBuilder.SignatureVerifier = signing.NewVerifier(ctx, publicKeys)
+	}
+
+	var imageSigner types.ImageSigner
+
+	if opts.Signing.Enabled {
+		// The key is loaded before the build, so a wrong password does not waste a build.
+		signer, err := newSigner(opts.Signing)
+		if err != nil {
+			return err
+		}
+
+		imageSigner = signing.NewManager(ctx, signer, opts.DryRun)
+	}
+
 	gcrRegistry, err := registry.NewRegistry(opts.RegistryURL, opts.DryRun)
 	if err != nil {
 		return fmt.Errorf("cannot connect to registry: %w", err)
 	}
 
-	err = dibBuilder.Plan(gcrRegistry)
+	instrumentedRegistry := buildMetrics.InstrumentRegistry(gcrRegistry)
+
+	err = dibBuilder.Plan(ctx, instrumentedRegistry)
 	if err != nil {
 		return fmt.Errorf("cannot plan build: %w", err)
 	}
@@ -200,12 +264,18 @@ func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]strin
 	res := dibBuilder.RebuildGraph(ctx, builder, ratelimit.NewChannelRateLimiter(opts.RateLimit), buildArgs)
 
 	res.Print()
+	buildMetrics.ObserveBuild(graph, res)
 
 	err = report.Generate(res, dibBuilder.Graph)
 	if err != nil {
 		return fmt.Errorf("cannot generate report: %w", err)
 	}
 
+	err = report.GenerateMarkdownSummary(res, dibBuilder.Graph, opts.SummaryFile)
+	if err != nil {
+		return fmt.Errorf("cannot generate markdown summary: %w", err)
+	}
+
 	err = res.CheckError()
 	if err != nil {
 		return err
@@ -215,7 +285,11 @@ func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]strin
 		return nil
 	}
 
-	var tagger types.ImageTagger
+	var (
+		tagger       types.ImageTagger
+		mirrorer     types.ImageMirrorer
+		sbomProvider types.SBOMProvider
+	)
 
 	if opts.LocalOnly {
 		// Currently, completely ignore retagging when using BuildKit backend
@@ -244,15 +318,38 @@ func doBuild(ctx context.Context, opts dib.BuildOpts, buildArgs map[string]strin
 
 		tagger = dockerBuilderTagger
 	} else {
-		tagger = gcrRegistry
+		tagger = instrumentedRegistry
+		mirrorer = instrumentedRegistry
+		sbomProvider = dibBuilder.SBOMProvider
 	}
 
-	err = dib.Retag(graph, tagger, opts.PlaceholderTag, opts.Release)
+	err = dib.Retag(ctx, graph, tagger, mirrorer, sbomProvider, opts.PlaceholderTag, opts.Release)
+
+	buildMetrics.ObserveRetag(graph)
+
 	if err != nil {
 		return fmt.Errorf("cannot retag images: %w", err)
 	}
 
-	return nil
+	if imageSigner == nil {
+		return nil
+	}
+
+	signatures, err := dib.Sign(ctx, graph, imageSigner, opts.Release)
+	if err != nil {
+		return fmt.Errorf("cannot sign images: %w", err)
+	}
+
+	return report.RecordSignatures(res, signatures)
+}
+
+// newSigner returns the signer using the configured key, or KMS plugin.
+func newSigner(config signing.Config) (signing.Signer, error) {
+	if config.KMSPlugin != "" {
+		return signing.NewPluginSigner(exec.NewShellExecutor(workingDir, os.Environ()), config.KMSPlugin), nil
+	}
+
+	return signing.LoadKey(config.Key, os.Getenv(signing.PasswordEnv))
 }
 
 func getBuildkitHost(cmd *cobra.Command) (string, error) {
@@ -297,12 +394,77 @@ func checkRequirements(opts dib.BuildOpts) {
 			}
 
 			enabledTestsRunner = append(enabledTestsRunner, includedRunner)
-			if opts.Backend == types.BackendDocker {
+
+			switch {
+			case includedRunner == types.TestRunnerLint:
+				// The linter is built in dib.
+				continue
+			case includedRunner == types.TestRunnerTrivy:
+				// Trivy always runs locally, whatever the backend.
+				requiredBinaries = append(requiredBinaries, includedRunner)
+			case opts.Backend != types.BackendDocker:
+				continue
+			case includedRunner == types.TestRunnerStructureTest:
+				requiredBinaries = append(requiredBinaries, structuretest.Binary)
+			default:
 				requiredBinaries = append(requiredBinaries, includedRunner)
 			}
 		}
 	}
 
+	if opts.SBOM.Enabled {
+		err := opts.SBOM.Validate()
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		switch {
+		case opts.LocalOnly && !opts.Push:
+			logger.Fatalf("SBOMs are attached to the images in the registry, so they require the images to be pushed")
+		case opts.SBOM.Generator == sbom.GeneratorSyft:
+			requiredBinaries = append(requiredBinaries, sbom.SyftBinary)
+		case opts.Backend != types.BuildKitBackend:
+			logger.Fatalf("the %q sbom generator requires the %q backend", sbom.GeneratorBuildkit,
+				types.BuildKitBackend)
+		}
+	}
+
+	if opts.Provenance.Enabled {
+		err := opts.Provenance.Validate()
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		switch {
+		case opts.Backend != types.BuildKitBackend:
+			logger.Fatalf("provenance attestations require the %q backend", types.BuildKitBackend)
+		case opts.LocalOnly && !opts.Push:
+			logger.Fatalf("provenance attestations are stored with the images in the registry, " +
+				"so they require the images to be pushed")
+		}
+	}
+
+	if opts.Signing.Enabled {
+		err := opts.Signing.Validate()
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		switch {
+		case opts.LocalOnly:
+			logger.Fatalf("signatures are pushed to the registry, so image signing cannot be used with --local-only")
+		case opts.NoRetag:
+			logger.Fatalf("images are signed once retagged, so image signing cannot be used with --no-retag")
+		}
+	}
+
+	if opts.Signing.Verify.Enabled {
+		err := opts.Signing.Verify.Validate()
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+	}
+
 	preflight.RunPreflightChecks(requiredBinaries)
 }
 
@@ -318,6 +480,25 @@ func getTestRunners(opts dib.BuildOpts, workingDir string) []types.TestRunner {
 
 			testRunners = append(testRunners, gossRunner)
 		}
+
+		if isTestRunnerEnabled(types.TestRunnerStructureTest, enabledTestsRunner) {
+			structureTestRunner, err := structuretest.CreateTestRunner(opts.StructureTest, opts.LocalOnly,
+				opts.BuildkitHost, workingDir, opts.Backend)
+			if err != nil {
+				logger.Fatalf("cannot create structure-test test runner: %v", err)
+			}
+
+			testRunners = append(testRunners, structureTestRunner)
+		}
+
+		if isTestRunnerEnabled(types.TestRunnerLint, enabledTestsRunner) {
+			testRunners = append(testRunners, lint.NewTestRunner(opts.Lint, opts.RegistryURL, workingDir))
+		}
+
+		if isTestRunnerEnabled(types.TestRunnerTrivy, enabledTestsRunner) {
+			testRunners = append(testRunners,
+				trivy.NewTestRunner(exec.NewShellExecutor(workingDir, os.Environ()), opts.Trivy, workingDir))
+		}
 	}
 
 	return testRunners
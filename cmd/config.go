@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/config"
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// dibConfig holds all the keys accepted in the config file, whatever the command.
+//
+//nolint:musttag
+type dibConfig struct {
+	dib.BuildOpts    `mapstructure:",squash"`
+	dib.ListOpts     `mapstructure:",squash"`
+	dib.BasesOpts    `mapstructure:",squash"`
+	dib.PinOpts      `mapstructure:",squash"`
+	dib.GCOpts       `mapstructure:",squash"`
+	dib.PromoteOpts  `mapstructure:",squash"`
+	dib.ExportOpts   `mapstructure:",squash"`
+	dib.ImportOpts   `mapstructure:",squash"`
+	dib.ValidateOpts `mapstructure:",squash"`
+
+	LogLevel  string `mapstructure:"log_level"`
+	LogFormat string `mapstructure:"log_format"`
+}
+
+func configCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the dib configuration",
+	}
+	cmd.AddCommand(configSchemaCommand())
+	cmd.AddCommand(configShowCommand())
+
+	return cmd
+}
+
+func configSchemaCommand() *cobra.Command {
+	const longHelp = `Command schema prints the JSON Schema of the config file.
+
+The schema can be used by editors to validate and auto-complete the config file, e.g. with the YAML language
+server, by adding this comment at the top of the file:
+
+  # yaml-language-server: $schema=https://raw.githubusercontent.com/radiofrance/dib/main/docs/examples/config/dib.schema.json
+
+  ex : dib config schema > dib.schema.json
+`
+
+	return &cobra.Command{
+		Use:          "schema",
+		Short:        "Print the JSON Schema of the config file",
+		Long:         longHelp,
+		RunE:         configSchemaAction,
+		SilenceUsage: true,
+	}
+}
+
+func configSchemaAction(_ *cobra.Command, _ []string) error {
+	output, err := configSchema()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output)) //nolint:forbidigo
+
+	return nil
+}
+
+func configSchema() ([]byte, error) {
+	output, err := json.MarshalIndent(config.GenerateSchema(dibConfig{}, "dib configuration"), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render json schema: %w", err)
+	}
+
+	return output, nil
+}
+
+func configShowCommand() *cobra.Command {
+	const longHelp = `Command show prints the effective configuration, merged from the default values, the config file,
+the environment variables (DIB_*) and the flags, along with the source of each value.
+
+  ex : dib config show -o yaml
+`
+
+	cmd := &cobra.Command{
+		Use:          "show",
+		Short:        "Print the effective configuration, and where each value comes from",
+		Long:         longHelp,
+		RunE:         configShowAction,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringP("output", "o", config.ConsoleFormat, "Output format : console|json|yaml")
+
+	return cmd
+}
+
+func configShowAction(cmd *cobra.Command, _ []string) error {
+	// Bind the flags of all the commands, so their default values are part of the effective configuration.
+	// The flags of this command are not configuration values, so they are left out.
+	for _, command := range rootCmd.Commands() {
+		if command != cmd.Parent() {
+			bindPFlagsSnakeCase(command.LocalFlags())
+		}
+	}
+
+	bindPFlagsSnakeCase(cmd.InheritedFlags())
+
+	changedFlags := map[string]bool{}
+	cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			changedFlags[strings.ReplaceAll(flag.Name, "-", "_")] = true
+		}
+	})
+
+	var fileConfig *viper.Viper
+
+	if viper.ConfigFileUsed() != "" {
+		err := checkConfigFile()
+		if err != nil {
+			return err
+		}
+
+		fileConfig, err = config.ReadFile(viper.ConfigFileUsed())
+		if err != nil {
+			return err
+		}
+	}
+
+	keys := config.Keys(dibConfig{})
+	settings := make([]config.Setting, 0, len(keys))
+
+	for _, key := range keys {
+		settings = append(settings, config.Setting{
+			Key:    key,
+			Value:  viper.Get(key),
+			Source: settingSource(key, fileConfig, changedFlags),
+		})
+	}
+
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	return config.RenderSettings(settings, output)
+}
+
+// settingSource returns where the effective value of the key comes from, following the precedence of viper:
+// flag, then environment variable, then config file, then default value.
+func settingSource(key string, fileConfig *viper.Viper, changedFlags map[string]bool) config.Source {
+	envName := "DIB_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+
+	switch {
+	case changedFlags[key]:
+		return config.SourceFlag
+	case os.Getenv(envName) != "":
+		return config.SourceEnv
+	case fileConfig != nil && fileConfig.IsSet(key):
+		return config.SourceFile
+	default:
+		return config.SourceDefault
+	}
+}
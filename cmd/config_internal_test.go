@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configExamplesDir = "../docs/examples/config"
+
+func TestConfigSchema_UpToDate(t *testing.T) {
+	t.Parallel()
+
+	expected, err := configSchema()
+	require.NoError(t, err)
+
+	actual, err := os.ReadFile(path.Join(configExamplesDir, "dib.schema.json"))
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(expected), string(actual),
+		"the schema is outdated, run: dib config schema > docs/examples/config/dib.schema.json")
+}
+
+func TestConfigReference_Valid(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, config.CheckFile(path.Join(configExamplesDir, "reference.yaml"), &dibConfig{}))
+}
+
+func TestSettingSource(t *testing.T) {
+	t.Setenv("DIB_BACKEND", "docker")
+	t.Setenv("DIB_GOSS_EXECUTOR_KUBERNETES_IMAGE", "")
+
+	fileConfig := viper.New()
+	fileConfig.Set("backend", "buildkit")
+	fileConfig.Set("goss.executor.kubernetes.image", "goss:latest")
+	fileConfig.Set("registry_url", "registry.example.org")
+
+	changedFlags := map[string]bool{"registry_url": true}
+
+	tests := map[string]config.Source{
+		"registry_url":                   config.SourceFlag,
+		"backend":                        config.SourceEnv,
+		"goss.executor.kubernetes.image": config.SourceFile,
+		"build_path":                     config.SourceDefault,
+	}
+
+	for key, expected := range tests {
+		assert.Equal(t, expected, settingSource(key, fileConfig, changedFlags), key)
+	}
+
+	assert.Equal(t, config.SourceDefault, settingSource("registry_url", nil, nil))
+}
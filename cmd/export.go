@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func exportCommand() *cobra.Command {
+	const longHelp = `Command export writes the images of the build path to an OCI image layout, so they can be imported in a
+registry having no access to the original registries, e.g. on an air-gapped site.
+
+The current hash of each image is exported, along with the external base images used in FROM statements.
+Multi-platform images are exported with all their platforms. The archive is written to a directory, or to a
+tarball when the path has the ".tar" extension. All images must have been built beforehand.
+
+  ex : dib export --archive dib-images.tar
+`
+
+	cmd := &cobra.Command{
+		Use:          "export",
+		Short:        "Export the images and their base images to an OCI image layout",
+		Long:         longHelp,
+		RunE:         exportAction,
+		SilenceUsage: true,
+	}
+	cmd.Flags().String("archive", "", "Path to the directory, or the \".tar\" file, the images are exported to")
+	cmd.Flags().StringArray("build-arg", []string{},
+		"`argument=value` to supply to the builder")
+
+	return cmd
+}
+
+func importCommand() *cobra.Command {
+	const longHelp = `Command import pushes the images of an archive written by "dib export" to a registry.
+
+Each image is pushed to the destination registry, in the repository it was exported with: "<registry>/<name>"
+for dib images, and "<registry>/<repository>" for base images (e.g. "<registry>/library/debian"). Images keep
+their tags and digests.
+
+  ex : dib import --archive dib-images.tar --to registry.site.example.org
+`
+
+	cmd := &cobra.Command{
+		Use:          "import",
+		Short:        "Push the images of an exported archive to a registry",
+		Long:         longHelp,
+		RunE:         importAction,
+		SilenceUsage: true,
+	}
+	cmd.Flags().String("archive", "", "Path to the directory, or the \".tar\" file, written by \"dib export\"")
+	cmd.Flags().String("to", "", "Registry to push the images to")
+	cmd.Flags().Bool("dry-run", false, "Only print the images that would be pushed, without pushing them")
+
+	return cmd
+}
+
+func exportAction(cmd *cobra.Command, _ []string) (err error) {
+	// Bind command flags to viper configuration using snake_case
+	bindPFlagsSnakeCase(cmd.Flags())
+
+	opts := dib.ExportOpts{}
+	err = hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Archive == "" {
+		return errors.New("the archive path is required, use --archive")
+	}
+
+	buildPath := path.Join(workingDir, opts.BuildPath)
+
+	naming := dib.Naming{
+		RegistryURL:       opts.RegistryURL,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	graph, err := dib.GenerateDAG(cmd.Context(), buildPath, naming, opts.HashListFilePath,
+		parseBuildArgs(opts.BuildArg), upstreamRegistry(cmd.Context(), opts.ResolveBaseDigests))
+	if err != nil {
+		return fmt.Errorf("cannot generate DAG: %w", err)
+	}
+
+	archive, err := registry.CreateArchive(cmd.Context(), opts.Archive)
+	if err != nil {
+		return fmt.Errorf("cannot create archive: %w", err)
+	}
+
+	defer func() { err = errors.Join(err, archive.Close()) }()
+
+	exported, err := dib.Export(graph, archive, parseBuildArgs(opts.BuildArg))
+	if err != nil {
+		return err
+	}
+
+	err = archive.Flush()
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("%d image(s) exported to %s", len(exported), opts.Archive)
+
+	return nil
+}
+
+func importAction(cmd *cobra.Command, _ []string) (err error) {
+	// Bind command flags to viper configuration using snake_case
+	bindPFlagsSnakeCase(cmd.Flags())
+
+	opts := dib.ImportOpts{}
+	err = hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Archive == "" {
+		return errors.New("the archive path is required, use --archive")
+	}
+
+	if opts.To == "" {
+		return errors.New("the destination registry is required, use --to")
+	}
+
+	archive, err := registry.OpenArchive(cmd.Context(), opts.Archive)
+	if err != nil {
+		return fmt.Errorf("cannot open archive: %w", err)
+	}
+
+	defer func() { err = errors.Join(err, archive.Close()) }()
+
+	imported, err := dib.Import(archive, opts.To, opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("%d image(s) imported to %s", len(imported), opts.To)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+const defaultGCKeep = 5
+
+func gcCommand() *cobra.Command {
+	const longHelp = `Command gc removes the stale tags generated by dib from the registry.
+
+For each image of the build path, the tags of the current hash ("<hash>" and "dev-<hash>"), the extra tags,
+the placeholder tag, and the tags of the last --keep hashes are kept. Other hash tags are removed, along with
+their signatures, in the registry and in the extra registries. Tags which were not generated by dib are never
+removed.
+
+Registries delete manifests rather than tags, so a stale tag pointing to the same manifest as a kept tag
+is left untouched. Credentials are read from the docker config file.
+
+  ex : dib gc --keep 10 --dry-run
+`
+
+	cmd := &cobra.Command{
+		Use:          "gc",
+		Short:        "Remove stale hash tags from the registry",
+		Long:         longHelp,
+		RunE:         gcAction,
+		SilenceUsage: true,
+	}
+	cmd.Flags().Int("keep", defaultGCKeep, "Number of previous hashes to keep for each image")
+	cmd.Flags().Bool("dry-run", false, "Only print the tags that would be removed, without deleting them")
+	cmd.Flags().StringArray("build-arg", []string{},
+		"`argument=value` to supply to the builder")
+	cmd.Flags().StringSlice("extra-registry-urls", []string{},
+		"List of registries the images are pushed to, in addition to the registry URL. "+
+			"Their stale tags are removed as well.")
+
+	return cmd
+}
+
+func gcAction(cmd *cobra.Command, _ []string) error {
+	// Bind command flags to viper configuration using snake_case
+	bindPFlagsSnakeCase(cmd.Flags())
+
+	opts := dib.GCOpts{}
+	err := hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Keep < 0 {
+		return fmt.Errorf("invalid value for keep: %d, must be positive", opts.Keep)
+	}
+
+	buildPath := path.Join(workingDir, opts.BuildPath)
+
+	naming := dib.Naming{
+		RegistryURL:       opts.RegistryURL,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	graph, err := dib.GenerateDAG(cmd.Context(), buildPath, naming, opts.HashListFilePath,
+		parseBuildArgs(opts.BuildArg), upstreamRegistry(cmd.Context(), opts.ResolveBaseDigests))
+	if err != nil {
+		return fmt.Errorf("cannot generate DAG: %w", err)
+	}
+
+	dib.SetExtraRegistries(graph, opts.ExtraRegistryURLs)
+
+	removed, err := dib.CollectGarbage(graph, registry.NewUpstream(cmd.Context()), opts.PlaceholderTag,
+		opts.HashListFilePath, opts.Keep, opts.DryRun)
+	if err != nil {
+		return fmt.Errorf("cannot collect garbage: %w", err)
+	}
+
+	err = dib.RenderRemovedTags(removed)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		logger.Infof("%d tag(s) would be removed", len(removed))
+		return nil
+	}
+
+	logger.Infof("%d tag(s) removed", len(removed))
+
+	return nil
+}
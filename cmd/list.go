@@ -2,11 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"path"
-	"strings"
 
 	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -17,14 +16,30 @@ The output can be customized with the --output flag :
 • console (default output)
   ex : dib list
 
-• go-template-file (render output using a Go template)
+• json (full image metadata, including parents, children, depth, base images and descendants count)
+  ex : dib list -o json
+
+• yaml (same as json, in YAML)
+  ex : dib list -o yaml
+
+• go-template (render output using an inline Go template)
+  ex : dib list -o go-template='{{ range . }}{{ .ShortName }}:{{ .Hash }}{{ "\n" }}{{ end }}'
+
+• go-template-file (render output using a Go template file)
   ex : dib list -o go-template-file=dib_list.tmpl
 
+• mermaid (Mermaid flowchart, to be embedded in Markdown documents)
+  ex : dib list -o mermaid
+
 • graphviz (dot language output)
   ex : dib list -o graphviz
 
   You can also generate a PNG image from the graphviz output using the following command :
   dib list -o graphviz | dot -Tpng > dib.png
+
+With --status, the registry is checked to colour the graphviz and mermaid outputs by the status of the
+images: up-to-date, or needing a rebuild.
+  ex : dib list -o mermaid --status
 `
 
 	cmd := &cobra.Command{
@@ -35,9 +50,11 @@ The output can be customized with the --output flag :
 		SilenceUsage: true,
 	}
 	cmd.Flags().StringP("output", "o", dib.ConsoleFormat,
-		"Output format : console|graphviz|go-template-file")
+		"Output format : console|json|yaml|graphviz|mermaid|go-template|go-template-file")
 	cmd.Flags().StringArray("build-arg", []string{},
 		"`argument=value` to supply to the builder")
+	cmd.Flags().Bool("status", false,
+		"Check which images need to be rebuilt in the registry, to colour the graphviz and mermaid outputs.")
 
 	return cmd
 }
@@ -47,35 +64,50 @@ func listAction(cmd *cobra.Command, _ []string) error {
 	bindPFlagsSnakeCase(cmd.Flags())
 
 	opts := dib.ListOpts{}
-	hydrateOptsFromViper(&opts)
+	err := hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
 
 	formatOpts, err := dib.ParseOutputOptions(opts.Output)
 	if err != nil {
 		return fmt.Errorf("error while parsing output options: %w", err)
 	}
 
-	buildArgs := map[string]string{}
-
-	for _, arg := range opts.BuildArg {
-		key, val, hasVal := strings.Cut(arg, "=")
-		if hasVal {
-			buildArgs[key] = os.ExpandEnv(val)
-		} else {
-			// check if the env is set in the local environment and use that value if it is
-			if val, present := os.LookupEnv(key); present {
-				buildArgs[key] = os.ExpandEnv(val)
-			} else {
-				delete(buildArgs, key)
-			}
-		}
-	}
+	buildArgs := parseBuildArgs(opts.BuildArg)
 
 	buildPath := path.Join(workingDir, opts.BuildPath)
 
-	graph, err := dib.GenerateDAG(buildPath, opts.RegistryURL, opts.HashListFilePath, buildArgs)
+	naming := dib.Naming{
+		RegistryURL:       opts.RegistryURL,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	graph, err := dib.GenerateDAG(cmd.Context(), buildPath, naming, opts.HashListFilePath, buildArgs,
+		upstreamRegistry(cmd.Context(), opts.ResolveBaseDigests))
 	if err != nil {
 		return fmt.Errorf("cannot generate DAG: %w", err)
 	}
 
+	if opts.Status {
+		gcrRegistry, err := registry.NewRegistry(opts.RegistryURL, false)
+		if err != nil {
+			return fmt.Errorf("cannot connect to registry: %w", err)
+		}
+
+		dibBuilder := dib.Builder{
+			Graph:     graph,
+			BuildOpts: dib.BuildOpts{Registry: opts.Registry, NoTests: true},
+		}
+
+		err = dibBuilder.Plan(cmd.Context(), gcrRegistry)
+		if err != nil {
+			return fmt.Errorf("cannot check the status of the images: %w", err)
+		}
+
+		formatOpts.Status = true
+	}
+
 	return dib.GenerateList(graph, formatOpts)
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func pinCommand() *cobra.Command {
+	const longHelp = `Command pin rewrites the FROM statements of all Dockerfiles, so external base images are referenced
+by digest (e.g. "FROM debian:bookworm" becomes "FROM debian:bookworm@sha256:...").
+
+Digests are resolved from the upstream registries, using the credentials from the docker config file.
+Images managed by dib and base images already pinned are left untouched.
+
+  ex : dib pin --dry-run
+`
+
+	cmd := &cobra.Command{
+		Use:          "pin",
+		Short:        "Pin external base images by digest in Dockerfiles",
+		Long:         longHelp,
+		RunE:         pinAction,
+		SilenceUsage: true,
+	}
+	cmd.Flags().Bool("dry-run", false, "Only print the base images that would be pinned, without modifying Dockerfiles")
+
+	return cmd
+}
+
+func pinAction(cmd *cobra.Command, _ []string) error {
+	// Bind command flags to viper configuration using snake_case
+	bindPFlagsSnakeCase(cmd.Flags())
+
+	opts := dib.PinOpts{}
+	err := hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
+
+	buildPath := path.Join(workingDir, opts.BuildPath)
+
+	naming := dib.Naming{
+		RegistryURL:       opts.RegistryURL,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	graph, err := dib.GenerateDAG(cmd.Context(), buildPath, naming, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("cannot generate DAG: %w", err)
+	}
+
+	_, err = dib.PinBaseImages(graph, registry.NewUpstream(cmd.Context()), opts.DryRun)
+	if err != nil {
+		return fmt.Errorf("cannot pin base images: %w", err)
+	}
+
+	return nil
+}
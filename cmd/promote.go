@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func promoteCommand() *cobra.Command {
+	const longHelp = `Command promote copies the images of the build path from a registry to another, without rebuilding them.
+
+For each image, the current hash is copied from the source registry (defaults to the registry URL) to the
+destination registry, with all its blobs. Multi-platform images are copied with all their platforms. The image
+is then tagged with the placeholder tag and its extra tags in the destination registry.
+
+All images must have been built in the source registry beforehand. Credentials for both registries are read
+from the docker config file.
+
+  ex : dib promote --from registry.staging.example.org --to registry.example.org
+`
+
+	cmd := &cobra.Command{
+		Use:          "promote",
+		Short:        "Copy the images from a registry to another, without rebuilding them",
+		Long:         longHelp,
+		RunE:         promoteAction,
+		SilenceUsage: true,
+	}
+	cmd.Flags().String("from", "", "Registry to copy the images from (defaults to the registry URL)")
+	cmd.Flags().String("to", "", "Registry to copy the images to")
+	cmd.Flags().Bool("dry-run", false, "Only print the images that would be promoted, without copying them")
+	cmd.Flags().StringArray("build-arg", []string{},
+		"`argument=value` to supply to the builder")
+
+	return cmd
+}
+
+func promoteAction(cmd *cobra.Command, _ []string) error {
+	// Bind command flags to viper configuration using snake_case
+	bindPFlagsSnakeCase(cmd.Flags())
+
+	opts := dib.PromoteOpts{}
+	err := hydrateOptsFromViper(&opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.To == "" {
+		return errors.New("the destination registry is required, use --to")
+	}
+
+	from := opts.From
+	if from == "" {
+		from = opts.RegistryURL
+	}
+
+	if from == opts.To {
+		return fmt.Errorf("cannot promote images from %s to itself", from)
+	}
+
+	buildPath := path.Join(workingDir, opts.BuildPath)
+
+	naming := dib.Naming{
+		RegistryURL:       from,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	graph, err := dib.GenerateDAG(cmd.Context(), buildPath, naming, opts.HashListFilePath,
+		parseBuildArgs(opts.BuildArg), upstreamRegistry(cmd.Context(), opts.ResolveBaseDigests))
+	if err != nil {
+		return fmt.Errorf("cannot generate DAG: %w", err)
+	}
+
+	err = dib.Promote(graph, registry.NewUpstream(cmd.Context()), opts.To, opts.PlaceholderTag, opts.DryRun)
+	if err != nil {
+		return fmt.Errorf("cannot promote images: %w", err)
+	}
+
+	return nil
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
 	"strings"
 
+	"github.com/radiofrance/dib/pkg/config"
+	"github.com/radiofrance/dib/pkg/dib"
 	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/provenance"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/radiofrance/dib/pkg/sbom"
+	"github.com/radiofrance/dib/pkg/trivy"
+	"github.com/radiofrance/dib/pkg/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -16,9 +24,14 @@ const (
 	defaultRegistryURL         = "eu.gcr.io/my-test-repository"
 	defaultPlaceholderTag      = "latest"
 	defaultLogLevel            = "info"
+	defaultLogFormat           = "text"
 	defaultBuildPath           = "docker"
 	defaultGossImage           = "aelsabbahy/goss:latest"
+	defaultStructureTestImage  = "gcr.io/gcp-runtimes/container-structure-test:latest"
 	defaultKubernetesNamespace = "default"
+	defaultRegistryConcurrency = 10
+	defaultRegistryMaxRetries  = 5
+	defaultRegistryCacheTTL    = 86400
 )
 
 var (
@@ -44,7 +57,7 @@ func Execute() {
 }
 
 func init() {
-	// Set logger level from flags as early as possible, then load config, then finalize from Viper
+	// Set logger level and format from flags as early as possible, then load config, then finalize from Viper
 	cobra.OnInitialize(preInitLogLevelFromFlags, initConfig, initLogLevel)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "",
@@ -55,14 +68,25 @@ found and added to the build graph. You can provide any subdirectory if you want
 as long as it has at least one Dockerfile in it.`)
 	rootCmd.PersistentFlags().String("registry-url", defaultRegistryURL,
 		"Docker registry URL where images are stored.")
+	rootCmd.PersistentFlags().String("name-template", dib.DefaultNameTemplate,
+		`Go template of the image names. Variables are .Registry, .Name (the "name" label), .Path (the directory of 
+the Dockerfile relative to the build path), .Dirs (the elements of .Path), .Labels, and the labels of the Dockerfile 
+with their first letter in uppercase (e.g. .Team for the "team" label). The rendered names must be in the registry.`)
+	rootCmd.PersistentFlags().Bool("name-from-directory", false,
+		`Make the "name" label optional: the name of the directory of the Dockerfile is used when the label is absent.`)
 	rootCmd.PersistentFlags().String("placeholder-tag", defaultPlaceholderTag,
 		`Tag used as placeholder in Dockerfile "from" statements, and replaced internally by dib during builds 
 to use the latest tags from parent images. In release mode, all images will be tagged with the placeholder tag, so 
 Dockerfiles are always valid (images can still be built even without using dib).`)
 	rootCmd.PersistentFlags().StringP("log-level", "l", defaultLogLevel,
 		`Log level. Can be any standard log-level ("info", "debug", etc...)`)
+	rootCmd.PersistentFlags().String("log-format", defaultLogFormat,
+		`Log format. Use "json" to print each log line as a JSON object, e.g. to ship logs to a log pipeline.`)
 	rootCmd.PersistentFlags().String("hash-list-file-path", "",
 		"Path to custom hash list file that will be used to humanize hash")
+	rootCmd.PersistentFlags().Bool("resolve-base-digests", false,
+		`Resolve the digests of the external base images from their registry, and include them in the hashes, so 
+images are rebuilt when their base images are updated upstream.`)
 
 	err := viper.BindPFlags(rootCmd.PersistentFlags())
 	if err != nil {
@@ -71,6 +95,15 @@ Dockerfiles are always valid (images can still be built even without using dib).
 
 	rootCmd.AddCommand(versionCommand())
 	rootCmd.AddCommand(listCommand())
+	rootCmd.AddCommand(basesCommand())
+	rootCmd.AddCommand(outdatedCommand())
+	rootCmd.AddCommand(pinCommand())
+	rootCmd.AddCommand(gcCommand())
+	rootCmd.AddCommand(promoteCommand())
+	rootCmd.AddCommand(exportCommand())
+	rootCmd.AddCommand(importCommand())
+	rootCmd.AddCommand(validateCommand())
+	rootCmd.AddCommand(configCommand())
 	rootCmd.AddCommand(buildCommand())
 	rootCmd.AddCommand(docgenCommand())
 }
@@ -103,6 +136,31 @@ func initConfig() {
 	// Set defaults for config values that have no flag bound to them.
 	viper.SetDefault("goss.executor.kubernetes.image", defaultGossImage)
 	viper.SetDefault("goss.executor.kubernetes.namespace", defaultKubernetesNamespace)
+	viper.SetDefault("structure_test.executor.kubernetes.image", defaultStructureTestImage)
+	viper.SetDefault("structure_test.executor.kubernetes.namespace", defaultKubernetesNamespace)
+	viper.SetDefault("lint.failure_threshold", "error")
+	viper.SetDefault("lint.ignore", []string{})
+	viper.SetDefault("trivy.severity", trivy.DefaultSeverity)
+	viper.SetDefault("trivy.ignore_unfixed", false)
+	viper.SetDefault("sbom.enabled", false)
+	viper.SetDefault("sbom.generator", sbom.GeneratorBuildkit)
+	viper.SetDefault("provenance.enabled", false)
+	viper.SetDefault("provenance.mode", provenance.ModeMax)
+	viper.SetDefault("signing.enabled", false)
+	viper.SetDefault("signing.key", "")
+	viper.SetDefault("signing.kms_plugin", "")
+	viper.SetDefault("signing.verify.enabled", false)
+	viper.SetDefault("signing.verify.public_keys", []string{})
+	viper.SetDefault("signing.verify.ignore", []string{})
+	viper.SetDefault("registry.concurrency", defaultRegistryConcurrency)
+	viper.SetDefault("registry.max_retries", defaultRegistryMaxRetries)
+	viper.SetDefault("registry.cache_file", "")
+	viper.SetDefault("registry.cache_ttl", defaultRegistryCacheTTL)
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("metrics.pushgateway_url", "")
+	viper.SetDefault("metrics.job", "")
+	viper.SetDefault("metrics.textfile_path", "")
 
 	// Env vars starting with the DIB_ prefix can override any configuration.
 	// e.g. DIB_LOG_LEVEL, DIB_BACKEND, etc...
@@ -125,27 +183,37 @@ func initConfig() {
 func initLogLevel() {
 	logLevel := viper.GetString("log_level")
 	logger.SetLevel(&logLevel)
+
+	logFormat := viper.GetString("log_format")
+	logger.SetFormat(&logFormat)
 }
 
-// preInitLogLevelFromFlags sets the log level from Cobra flags or env before config/env are loaded by Viper,
+// preInitLogLevelFromFlags sets the log level and format from Cobra flags or env before config/env are loaded by Viper,
 // so that early logs (like config not found) respect user-provided preference.
-// Precedence respected here: flag > env (DIB_LOG_LEVEL) > config (handled later in initLogLevel via Viper).
+// Precedence respected here: flag > env (DIB_LOG_LEVEL, DIB_LOG_FORMAT) > config
+// (handled later in initLogLevel via Viper).
 func preInitLogLevelFromFlags() {
 	if rootCmd == nil {
 		return
 	}
 
-	flag := rootCmd.PersistentFlags().Lookup("log-level")
+	preInitFromFlag("log-level", "DIB_LOG_LEVEL", logger.SetLevel)
+	preInitFromFlag("log-format", "DIB_LOG_FORMAT", logger.SetFormat)
+}
+
+// preInitFromFlag calls the setter with the value of the flag if set, otherwise with the value of the env variable.
+func preInitFromFlag(flagName, envName string, setter func(*string)) {
+	flag := rootCmd.PersistentFlags().Lookup(flagName)
 	if flag != nil && flag.Changed {
-		val, err := rootCmd.PersistentFlags().GetString("log-level")
+		val, err := rootCmd.PersistentFlags().GetString(flagName)
 		if err == nil {
-			logger.SetLevel(&val)
+			setter(&val)
 			return
 		}
 	}
 
-	if val, ok := os.LookupEnv("DIB_LOG_LEVEL"); ok && val != "" {
-		logger.SetLevel(&val)
+	if val, ok := os.LookupEnv(envName); ok && val != "" {
+		setter(&val)
 	}
 }
 
@@ -161,8 +229,24 @@ func setConfigFile(name string) {
 // hydrateOptsFromViper copies all the viper values into our config struct.
 // The mapping between viper identifiers and struct field names
 // is ensured by `mapstructure` struct tags.
-func hydrateOptsFromViper(opts any) {
-	_ = viper.Unmarshal(opts)
+// The config file is then checked strictly, so unknown keys (e.g. typos) are reported instead of being
+// silently ignored. The opts are hydrated even when the config file is invalid.
+func hydrateOptsFromViper(opts any) error {
+	err := viper.Unmarshal(opts)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return checkConfigFile()
+}
+
+// checkConfigFile checks the config file in use, if any, only contains known keys with values of the right type.
+func checkConfigFile() error {
+	if viper.ConfigFileUsed() == "" {
+		return nil
+	}
+
+	return config.CheckFile(viper.ConfigFileUsed(), &dibConfig{})
 }
 
 // bindPFlagsSnakeCase binds the flags with viper values. The identifier of the viper value
@@ -174,3 +258,38 @@ func bindPFlagsSnakeCase(flags *pflag.FlagSet) {
 		_ = viper.BindPFlag(strings.ReplaceAll(flag.Name, "-", "_"), flag)
 	})
 }
+
+// upstreamRegistry returns the registry used to resolve the digests of the external base images,
+// or nil when the resolution is disabled.
+func upstreamRegistry(ctx context.Context, resolveBaseDigests bool) types.UpstreamRegistry {
+	if !resolveBaseDigests {
+		return nil
+	}
+
+	return registry.NewUpstream(ctx)
+}
+
+// parseBuildArgs parses the values of the "--build-arg" flag, expanding environment variables.
+// Arguments without value are taken from the environment, if set.
+func parseBuildArgs(args []string) map[string]string {
+	buildArgs := map[string]string{}
+
+	for _, arg := range args {
+		key, val, hasVal := strings.Cut(arg, "=")
+		if hasVal {
+			buildArgs[key] = os.ExpandEnv(val)
+		} else {
+			// check if the env is set in the local environment and use that value if it is
+			if val, present := os.LookupEnv(key); present {
+				buildArgs[key] = os.ExpandEnv(val)
+			} else {
+				// Avoid masking default build arg value from Dockerfile if environment variable is not set
+				// https://github.com/moby/moby/issues/24101
+				logger.Debugf("ignoring unset build arg %q", key)
+				delete(buildArgs, key)
+			}
+		}
+	}
+
+	return buildArgs
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var errValidationFailed = errors.New("validation failed")
+
+func validateCommand() *cobra.Command {
+	const longHelp = `Command validate checks the whole build path without building anything: Dockerfile labels,
+image names against the OCI reference grammar, extra tags syntax, duplicate names, dependency cycles,
+.dockerignore patterns, test files syntax and the configuration file.
+
+All problems are reported at once, as "file:line: message", and the command exits with a non-zero
+status when any problem is found, so it can be used in CI.
+
+  ex : dib validate
+`
+
+	return &cobra.Command{
+		Use:          "validate",
+		Short:        "Check the build path and the configuration for problems",
+		Long:         longHelp,
+		RunE:         validateAction,
+		SilenceUsage: true,
+	}
+}
+
+func validateAction(cmd *cobra.Command, _ []string) error {
+	// Bind command flags to viper configuration using snake_case
+	bindPFlagsSnakeCase(cmd.Flags())
+
+	opts := dib.ValidateOpts{}
+	// The opts are hydrated even when the config file is invalid, so the build path is still validated.
+	configErr := hydrateOptsFromViper(&opts)
+
+	naming := dib.Naming{
+		RegistryURL:       opts.RegistryURL,
+		Template:          opts.NameTemplate,
+		NameFromDirectory: opts.NameFromDirectory,
+	}
+
+	problems := dib.Validate(path.Join(workingDir, opts.BuildPath), naming)
+
+	if configErr != nil {
+		configFile := viper.ConfigFileUsed()
+		if configFile == "" {
+			configFile = "configuration"
+		}
+
+		problems = append(problems, dib.Problem{File: configFile, Message: configErr.Error()})
+	}
+
+	for _, problem := range problems {
+		problem.File = strings.TrimPrefix(problem.File, workingDir+"/")
+		problem.Message = strings.ReplaceAll(problem.Message, workingDir+"/", "")
+		fmt.Println(problem) //nolint:forbidigo
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %d problem(s) found", errValidationFailed, len(problems))
+	}
+
+	logger.Infof("No problem found")
+
+	return nil
+}
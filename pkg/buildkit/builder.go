@@ -17,6 +17,7 @@ import (
 	k8sutils "github.com/radiofrance/dib/pkg/kubernetes"
 	"github.com/radiofrance/dib/pkg/logger"
 	"github.com/radiofrance/dib/pkg/strutil"
+	"github.com/radiofrance/dib/pkg/tracing"
 	"github.com/radiofrance/dib/pkg/types"
 	"github.com/radiofrance/kubecli"
 	corev1 "k8s.io/api/core/v1"
@@ -168,7 +169,11 @@ func NewBuilder(ctx context.Context, cfg Config, shell executor.ShellExecutor,
 func (b *Builder) Build(ctx context.Context, opts types.ImageBuilderOpts) error {
 	var err error
 
-	opts.Context, err = b.contextProvider.PrepareContext(ctx, opts)
+	uploadCtx, span := tracing.Start(ctx, "upload_context", tracing.AttributeBackend.String(types.BuildKitBackend))
+	opts.Context, err = b.contextProvider.PrepareContext(uploadCtx, opts)
+
+	tracing.End(span, err)
+
 	if err != nil {
 		return fmt.Errorf("cannot prepare buildkit build context: %w", err)
 	}
@@ -316,9 +321,35 @@ func generateBuildctlArgs(opts types.ImageBuilderOpts) ([]string, error) {
 		buildctlArgs = append(buildctlArgs, "--opt=label:"+k+"="+v)
 	}
 
+	if opts.AttestSBOM {
+		buildctlArgs = append(buildctlArgs, "--opt=attest:sbom=")
+	}
+
+	if opts.Provenance != nil {
+		buildctlArgs = append(buildctlArgs, provenanceArgs(*opts.Provenance)...)
+	}
+
 	return buildctlArgs, nil
 }
 
+// provenanceArgs returns the buildctl arguments requesting the provenance attestation. The metadata are passed as
+// frontend options: BuildKit records the "vcs:*" ones in the provenance metadata, and all of them in the build
+// parameters when the mode is "max".
+func provenanceArgs(opts types.ProvenanceOpts) []string {
+	attest := "mode=" + opts.Mode
+	if opts.BuilderID != "" {
+		attest += ",builder-id=" + opts.BuilderID
+	}
+
+	args := []string{"--opt=attest:provenance=" + attest}
+
+	for _, key := range slices.Sorted(maps.Keys(opts.Metadata)) {
+		args = append(args, "--opt="+key+"="+opts.Metadata[key])
+	}
+
+	return args
+}
+
 func buildPod(dockerConfigSecret string, podConfig k8sutils.PodConfig, args []string) (*corev1.Pod, error) {
 	if dockerConfigSecret == "" {
 		return nil, errors.New("the DockerConfigSecret option is required")
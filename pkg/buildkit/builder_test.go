@@ -503,3 +503,41 @@ func Test_Build_Local(t *testing.T) {
 		})
 	}
 }
+
+func Test_generateBuildctlArgs_AttestSBOM(t *testing.T) {
+	t.Parallel()
+
+	opts := provideDefaultOptions(t)
+
+	buildctlArgs, err := generateBuildctlArgs(opts)
+	require.NoError(t, err)
+	assert.NotContains(t, buildctlArgs, "--opt=attest:sbom=")
+
+	opts.AttestSBOM = true
+
+	buildctlArgs, err = generateBuildctlArgs(opts)
+	require.NoError(t, err)
+	assert.Contains(t, buildctlArgs, "--opt=attest:sbom=")
+}
+
+func Test_generateBuildctlArgs_Provenance(t *testing.T) {
+	t.Parallel()
+
+	opts := provideDefaultOptions(t)
+	opts.Provenance = &types.ProvenanceOpts{
+		Mode:      "max",
+		BuilderID: "https://gitlab.com/project/repository/-/jobs/1234",
+		Metadata: map[string]string{
+			"vcs:source":  "https://gitlab.com/project/repository",
+			"dib:version": "v1.0.0",
+		},
+	}
+
+	buildctlArgs, err := generateBuildctlArgs(opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--opt=attest:provenance=mode=max,builder-id=https://gitlab.com/project/repository/-/jobs/1234",
+		"--opt=dib:version=v1.0.0",
+		"--opt=vcs:source=https://gitlab.com/project/repository",
+	}, buildctlArgs[len(buildctlArgs)-3:])
+}
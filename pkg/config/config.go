@@ -0,0 +1,136 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ConsoleFormat = "console"
+	JSONFormat    = "json"
+	YAMLFormat    = "yaml"
+)
+
+// Source is where the effective value of a configuration key comes from, by order of precedence.
+type Source string
+
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Setting is the effective value of a configuration key.
+type Setting struct {
+	Key    string `json:"key"    yaml:"key"`
+	Value  any    `json:"value"  yaml:"value"`
+	Source Source `json:"source" yaml:"source"`
+}
+
+// ReadFile reads the configuration file in a dedicated viper instance, so its values can be told apart from
+// the defaults, environment variables and flags.
+func ReadFile(filename string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetConfigFile(filename)
+
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file %s: %w", filename, err)
+	}
+
+	return v, nil
+}
+
+// CheckFile decodes the configuration file into the configuration struct, and returns an error when the file
+// contains unknown keys (e.g. a typo in "buildkit.executor.kubernetes.namespace"), or values of the wrong type.
+func CheckFile(filename string, config any) error {
+	v, err := ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	err = v.UnmarshalExact(config)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// RenderSettings prints the settings using the given format, one of "console", "json" or "yaml".
+func RenderSettings(settings []Setting, format string) error {
+	switch format {
+	case "", ConsoleFormat:
+		return renderSettingsConsole(settings)
+	case JSONFormat:
+		output, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to render json output: %w", err)
+		}
+
+		fmt.Println(string(output)) //nolint:forbidigo
+	case YAMLFormat:
+		output, err := yaml.Marshal(settings)
+		if err != nil {
+			return fmt.Errorf("failed to render yaml output: %w", err)
+		}
+
+		fmt.Print(string(output)) //nolint:forbidigo
+	default:
+		return fmt.Errorf("\"%s\" is not a valid output format", format)
+	}
+
+	return nil
+}
+
+func renderSettingsConsole(settings []Setting) error {
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithConfig(tablewriter.Config{
+			Header: tw.CellConfig{
+				Alignment: tw.CellAlignment{Global: tw.AlignLeft},
+			},
+			Row: tw.CellConfig{
+				Formatting: tw.CellFormatting{AutoWrap: tw.WrapNone},
+			},
+		}),
+	)
+
+	data := make([][]string, 0, len(settings))
+	for _, setting := range settings {
+		data = append(data, []string{setting.Key, formatValue(setting.Value), string(setting.Source)})
+	}
+
+	err := table.Bulk(data)
+	if err != nil {
+		return err
+	}
+
+	table.Header([]string{"Key", "Value", "Source"})
+
+	return table.Render()
+}
+
+// formatValue returns strings as is, unset values as empty strings, and other values in JSON notation (e.g. ["goss","trivy"]).
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	}
+
+	output, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(output)
+}
@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr []string
+	}{
+		{
+			name:    "valid config",
+			content: "build_path: docker\nrate_limit: 2\nexecutor:\n  namespace: dib\n  secrets:\n  env:\n    FOO: bar\n",
+		},
+		{
+			name:        "unknown keys",
+			content:     "build_pth: docker\nexecutor:\n  namespac: dib\n",
+			expectedErr: []string{"has invalid keys: build_pth", "'executor' has invalid keys: namespac"},
+		},
+		{
+			name:        "wrong type",
+			content:     "rate_limit: many\n",
+			expectedErr: []string{"'rate_limit' cannot parse value as 'int'"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			filename := path.Join(t.TempDir(), ".dib.yaml")
+			require.NoError(t, os.WriteFile(filename, []byte(test.content), 0o600))
+
+			err := config.CheckFile(filename, &testConfig{})
+			if test.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+
+			for _, expected := range test.expectedErr {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func Test_CheckFile_NotFound(t *testing.T) {
+	t.Parallel()
+
+	err := config.CheckFile(path.Join(t.TempDir(), ".dib.yaml"), &testConfig{})
+	require.ErrorContains(t, err, "cannot read config file")
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaURI is the JSON Schema dialect of the generated schemas.
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema, limited to the keywords needed to describe the configuration.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// GenerateSchema generates the JSON Schema of the configuration struct, from its `mapstructure` tags.
+// Unknown properties are not allowed, as the configuration file is decoded strictly.
+func GenerateSchema(config any, title string) *Schema {
+	schema := typeSchema(reflect.TypeOf(config))
+	schema.SchemaURI = SchemaURI
+	schema.Title = title
+
+	return schema
+}
+
+// Keys returns the keys of all the configuration values, in dotted notation (e.g. "goss.executor.kubernetes.image"),
+// sorted alphabetically. Maps are considered as values, so their own keys are not listed.
+func Keys(config any) []string {
+	keys := typeKeys(reflect.TypeOf(config))
+	slices.Sort(keys)
+
+	return slices.Compact(keys)
+}
+
+func typeKeys(typ reflect.Type) []string {
+	var keys []string
+
+	walkFields(typ, func(name string, field reflect.Type) {
+		if field.Kind() != reflect.Struct {
+			keys = append(keys, name)
+			return
+		}
+
+		for _, key := range typeKeys(field) {
+			keys = append(keys, name+"."+key)
+		}
+	})
+
+	return keys
+}
+
+func typeSchema(typ reflect.Type) *Schema {
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		return typeSchema(typ.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// Lists may be left empty in YAML (e.g. "image_pull_secrets:"), which decodes as null.
+		return &Schema{Type: []string{"array", "null"}, Items: typeSchema(typ.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object", "null"}, AdditionalProperties: typeSchema(typ.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+
+		walkFields(typ, func(name string, field reflect.Type) {
+			schema.Properties[name] = typeSchema(field)
+		})
+
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+// walkFields calls fn with the name and type of each field of the struct, as decoded by mapstructure.
+// Fields of squashed structs are walked as if they were fields of the struct itself.
+func walkFields(typ reflect.Type, fn func(name string, field reflect.Type)) {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if slices.Contains(strings.Split(options, ","), "squash") {
+			walkFields(field.Type, fn)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fn(name, field.Type)
+	}
+}
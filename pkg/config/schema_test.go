@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type executorConfig struct {
+	Namespace string            `mapstructure:"namespace"`
+	Secrets   []string          `mapstructure:"secrets"`
+	Env       map[string]string `mapstructure:"env"`
+}
+
+type RootOpts struct {
+	BuildPath string `mapstructure:"build_path"`
+}
+
+type testConfig struct {
+	RootOpts `mapstructure:",squash"`
+
+	RateLimit int            `mapstructure:"rate_limit"`
+	Output    string         `mapstructure:"output,omitempty"`
+	Executor  executorConfig `mapstructure:"executor"`
+	Ignored   string         `mapstructure:"-"`
+}
+
+func Test_GenerateSchema(t *testing.T) {
+	t.Parallel()
+
+	actual := config.GenerateSchema(testConfig{}, "test")
+
+	expected := &config.Schema{
+		SchemaURI: config.SchemaURI,
+		Title:     "test",
+		Type:      "object",
+		Properties: map[string]*config.Schema{
+			"build_path": {Type: "string"},
+			"rate_limit": {Type: "integer"},
+			"output":     {Type: "string"},
+			"executor": {
+				Type: "object",
+				Properties: map[string]*config.Schema{
+					"namespace": {Type: "string"},
+					"secrets":   {Type: []string{"array", "null"}, Items: &config.Schema{Type: "string"}},
+					"env": {
+						Type:                 []string{"object", "null"},
+						AdditionalProperties: &config.Schema{Type: "string"},
+					},
+				},
+				AdditionalProperties: false,
+			},
+		},
+		AdditionalProperties: false,
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func Test_Keys(t *testing.T) {
+	t.Parallel()
+
+	expected := []string{
+		"build_path",
+		"executor.env",
+		"executor.namespace",
+		"executor.secrets",
+		"output",
+		"rate_limit",
+	}
+	assert.Equal(t, expected, config.Keys(testConfig{}))
+}
@@ -14,10 +14,13 @@ type Image struct {
 	// Hash of the build context "At the moment"
 	Hash string `yaml:"hash"`
 	// A list of tags to make in addition to image hash.
-	ExtraTags         []string               `yaml:"extra_tags,flow,omitempty"`
+	ExtraTags []string `yaml:"extra_tags,flow,omitempty"`
+	// Names of the image in the extra registries, where it is pushed in addition to Name.
+	Mirrors           []string               `yaml:"mirrors,flow,omitempty"`
 	Dockerfile        *dockerfile.Dockerfile `yaml:"dockerfile,omitempty"`
 	IgnorePatterns    []string               `yaml:"ignore_patterns,flow,omitempty"`
 	ContextFiles      []string               `yaml:"-"`
+	BaseDigests       []string               `yaml:"-"`
 	NeedsRebuild      bool                   `yaml:"-"`
 	SkipBuild         bool                   `yaml:"-"`
 	NeedsTests        bool                   `yaml:"-"`
@@ -30,13 +33,21 @@ type Image struct {
 // CurrentRef returns the fully-qualified docker ref for the current version.
 // If the image needs to be rebuilt, a temporary `dev-` prefix is added to the tag.
 func (img Image) CurrentRef() string {
-	tag := img.Hash
+	return img.DockerRef(img.currentVersion())
+}
+
+// CurrentRefs returns the fully-qualified docker refs for the current version, in the primary registry first,
+// then in the extra registries.
+func (img Image) CurrentRefs() []string {
+	return img.DockerRefs(img.currentVersion())
+}
 
+func (img Image) currentVersion() string {
 	if img.NeedsRebuild {
-		tag = "dev-" + img.Hash
+		return "dev-" + img.Hash
 	}
 
-	return img.DockerRef(tag)
+	return img.Hash
 }
 
 // DockerRef returns the fully-qualified docker ref for a given version.
@@ -44,6 +55,24 @@ func (img Image) DockerRef(version string) string {
 	return fmt.Sprintf("%s:%s", img.Name, version)
 }
 
+// DockerRefs returns the fully-qualified docker refs for a given version, in the primary registry first,
+// then in the extra registries.
+func (img Image) DockerRefs(version string) []string {
+	names := img.Names()
+
+	refs := make([]string, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, fmt.Sprintf("%s:%s", name, version))
+	}
+
+	return refs
+}
+
+// Names returns the names of the image, in the primary registry first, then in the extra registries.
+func (img Image) Names() []string {
+	return append([]string{img.Name}, img.Mirrors...)
+}
+
 //nolint:musttag
 func (img Image) Print() string {
 	strImg, err := yaml.Marshal(img)
@@ -41,6 +41,36 @@ func Test_DockerRef(t *testing.T) {
 	assert.Equal(t, "gcr.io/project-id/nginx:version", image.DockerRef("version"))
 }
 
+func Test_DockerRefs_IncludesMirrors(t *testing.T) {
+	t.Parallel()
+
+	image := dag.Image{
+		Name:    "gcr.io/project-id/nginx",
+		Mirrors: []string{"registry.example.org/nginx"},
+	}
+
+	assert.Equal(t, []string{
+		"gcr.io/project-id/nginx:version",
+		"registry.example.org/nginx:version",
+	}, image.DockerRefs("version"))
+}
+
+func Test_CurrentRefs_HasDevPrefixWhenNeedsRebuild(t *testing.T) {
+	t.Parallel()
+
+	image := dag.Image{
+		Name:         "gcr.io/project-id/nginx",
+		Mirrors:      []string{"registry.example.org/nginx"},
+		Hash:         "version",
+		NeedsRebuild: true,
+	}
+
+	assert.Equal(t, []string{
+		"gcr.io/project-id/nginx:dev-version",
+		"registry.example.org/nginx:dev-version",
+	}, image.CurrentRefs())
+}
+
 func Test_Print(t *testing.T) {
 	t.Parallel()
 
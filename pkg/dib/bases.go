@@ -0,0 +1,315 @@
+package dib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultBaseImageTag = "latest"
+
+var rxVersionTag = regexp.MustCompile(`^(v?)(\d+(?:\.\d+)*)(.*)$`)
+
+type BasesOpts struct {
+	// Root options
+	BuildPath         string `mapstructure:"build_path"`
+	RegistryURL       string `mapstructure:"registry_url"`
+	NameTemplate      string `mapstructure:"name_template"`
+	NameFromDirectory bool   `mapstructure:"name_from_directory"`
+	HashListFilePath  string `mapstructure:"hash_list_file_path"`
+
+	// Bases specific options
+	Output   string   `mapstructure:"output,omitempty"`
+	BuildArg []string `mapstructure:"build_arg,omitempty"`
+	Check    bool     `mapstructure:"check,omitempty"`
+}
+
+// BaseImage holds the information about an image used in FROM statements, but not managed by dib.
+type BaseImage struct {
+	Ref    string `json:"ref"              yaml:"ref"`
+	Name   string `json:"name"             yaml:"name"`
+	Tag    string `json:"tag,omitempty"    yaml:"tag,omitempty"`
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	// Pinned is true when the image is referenced by digest.
+	Pinned bool `json:"pinned" yaml:"pinned"`
+	// Dependents holds the short names of the dib images built from this base image.
+	Dependents []string `json:"dependents" yaml:"dependents"`
+
+	// The fields below are only set when the upstream registry has been checked.
+	Checked bool `json:"checked" yaml:"checked"`
+	// LatestDigest is the digest the tag currently points to in the upstream registry.
+	LatestDigest string `json:"latest_digest,omitempty" yaml:"latest_digest,omitempty"`
+	// NewerTags holds the tags following the same versioning scheme, with a higher version.
+	NewerTags []string `json:"newer_tags,omitempty" yaml:"newer_tags,omitempty"`
+	// Outdated is true when the pinned digest is not the latest one, or newer tags exist.
+	Outdated bool `json:"outdated" yaml:"outdated"`
+	// CheckError holds the reason why the upstream registry could not be checked.
+	CheckError string `json:"check_error,omitempty" yaml:"check_error,omitempty"`
+}
+
+// GetBaseImages returns all the external base images used in the graph, sorted by reference.
+// Images managed by dib, build stages and the special "scratch" image are not considered as base images.
+func GetBaseImages(graph *dag.DAG) []BaseImage {
+	managedImages := make(map[string]struct{})
+
+	graph.Walk(func(node *dag.Node) {
+		managedImages[node.Image.Name] = struct{}{}
+	})
+
+	bases := make(map[string]*BaseImage)
+
+	graph.Walk(func(node *dag.Node) {
+		if node.Image.Dockerfile == nil {
+			return
+		}
+
+		for _, from := range node.Image.Dockerfile.From {
+			if !isBaseImage(from, node.Image.Dockerfile, managedImages) {
+				continue
+			}
+
+			ref := imageRefString(from)
+
+			base, ok := bases[ref]
+			if !ok {
+				base = &BaseImage{
+					Ref:        ref,
+					Name:       from.Name,
+					Tag:        from.Tag,
+					Digest:     from.Digest,
+					Pinned:     from.Digest != "",
+					Dependents: []string{},
+				}
+				bases[ref] = base
+			}
+
+			if !slices.Contains(base.Dependents, node.Image.ShortName) {
+				base.Dependents = append(base.Dependents, node.Image.ShortName)
+			}
+		}
+	})
+
+	list := make([]BaseImage, 0, len(bases))
+	for _, base := range bases {
+		sort.Strings(base.Dependents)
+		list = append(list, *base)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Ref < list[j].Ref
+	})
+
+	return list
+}
+
+// CheckBaseImages queries the upstream registries to find out whether a newer digest or tag exists
+// for each base image. Errors are recorded on each image, so a single unreachable registry does not
+// prevent the other images from being checked.
+func CheckBaseImages(bases []BaseImage, upstream types.UpstreamRegistry) {
+	for i := range bases {
+		base := &bases[i]
+		base.Checked = true
+
+		if base.Pinned && base.Tag == "" {
+			base.CheckError = "pinned by digest without tag, no newer version can be found"
+			continue
+		}
+
+		tag := base.Tag
+		if tag == "" {
+			tag = defaultBaseImageTag
+		}
+
+		logger.Debugf("Checking base image \"%s\"", base.Ref)
+
+		digest, err := upstream.Digest(base.Name + ":" + tag)
+		if err != nil {
+			base.CheckError = err.Error()
+			continue
+		}
+
+		base.LatestDigest = digest
+
+		tags, err := upstream.Tags(base.Name)
+		if err != nil {
+			base.CheckError = err.Error()
+			continue
+		}
+
+		base.NewerTags = newerTags(tag, tags)
+		base.Outdated = len(base.NewerTags) > 0 || (base.Pinned && base.Digest != base.LatestDigest)
+	}
+}
+
+// FilterOutdatedBaseImages returns only the base images that are outdated.
+func FilterOutdatedBaseImages(bases []BaseImage) []BaseImage {
+	outdated := []BaseImage{}
+
+	for _, base := range bases {
+		if base.Outdated {
+			outdated = append(outdated, base)
+		}
+	}
+
+	return outdated
+}
+
+// RenderBaseImages prints the base images using the given format, one of "console", "json" or "yaml".
+func RenderBaseImages(bases []BaseImage, format string) error {
+	switch format {
+	case "", ConsoleFormat:
+		return renderBaseImagesConsole(bases)
+	case JSONFormat:
+		output, err := json.MarshalIndent(bases, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to render json output: %w", err)
+		}
+
+		fmt.Println(string(output)) //nolint:forbidigo
+	case YAMLFormat:
+		output, err := yaml.Marshal(bases)
+		if err != nil {
+			return fmt.Errorf("failed to render yaml output: %w", err)
+		}
+
+		fmt.Print(string(output)) //nolint:forbidigo
+	default:
+		return fmt.Errorf("\"%s\" is not a valid output format", format)
+	}
+
+	return nil
+}
+
+func renderBaseImagesConsole(bases []BaseImage) error {
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithConfig(tablewriter.Config{
+			Header: tw.CellConfig{
+				Alignment: tw.CellAlignment{Global: tw.AlignLeft},
+			},
+			Row: tw.CellConfig{
+				Formatting: tw.CellFormatting{AutoWrap: tw.WrapNone},
+			},
+		}),
+	)
+
+	checked := slices.ContainsFunc(bases, func(base BaseImage) bool { return base.Checked })
+
+	var data [][]string
+	for _, base := range bases {
+		row := []string{base.Ref, strconv.FormatBool(base.Pinned), strings.Join(base.Dependents, ", ")}
+		if checked {
+			row = append(row, strings.Join(base.NewerTags, ", "), baseImageStatus(base))
+		}
+
+		data = append(data, row)
+	}
+
+	err := table.Bulk(data)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"Image", "Pinned", "Used by"}
+	if checked {
+		header = append(header, "Newer tags", "Status")
+	}
+
+	table.Header(header)
+
+	return table.Render()
+}
+
+func baseImageStatus(base BaseImage) string {
+	switch {
+	case base.CheckError != "":
+		return "error: " + base.CheckError
+	case base.Pinned && base.Digest != base.LatestDigest:
+		return "digest changed"
+	case base.Outdated:
+		return "newer tag available"
+	default:
+		return "up-to-date"
+	}
+}
+
+// newerTags returns the tags using the same versioning scheme as the current tag, with a higher version,
+// sorted from the lowest to the highest version. For instance, "3.18" and "3.19" are newer than "3.17",
+// but "3.18-rc1" and "edge" are ignored.
+func newerTags(current string, tags []string) []string {
+	currentVersion, ok := parseVersionTag(current)
+	if !ok {
+		return nil
+	}
+
+	type candidate struct {
+		tag     string
+		version versionTag
+	}
+
+	var candidates []candidate
+
+	for _, tag := range tags {
+		version, ok := parseVersionTag(tag)
+		if !ok || !version.sameScheme(currentVersion) || slices.Compare(version.numbers, currentVersion.numbers) <= 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{tag, version})
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return slices.Compare(a.version.numbers, b.version.numbers)
+	})
+
+	newer := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		newer = append(newer, c.tag)
+	}
+
+	return newer
+}
+
+// versionTag is a tag made of dot-separated numbers, with an optional "v" prefix and an optional suffix
+// (e.g. "v1.2.3", "3.17-alpine").
+type versionTag struct {
+	prefix  string
+	numbers []int
+	suffix  string
+}
+
+func parseVersionTag(tag string) (versionTag, bool) {
+	match := rxVersionTag.FindStringSubmatch(tag)
+	if match == nil {
+		return versionTag{}, false
+	}
+
+	parts := strings.Split(match[2], ".")
+	numbers := make([]int, 0, len(parts))
+
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return versionTag{}, false
+		}
+
+		numbers = append(numbers, number)
+	}
+
+	return versionTag{prefix: match[1], numbers: numbers, suffix: match[3]}, true
+}
+
+func (v versionTag) sameScheme(other versionTag) bool {
+	return v.prefix == other.prefix && v.suffix == other.suffix && len(v.numbers) == len(other.numbers)
+}
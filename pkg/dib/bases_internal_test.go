@@ -0,0 +1,33 @@
+package dib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newerTags(t *testing.T) {
+	t.Parallel()
+
+	tags := []string{"3", "3.16", "3.17", "3.9", "3.18-alpine", "3.17-alpine", "v3.18", "3.20", "3.18", "latest", "4.0"}
+
+	tests := []struct {
+		current  string
+		expected []string
+	}{
+		{current: "3.17", expected: []string{"3.18", "3.20", "4.0"}},
+		{current: "3.16-alpine", expected: []string{"3.17-alpine", "3.18-alpine"}},
+		{current: "v3.17", expected: []string{"v3.18"}},
+		{current: "2", expected: []string{"3"}},
+		{current: "4.0", expected: []string{}},
+		{current: "latest", expected: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.current, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, newerTags(test.current, tags))
+		})
+	}
+}
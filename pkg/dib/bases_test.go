@@ -0,0 +1,111 @@
+package dib_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBasesDag(t *testing.T) *dag.DAG {
+	t.Helper()
+
+	rootNode := newNode("test-registry/bullseye", "floor-venus-august-venus", "docker/bullseye")
+	rootNode.Image.Dockerfile.From = []dockerfile.ImageRef{{Name: "debian", Tag: "11.6"}}
+
+	goNode := newNode("test-registry/golang", "cup-neptune-snake-thirteen", "docker/bullseye/golang")
+	goNode.Image.Dockerfile.From = []dockerfile.ImageRef{
+		{Name: "golang", Tag: "1.26", Digest: "sha256:old"},
+		{Name: "test-registry/bullseye"},
+	}
+
+	alpineNode := newNode("test-registry/alpine", "blue-bulldog-fourteen-angel", "docker/alpine")
+	alpineNode.Image.Dockerfile.From = []dockerfile.ImageRef{
+		{Name: "alpine", Tag: "3.17"},
+		{Name: "golang", Tag: "1.26", Digest: "sha256:old"},
+		{Name: "scratch"},
+	}
+
+	rootNode.AddChild(goNode)
+
+	DAG := &dag.DAG{}
+	DAG.AddNode(rootNode)
+	DAG.AddNode(alpineNode)
+
+	return DAG
+}
+
+func Test_GetBaseImages(t *testing.T) {
+	t.Parallel()
+
+	actual := dib.GetBaseImages(setupBasesDag(t))
+
+	expected := []dib.BaseImage{
+		{Ref: "alpine:3.17", Name: "alpine", Tag: "3.17", Dependents: []string{"alpine"}},
+		{Ref: "debian:11.6", Name: "debian", Tag: "11.6", Dependents: []string{"bullseye"}},
+		{
+			Ref:        "golang:1.26@sha256:old",
+			Name:       "golang",
+			Tag:        "1.26",
+			Digest:     "sha256:old",
+			Pinned:     true,
+			Dependents: []string{"alpine", "golang"},
+		},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func Test_CheckBaseImages(t *testing.T) {
+	t.Parallel()
+
+	bases := dib.GetBaseImages(setupBasesDag(t))
+	upstream := &mock.UpstreamRegistry{
+		Digests: map[string]string{
+			"alpine:3.17": "sha256:alpine",
+			"golang:1.26": "sha256:new",
+		},
+		TagList: map[string][]string{
+			"alpine": {"3.16", "3.17", "3.18", "3.18-rc1", "3.19", "edge", "latest"},
+			"golang": {"1.25", "1.26", "1.26-alpine"},
+		},
+	}
+
+	dib.CheckBaseImages(bases, upstream)
+
+	alpine, debian, golang := bases[0], bases[1], bases[2]
+
+	assert.True(t, alpine.Checked)
+	assert.Equal(t, "sha256:alpine", alpine.LatestDigest)
+	assert.Equal(t, []string{"3.18", "3.19"}, alpine.NewerTags)
+	assert.True(t, alpine.Outdated)
+	assert.Empty(t, alpine.CheckError)
+
+	assert.True(t, debian.Checked)
+	assert.False(t, debian.Outdated)
+	assert.Contains(t, debian.CheckError, "manifest unknown")
+
+	assert.Equal(t, "sha256:new", golang.LatestDigest)
+	assert.Empty(t, golang.NewerTags)
+	assert.True(t, golang.Outdated, "the pinned digest is not the latest one")
+
+	outdated := dib.FilterOutdatedBaseImages(bases)
+	require.Len(t, outdated, 2)
+	assert.Equal(t, "alpine:3.17", outdated[0].Ref)
+	assert.Equal(t, "golang:1.26@sha256:old", outdated[1].Ref)
+}
+
+func Test_RenderBaseImages(t *testing.T) {
+	t.Parallel()
+
+	bases := dib.GetBaseImages(setupBasesDag(t))
+
+	for _, format := range []string{dib.ConsoleFormat, dib.JSONFormat, dib.YAMLFormat} {
+		require.NoError(t, dib.RenderBaseImages(bases, format))
+	}
+
+	require.ErrorContains(t, dib.RenderBaseImages(bases, "xml"), "\"xml\" is not a valid output format")
+}
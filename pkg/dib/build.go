@@ -6,25 +6,39 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/radiofrance/dib/pkg/buildkit"
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/radiofrance/dib/pkg/exec"
 	"github.com/radiofrance/dib/pkg/goss"
+	"github.com/radiofrance/dib/pkg/lint"
 	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/metrics"
+	"github.com/radiofrance/dib/pkg/provenance"
 	"github.com/radiofrance/dib/pkg/ratelimit"
+	"github.com/radiofrance/dib/pkg/registry"
 	"github.com/radiofrance/dib/pkg/report"
+	"github.com/radiofrance/dib/pkg/sbom"
+	"github.com/radiofrance/dib/pkg/signing"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/tracing"
+	"github.com/radiofrance/dib/pkg/trivy"
 	"github.com/radiofrance/dib/pkg/types"
+	"go.opentelemetry.io/otel/codes"
 	"gopkg.in/yaml.v3"
 )
 
 type BuildOpts struct {
 	// Root options
-	BuildPath        string `mapstructure:"build_path"`
-	RegistryURL      string `mapstructure:"registry_url"`
-	PlaceholderTag   string `mapstructure:"placeholder_tag"`
-	HashListFilePath string `mapstructure:"hash_list_file_path"`
+	BuildPath          string `mapstructure:"build_path"`
+	RegistryURL        string `mapstructure:"registry_url"`
+	NameTemplate       string `mapstructure:"name_template"`
+	NameFromDirectory  bool   `mapstructure:"name_from_directory"`
+	PlaceholderTag     string `mapstructure:"placeholder_tag"`
+	HashListFilePath   string `mapstructure:"hash_list_file_path"`
+	ResolveBaseDigests bool   `mapstructure:"resolve_base_digests"`
 
 	// Build specific options
 	BuildkitHost string   `mapstructure:"buildkit_host"`
@@ -32,6 +46,7 @@ type BuildOpts struct {
 	NoTests      bool     `mapstructure:"no_tests"`
 	IncludeTests []string `mapstructure:"include_tests"`
 	ReportsDir   string   `mapstructure:"reports_dir"`
+	SummaryFile  string   `mapstructure:"summary_file"`
 	DryRun       bool     `mapstructure:"dry_run"`
 	ForceRebuild bool     `mapstructure:"force_rebuild"`
 	NoRetag      bool     `mapstructure:"no_retag"`
@@ -44,10 +59,22 @@ type BuildOpts struct {
 	Progress     string   `mapstructure:"progress"`
 	Compression  string   `mapstructure:"compression"`
 
-	Goss      goss.Config     `mapstructure:"goss"`
-	Buildkit  buildkit.Config `mapstructure:"buildkit"`
-	RateLimit int             `mapstructure:"rate_limit"`
-	BuildArg  []string        `mapstructure:"build_arg"`
+	// ExtraRegistryURLs are the registries the images are pushed to in addition to RegistryURL.
+	ExtraRegistryURLs []string `mapstructure:"extra_registry_urls"`
+
+	Goss          goss.Config          `mapstructure:"goss"`
+	Trivy         trivy.Config         `mapstructure:"trivy"`
+	StructureTest structuretest.Config `mapstructure:"structure_test"`
+	Lint          lint.Config          `mapstructure:"lint"`
+	SBOM          sbom.Config          `mapstructure:"sbom"`
+	Provenance    provenance.Config    `mapstructure:"provenance"`
+	Signing       signing.Config       `mapstructure:"signing"`
+	Buildkit      buildkit.Config      `mapstructure:"buildkit"`
+	Tracing       tracing.Config       `mapstructure:"tracing"`
+	Metrics       metrics.Config       `mapstructure:"metrics"`
+	Registry      registry.Config      `mapstructure:"registry"`
+	RateLimit     int                  `mapstructure:"rate_limit"`
+	BuildArg      []string             `mapstructure:"build_arg"`
 }
 
 // RebuildGraph iterates over the graph to rebuild all the images that are marked to be rebuilt.
@@ -74,6 +101,7 @@ func (p *Builder) RebuildGraph(
 		rateLimiter,
 		res.GetBuildReportDir(),
 		res.GetJunitReportDir(),
+		res.GetSBOMReportDir(),
 		buildArgs,
 	)
 
@@ -89,9 +117,11 @@ func (p *Builder) rebuildGraph(
 	buildReportsChan chan report.BuildReport,
 	builder types.ImageBuilder,
 	rateLimiter ratelimit.RateLimiter,
-	buildReportDir, junitReportDir string,
+	buildReportDir, junitReportDir, sbomReportDir string,
 	buildArgs map[string]string,
 ) {
+	preBuildRunners, testRunners := splitTestRunners(p.TestRunners)
+
 	p.Graph.
 		WalkParallel(
 			func(node *dag.Node) {
@@ -101,21 +131,81 @@ func (p *Builder) rebuildGraph(
 					return
 				}
 
-				buildReport := report.BuildReport{Image: *img}
+				ctx, span := tracing.Start(ctx, "image",
+					append(tracing.ImageAttributes(img), tracing.AttributeBackend.String(p.Backend))...)
+
+				buildReport := report.BuildReport{
+					Image:   *img,
+					Timings: report.Timings{StartTime: time.Now()},
+				}
+
+				sendReport := func(buildReport report.BuildReport) {
+					if buildReport.FailureMessage != "" {
+						span.SetStatus(codes.Error, buildReport.FailureMessage)
+					}
+
+					span.End()
+
+					buildReport.Timings.EndTime = time.Now()
+					buildReportsChan <- buildReport
+				}
 
 				// Return if any parent build failed
 				for _, parent := range node.Parents() {
 					if parent.Image.RebuildFailed {
 						img.RebuildFailed = true
 
-						buildReportsChan <- buildReport
+						sendReport(buildReport)
+
+						return
+					}
+				}
+
+				runTestOpts := types.RunTestOptions{
+					ImageName:         img.ShortName,
+					ImageReference:    img.CurrentRef(),
+					BuildkitHost:      p.BuildkitHost,
+					DockerContextPath: img.Dockerfile.ContextPath,
+					DockerfilePath:    path.Join(img.Dockerfile.ContextPath, img.Dockerfile.Filename),
+					ReportJunitDir:    junitReportDir,
+				}
+
+				// Tests checking the build context run first, so a failing image does not waste a build slot.
+				if len(preBuildRunners) > 0 {
+					testCtx, testSpan := tracing.Start(ctx, "pre-build test", tracing.ImageAttributes(img)...)
+					err := testImage(testCtx, preBuildRunners, runTestOpts)
+
+					tracing.End(testSpan, err)
+
+					if err != nil {
+						img.RebuildFailed = true
+						buildReport.TestsStatus = report.TestsStatusFailed
+						buildReport.FailureMessage = err.Error()
+
+						sendReport(buildReport)
+
+						return
+					}
+				}
+
+				if img.NeedsRebuild && p.SignatureVerifier != nil {
+					verifyCtx, verifySpan := tracing.Start(ctx, "verify", tracing.ImageAttributes(img)...)
+					err := verifyBaseImages(verifyCtx, node, p.SignatureVerifier, p.Signing.Verify.Ignore,
+						buildArgs)
+
+					tracing.End(verifySpan, err)
+
+					if err != nil {
+						img.RebuildFailed = true
+
+						sendReport(buildReport.WithError(err))
 
 						return
 					}
 				}
 
 				if img.NeedsRebuild {
-					meta := LoadCommonMetadata(&exec.ShellExecutor{})
+					meta := LoadCommonMetadata(&exec.ShellExecutor{}).WithImage(img)
 
 					opts := types.ImageBuilderOpts{
 						BuildkitHost: p.BuildkitHost,
@@ -123,43 +213,62 @@ func (p *Builder) rebuildGraph(
 						File:         p.File,
 						LocalOnly:    p.LocalOnly,
 						Target:       p.Target,
-						Tags: []string{
-							img.CurrentRef(),
-						},
-						Labels: meta.WithImage(img).ToLabels(),
+						Tags:         img.CurrentRefs(),
+						Labels:       meta.ToLabels(),
 						// TODO fix this flag there is mix between push and local, is totally different
 						Push:        p.Push,
 						BuildArgs:   buildArgs,
 						Progress:    p.Progress,
 						Compression: p.Compression,
+						AttestSBOM:  p.SBOMProvider != nil && p.SBOM.Generator != sbom.GeneratorSyft,
+					}
+
+					if p.Provenance.Enabled {
+						opts.Provenance = p.provenanceOpts(meta, node)
 					}
 
 					err := buildNode(ctx, node, opts, builder, rateLimiter,
-						p.PlaceholderTag, buildReportDir,
+						p.PlaceholderTag, buildReportDir, &buildReport.Timings,
 					)
 					if err != nil {
 						img.RebuildFailed = true
 
-						buildReportsChan <- buildReport.WithError(err)
+						sendReport(buildReport.WithError(err))
 
 						return
 					}
 
+					if p.SBOMProvider != nil {
+						sbomCtx, sbomSpan := tracing.Start(ctx, "sbom", tracing.ImageAttributes(img)...)
+						err := p.SBOMProvider.Attach(sbomCtx, img.CurrentRef(),
+							sbom.ReportPath(sbomReportDir, img.ShortName))
+
+						tracing.End(sbomSpan, err)
+
+						if err != nil {
+							img.RebuildFailed = true
+
+							sendReport(buildReport.WithError(err))
+
+							return
+						}
+					}
+
 					buildReport.BuildStatus = report.BuildStatusSuccess
 				}
 
 				if !img.NeedsTests {
-					buildReportsChan <- buildReport
+					sendReport(buildReport)
 					return
 				}
 
-				err := testImage(ctx, p.TestRunners, types.RunTestOptions{
-					ImageName:         img.ShortName,
-					ImageReference:    img.CurrentRef(),
-					BuildkitHost:      p.BuildkitHost,
-					DockerContextPath: img.Dockerfile.ContextPath,
-					ReportJunitDir:    junitReportDir,
-				})
+				testCtx, testSpan := tracing.Start(ctx, "test", tracing.ImageAttributes(img)...)
+				testStart := time.Now()
+				err := testImage(testCtx, testRunners, runTestOpts)
+				buildReport.Timings.TestDuration = time.Since(testStart)
+
+				tracing.End(testSpan, err)
+
 				if err != nil {
 					buildReport.TestsStatus = report.TestsStatusFailed
 					buildReport.FailureMessage = err.Error()
@@ -167,7 +276,7 @@ func (p *Builder) rebuildGraph(
 					buildReport.TestsStatus = report.TestsStatusPassed
 				}
 
-				buildReportsChan <- buildReport
+				sendReport(buildReport)
 			})
 	close(buildReportsChan)
 }
@@ -180,10 +289,15 @@ func buildNode(
 	rateLimiter ratelimit.RateLimiter,
 	placeholderTag string,
 	buildReportDir string,
+	timings *report.Timings,
 ) error {
+	queueStart := time.Now()
+
 	rateLimiter.Acquire()
 	defer rateLimiter.Release()
 
+	timings.QueueDuration = time.Since(queueStart)
+
 	img := node.Image
 	// Before building the image, we need to replace all references to tags
 	// of any dib-managed images used as dependencies in the Dockerfile.
@@ -202,7 +316,7 @@ func buildNode(
 		err := dockerfile.ResetFile(
 			path.Join(img.Dockerfile.ContextPath, img.Dockerfile.Filename), tagsToReplace)
 		if err != nil {
-			logger.Warnf("failed to reset tag in dockerfile %s: %v", img.Dockerfile.ContextPath, err)
+			imageLogger(img).Warnf("failed to reset tag in dockerfile %s: %v", img.Dockerfile.ContextPath, err)
 		}
 	}()
 
@@ -218,12 +332,46 @@ func buildNode(
 		return fmt.Errorf("failed to create file %s: %w", filePath, err)
 	}
 
-	logger.Infof("Building \"%s\" in context \"%s\"", img.CurrentRef(), img.Dockerfile.ContextPath)
+	imageLogger(img).Infof("Building \"%s\" in context \"%s\"", img.CurrentRef(), img.Dockerfile.ContextPath)
+
+	buildCtx, span := tracing.Start(ctx, "build", tracing.ImageAttributes(img)...)
+
+	opts.OnPushed = func(duration time.Duration) {
+		timings.PushDuration = &duration
+	}
+	buildStart := time.Now()
+
+	err = builder.Build(buildCtx, opts)
+
+	timings.BuildDuration = time.Since(buildStart)
+	if timings.PushDuration != nil {
+		timings.BuildDuration -= *timings.PushDuration
+	}
+
+	tracing.End(span, err)
 
-	err = builder.Build(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("building image %s failed: %w", img.ShortName, err)
 	}
 
 	return nil
 }
+
+// provenanceOpts returns the options of the provenance attestation of the image, enriched with its metadata.
+func (p *Builder) provenanceOpts(meta ImageMetadata, node *dag.Node) *types.ProvenanceOpts {
+	mode := p.Provenance.Mode
+	if mode == "" {
+		mode = provenance.ModeMax
+	}
+
+	parents := make([]*dag.Image, 0, len(node.Parents()))
+	for _, parent := range node.Parents() {
+		parents = append(parents, parent.Image)
+	}
+
+	return &types.ProvenanceOpts{
+		Mode:      mode,
+		BuilderID: meta.JobURL,
+		Metadata:  meta.ToProvenance(p.Version, parents),
+	}
+}
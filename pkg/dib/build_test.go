@@ -2,6 +2,7 @@ package dib_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -13,7 +14,9 @@ import (
 	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/radiofrance/dib/pkg/logger"
 	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/provenance"
 	"github.com/radiofrance/dib/pkg/report"
+	"github.com/radiofrance/dib/pkg/signing"
 	"github.com/radiofrance/dib/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -117,6 +120,40 @@ func TestRebuildGraph(t *testing.T) {
 			},
 			expNumBuilds: 1,
 		},
+		{
+			name: "Graph with 1 parent and 1 child nodes, pre-build test is failing on parent",
+			buildGraph: func() *dag.DAG {
+				graph := &dag.DAG{}
+				parentNode := newTestNode(
+					true,
+					true,
+					false)
+				childNode := newTestNode(
+					true,
+					true,
+					false)
+
+				parentNode.AddChild(childNode)
+				graph.AddNode(parentNode)
+
+				return graph
+			},
+			testRunners: []types.TestRunner{&mock.PreBuildTestRunner{TestRunner: mock.TestRunner{
+				ReturnedError: fmt.Errorf("mock lint failed"),
+			}}},
+			expBuildReports: []report.BuildReport{
+				{
+					BuildStatus:    report.BuildStatusSkipped,
+					TestsStatus:    report.TestsStatusFailed,
+					FailureMessage: "mock lint failed",
+				},
+				{
+					BuildStatus: report.BuildStatusSkipped,
+					TestsStatus: report.TestsStatusSkipped,
+				},
+			},
+			expNumBuilds: 0,
+		},
 		{
 			name: "Graph with 1 parent and 2 children nodes, rebuild and test successful on all nodes",
 			buildGraph: func() *dag.DAG {
@@ -266,3 +303,225 @@ var testDockerfile = dockerfile.Dockerfile{
 	ContextPath: "../../test/fixtures/build",
 	Filename:    "Dockerfile",
 }
+
+func TestRebuildGraph_SBOM(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		sbomError      error
+		expBuildStatus report.BuildStatus
+		expFailure     string
+	}{
+		{
+			name:           "SBOM attached",
+			expBuildStatus: report.BuildStatusSuccess,
+		},
+		{
+			name:           "SBOM attachment failing",
+			sbomError:      fmt.Errorf("mock sbom failed"),
+			expBuildStatus: report.BuildStatusError,
+			expFailure:     "mock sbom failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			graph := &dag.DAG{}
+			node := newTestNode(true, false, false)
+			node.Image.ShortName = "image"
+			graph.AddNode(node)
+
+			builder := mock.NewBuilder()
+			sbomProvider := &mock.SBOMProvider{ReturnedError: test.sbomError}
+			dibBuilder := dib.Builder{
+				Version:      "v1.0.0",
+				Graph:        graph,
+				SBOMProvider: sbomProvider,
+				BuildOpts: dib.BuildOpts{
+					ReportsDir: mock.ReportsDir,
+				},
+			}
+
+			res := dibBuilder.RebuildGraph(context.Background(), builder, mock.RateLimiter{}, map[string]string{})
+
+			require.Len(t, res.BuildReports, 1)
+			assert.Equal(t, test.expBuildStatus, res.BuildReports[0].BuildStatus)
+			assert.Equal(t, test.expFailure, res.BuildReports[0].FailureMessage)
+			assert.Equal(t, map[string]string{
+				node.Image.CurrentRef(): path.Join(res.GetSBOMReportDir(), "image.spdx.json"),
+			}, sbomProvider.Attached)
+		})
+	}
+}
+
+func TestRebuildGraph_Provenance(t *testing.T) {
+	t.Parallel()
+
+	graph := &dag.DAG{}
+	parent := newTestNode(false, false, false)
+	parent.Image.Hash = "alpha-bravo-charlie-delta"
+	node := newTestNode(true, false, false)
+	node.Image.Hash = "echo-foxtrot-golf-hotel"
+	parent.AddChild(node)
+	graph.AddNode(parent)
+
+	builder := mock.NewBuilder()
+	dibBuilder := dib.Builder{
+		Version: "v1.0.0",
+		Graph:   graph,
+		BuildOpts: dib.BuildOpts{
+			ReportsDir: mock.ReportsDir,
+			Provenance: provenance.Config{Enabled: true},
+		},
+	}
+
+	res := dibBuilder.RebuildGraph(context.Background(), builder, mock.RateLimiter{}, map[string]string{})
+
+	require.Len(t, res.BuildReports, 1)
+	assert.Equal(t, report.BuildStatusSuccess, res.BuildReports[0].BuildStatus)
+
+	builds, err := os.ReadDir(path.Join(mock.ReportsDir, builder.ID))
+	require.NoError(t, err)
+	require.Len(t, builds, 1)
+
+	content, err := os.ReadFile(path.Join(mock.ReportsDir, builder.ID, builds[0].Name()))
+	require.NoError(t, err)
+
+	var opts struct{ Provenance *types.ProvenanceOpts }
+	require.NoError(t, json.Unmarshal(content, &opts))
+	require.NotNil(t, opts.Provenance)
+	assert.Equal(t, provenance.ModeMax, opts.Provenance.Mode)
+	assert.Equal(t, "v1.0.0", opts.Provenance.Metadata["dib:version"])
+	assert.Equal(t, "echo-foxtrot-golf-hotel", opts.Provenance.Metadata["dib:hash"])
+	assert.Equal(t, parent.Image.DockerRef("alpha-bravo-charlie-delta"), opts.Provenance.Metadata["dib:parents"])
+}
+
+func TestRebuildGraph_SignatureVerification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		parentRebuilt  bool
+		baseTag        string
+		buildArgs      map[string]string
+		unsigned       []string
+		ignore         []string
+		expBuildStatus report.BuildStatus
+		expFailure     string
+		expVerified    []string
+	}{
+		{
+			name:           "all base images signed",
+			expBuildStatus: report.BuildStatusSuccess,
+			expVerified:    []string{"registry.example.org/parent:alpha-bravo-charlie-delta", "debian:bookworm"},
+		},
+		{
+			name:           "parent image unsigned",
+			unsigned:       []string{"registry.example.org/parent:alpha-bravo-charlie-delta"},
+			expBuildStatus: report.BuildStatusError,
+			expFailure: "parent image parent is not trusted: no valid signature found for " +
+				"registry.example.org/parent:alpha-bravo-charlie-delta",
+			expVerified: []string{"registry.example.org/parent:alpha-bravo-charlie-delta"},
+		},
+		{
+			name:           "external base image unsigned",
+			unsigned:       []string{"debian:bookworm"},
+			expBuildStatus: report.BuildStatusError,
+			expFailure:     "base image debian:bookworm is not trusted: no valid signature found for debian:bookworm",
+			expVerified:    []string{"registry.example.org/parent:alpha-bravo-charlie-delta", "debian:bookworm"},
+		},
+		{
+			name:           "external base image ignored",
+			unsigned:       []string{"debian:bookworm"},
+			ignore:         []string{"debian"},
+			expBuildStatus: report.BuildStatusSuccess,
+			expVerified:    []string{"registry.example.org/parent:alpha-bravo-charlie-delta"},
+		},
+		{
+			name:           "parent image rebuilt in the same run",
+			parentRebuilt:  true,
+			unsigned:       []string{"registry.example.org/parent:alpha-bravo-charlie-delta"},
+			expBuildStatus: report.BuildStatusSuccess,
+			expVerified:    []string{"debian:bookworm"},
+		},
+		{
+			name:           "external base image tag depends on a build argument",
+			baseTag:        "${TAG}",
+			buildArgs:      map[string]string{"TAG": "bookworm"},
+			expBuildStatus: report.BuildStatusSuccess,
+			expVerified:    []string{"registry.example.org/parent:alpha-bravo-charlie-delta", "debian:bookworm"},
+		},
+		{
+			name:           "external base image tag depends on a build argument without value",
+			baseTag:        "${TAG}",
+			expBuildStatus: report.BuildStatusError,
+			expFailure: "cannot verify the signature: base image \"debian:${TAG}\" depends on the build argument " +
+				"\"TAG\", which has no value",
+			expVerified: []string{"registry.example.org/parent:alpha-bravo-charlie-delta"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			parent := dag.NewNode(&dag.Image{
+				Name:         "registry.example.org/parent",
+				ShortName:    "parent",
+				Hash:         "alpha-bravo-charlie-delta",
+				Dockerfile:   &testDockerfile,
+				NeedsRebuild: test.parentRebuilt,
+			})
+
+			baseTag := test.baseTag
+			if baseTag == "" {
+				baseTag = "bookworm"
+			}
+
+			childDockerfile := testDockerfile
+			childDockerfile.From = []dockerfile.ImageRef{
+				{Name: "registry.example.org/parent", Tag: "latest"},
+				{Name: "debian", Tag: baseTag},
+			}
+			child := dag.NewNode(&dag.Image{
+				Name:         "registry.example.org/child",
+				ShortName:    "child",
+				Hash:         "echo-foxtrot-golf-hotel",
+				Dockerfile:   &childDockerfile,
+				NeedsRebuild: true,
+			})
+			parent.AddChild(child)
+
+			graph := &dag.DAG{}
+			graph.AddNode(parent)
+
+			verifier := &mock.SignatureVerifier{Unsigned: test.unsigned}
+			dibBuilder := dib.Builder{
+				Graph:             graph,
+				SignatureVerifier: verifier,
+				BuildOpts: dib.BuildOpts{
+					ReportsDir: mock.ReportsDir,
+					Signing:    signing.Config{Verify: signing.VerifyConfig{Enabled: true, Ignore: test.ignore}},
+				},
+			}
+
+			res := dibBuilder.RebuildGraph(context.Background(), mock.NewBuilder(), mock.RateLimiter{},
+				test.buildArgs)
+
+			var childReport report.BuildReport
+
+			for _, buildReport := range res.BuildReports {
+				if buildReport.Image.ShortName == "child" {
+					childReport = buildReport
+				}
+			}
+
+			assert.Equal(t, test.expBuildStatus, childReport.BuildStatus)
+			assert.Equal(t, test.expFailure, childReport.FailureMessage)
+			assert.Equal(t, test.expVerified, verifier.Verified)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package dib
 
 import (
 	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/logger"
 	"github.com/radiofrance/dib/pkg/types"
 )
 
@@ -11,4 +12,14 @@ type Builder struct {
 	Version     string
 	Graph       *dag.DAG
 	TestRunners []types.TestRunner
+	// SBOMProvider generates and attaches the SBOM of the built images. It is nil when SBOMs are disabled.
+	SBOMProvider types.SBOMProvider
+	// SignatureVerifier verifies the signatures of the base images before each build. It is nil when the
+	// verification is disabled.
+	SignatureVerifier types.SignatureVerifier
+}
+
+// imageLogger returns a logger carrying the name and hash of the image as contextual fields.
+func imageLogger(img *dag.Image) *logger.Entry {
+	return logger.With("image", img.ShortName, "hash", img.Hash)
 }
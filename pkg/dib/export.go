@@ -0,0 +1,172 @@
+package dib
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+type ExportOpts struct {
+	// Root options
+	BuildPath          string `mapstructure:"build_path"`
+	RegistryURL        string `mapstructure:"registry_url"`
+	NameTemplate       string `mapstructure:"name_template"`
+	NameFromDirectory  bool   `mapstructure:"name_from_directory"`
+	HashListFilePath   string `mapstructure:"hash_list_file_path"`
+	ResolveBaseDigests bool   `mapstructure:"resolve_base_digests"`
+
+	// Export specific options
+	Archive  string   `mapstructure:"archive"`
+	BuildArg []string `mapstructure:"build_arg,omitempty"`
+}
+
+type ImportOpts struct {
+	// Import specific options
+	Archive string `mapstructure:"archive"`
+	To      string `mapstructure:"to"`
+	DryRun  bool   `mapstructure:"dry_run"`
+}
+
+// Export adds the current hash of every image of the graph to the archive, along with the external base images
+// they are built from, so the whole graph can be imported in another registry. dib images are stored in the
+// repository named after their short name, and base images in the repository of their upstream registry
+// (e.g. "library/debian"). The build arguments of the base images are expanded with buildArgs.
+func Export(
+	graph *dag.DAG,
+	archive types.ImageArchive,
+	buildArgs map[string]string,
+) ([]types.ArchivedImage, error) {
+	var exported []types.ArchivedImage
+
+	add := func(imageRef string, image types.ArchivedImage) error {
+		logger.Debugf("Exporting \"%s\"", imageRef)
+
+		archived, err := archive.Add(imageRef, image)
+		if err != nil {
+			return err
+		}
+
+		exported = append(exported, archived)
+
+		return nil
+	}
+
+	// Base image refs are checked first, so nothing is copied when one of them cannot be exported.
+	bases, err := exportedBaseImages(graph, buildArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	baseImages := make([]types.ArchivedImage, 0, len(bases))
+
+	for _, base := range bases {
+		repository, err := name.NewRepository(base.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base image %s: %w", imageRefString(base), err)
+		}
+
+		tag := base.Tag
+		if tag == "" && base.Digest == "" {
+			tag = defaultBaseImageTag
+		}
+
+		baseImages = append(baseImages, types.ArchivedImage{Repository: repository.RepositoryStr(), Tag: tag})
+	}
+
+	err = graph.WalkErr(func(node *dag.Node) error {
+		img := node.Image
+
+		return add(img.DockerRef(img.Hash), types.ArchivedImage{Repository: img.ShortName, Tag: img.Hash})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot export images: %w", err)
+	}
+
+	for i, base := range bases {
+		err = add(imageRefString(base), baseImages[i])
+		if err != nil {
+			return nil, fmt.Errorf("cannot export base image: %w", err)
+		}
+	}
+
+	return exported, nil
+}
+
+// exportedBaseImages returns the external base images of the graph, with their build arguments expanded, sorted by
+// reference.
+func exportedBaseImages(graph *dag.DAG, buildArgs map[string]string) ([]dockerfile.ImageRef, error) {
+	bases := make(map[string]dockerfile.ImageRef)
+
+	err := graph.WalkErr(func(node *dag.Node) error {
+		for _, ref := range nodeBaseImages(node) {
+			expanded, err := expandBuildArgs(ref, node.Image.Dockerfile, buildArgs)
+			if err != nil {
+				return fmt.Errorf("cannot export base image: %w", err)
+			}
+
+			bases[imageRefString(expanded)] = expanded
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refs := slices.Sorted(maps.Keys(bases))
+
+	list := make([]dockerfile.ImageRef, 0, len(refs))
+	for _, ref := range refs {
+		list = append(list, bases[ref])
+	}
+
+	return list, nil
+}
+
+// Import pushes all the images of the archive to the destination registry, keeping their repository and tag.
+// Images without tag are pushed by digest. Manifests are pushed as is, so the images keep their digests.
+// When dryRun is true, the pushes are only logged. It returns the refs of the pushed images.
+func Import(archive types.ImageArchive, to string, dryRun bool) ([]string, error) {
+	to = strings.TrimSuffix(to, "/")
+
+	images, err := archive.Images()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive: %w", err)
+	}
+
+	slices.SortFunc(images, func(a, b types.ArchivedImage) int {
+		return cmp.Or(cmp.Compare(a.Repository, b.Repository), cmp.Compare(a.Tag, b.Tag))
+	})
+
+	imported := make([]string, 0, len(images))
+
+	for _, image := range images {
+		destRef := fmt.Sprintf("%s/%s@%s", to, image.Repository, image.Digest)
+		if image.Tag != "" {
+			destRef = fmt.Sprintf("%s/%s:%s", to, image.Repository, image.Tag)
+		}
+
+		if dryRun {
+			logger.Infof("[DRY-RUN] Importing \"%s\"", destRef)
+		} else {
+			logger.Infof("Importing \"%s\"", destRef)
+
+			err := archive.Push(image, destRef)
+			if err != nil {
+				return nil, fmt.Errorf("cannot import %s: %w", destRef, err)
+			}
+		}
+
+		imported = append(imported, destRef)
+	}
+
+	return imported, nil
+}
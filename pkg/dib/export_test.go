@@ -0,0 +1,142 @@
+package dib_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Export(t *testing.T) {
+	t.Parallel()
+
+	archive := &mock.ImageArchive{
+		Digests: map[string]string{
+			"test-registry/bullseye:floor-venus-august-venus":  "sha256:bullseye",
+			"test-registry/golang:cup-neptune-snake-thirteen":  "sha256:golang",
+			"test-registry/alpine:blue-bulldog-fourteen-angel": "sha256:alpine",
+			"alpine:3.17":            "sha256:alpine-upstream",
+			"debian:11.6":            "sha256:debian-upstream",
+			"golang:1.26@sha256:old": "sha256:old",
+		},
+	}
+
+	exported, err := dib.Export(setupBasesDag(t), archive, nil)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []types.ArchivedImage{
+		{Repository: "bullseye", Tag: "floor-venus-august-venus", Digest: "sha256:bullseye"},
+		{Repository: "golang", Tag: "cup-neptune-snake-thirteen", Digest: "sha256:golang"},
+		{Repository: "alpine", Tag: "blue-bulldog-fourteen-angel", Digest: "sha256:alpine"},
+		{Repository: "library/alpine", Tag: "3.17", Digest: "sha256:alpine-upstream"},
+		{Repository: "library/debian", Tag: "11.6", Digest: "sha256:debian-upstream"},
+		{Repository: "library/golang", Tag: "1.26", Digest: "sha256:old"},
+	}, exported)
+	assert.Equal(t, exported, archive.Archived)
+}
+
+func Test_Export_ExpandsBuildArgs(t *testing.T) {
+	t.Parallel()
+
+	graph := setupBasesDag(t)
+	graph.Nodes()[0].Image.Dockerfile.From = []dockerfile.ImageRef{{Name: "debian", Tag: "${DEBIAN_VERSION}"}}
+
+	archive := &mock.ImageArchive{
+		Digests: map[string]string{
+			"test-registry/bullseye:floor-venus-august-venus":  "sha256:bullseye",
+			"test-registry/golang:cup-neptune-snake-thirteen":  "sha256:golang",
+			"test-registry/alpine:blue-bulldog-fourteen-angel": "sha256:alpine",
+			"alpine:3.17":            "sha256:alpine-upstream",
+			"debian:11.6":            "sha256:debian-upstream",
+			"golang:1.26@sha256:old": "sha256:old",
+		},
+	}
+
+	exported, err := dib.Export(graph, archive, map[string]string{"DEBIAN_VERSION": "11.6"})
+	require.NoError(t, err)
+	assert.Contains(t, exported,
+		types.ArchivedImage{Repository: "library/debian", Tag: "11.6", Digest: "sha256:debian-upstream"})
+}
+
+func Test_Export_FailsWhenImageIsNotBuilt(t *testing.T) {
+	t.Parallel()
+
+	_, err := dib.Export(setupBasesDag(t), &mock.ImageArchive{}, nil)
+	require.ErrorContains(t, err, "manifest unknown")
+}
+
+func Test_Export_FailsOnBaseImageWithBuildArgWithoutValue(t *testing.T) {
+	t.Parallel()
+
+	graph := setupBasesDag(t)
+	graph.Nodes()[0].Image.Dockerfile.From = []dockerfile.ImageRef{{Name: "debian", Tag: "${DEBIAN_VERSION}"}}
+
+	archive := &mock.ImageArchive{
+		Digests: map[string]string{
+			"test-registry/bullseye:floor-venus-august-venus":  "sha256:bullseye",
+			"test-registry/golang:cup-neptune-snake-thirteen":  "sha256:golang",
+			"test-registry/alpine:blue-bulldog-fourteen-angel": "sha256:alpine",
+		},
+	}
+
+	_, err := dib.Export(graph, archive, nil)
+	require.ErrorContains(t, err,
+		"base image \"debian:${DEBIAN_VERSION}\" depends on the build argument \"DEBIAN_VERSION\", which has no value")
+}
+
+func Test_Import(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		expImported []string
+		expPushed   int
+	}{
+		{
+			name: "push all images",
+			expImported: []string{
+				"site.example.org/bullseye:floor-venus-august-venus",
+				"site.example.org/library/debian:11.6",
+				"site.example.org/library/golang@sha256:old",
+			},
+			expPushed: 3,
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+			expImported: []string{
+				"site.example.org/bullseye:floor-venus-august-venus",
+				"site.example.org/library/debian:11.6",
+				"site.example.org/library/golang@sha256:old",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			archive := &mock.ImageArchive{
+				Archived: []types.ArchivedImage{
+					{Repository: "library/golang", Digest: "sha256:old"},
+					{Repository: "library/debian", Tag: "11.6", Digest: "sha256:debian"},
+					{Repository: "bullseye", Tag: "floor-venus-august-venus", Digest: "sha256:bullseye"},
+				},
+			}
+
+			imported, err := dib.Import(archive, "site.example.org/", test.dryRun)
+			require.NoError(t, err)
+			assert.Equal(t, test.expImported, imported)
+			require.Len(t, archive.RecordedCallsArgs, test.expPushed)
+
+			for i, call := range archive.RecordedCallsArgs {
+				assert.Equal(t, test.expImported[i], call.DestRef)
+			}
+		})
+	}
+}
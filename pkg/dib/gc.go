@@ -0,0 +1,326 @@
+package dib
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+// devTagPrefix is the prefix of the tags of the images being rebuilt (see dag.Image.CurrentRef).
+const devTagPrefix = "dev-"
+
+type GCOpts struct {
+	// Root options
+	BuildPath          string `mapstructure:"build_path"`
+	RegistryURL        string `mapstructure:"registry_url"`
+	NameTemplate       string `mapstructure:"name_template"`
+	NameFromDirectory  bool   `mapstructure:"name_from_directory"`
+	PlaceholderTag     string `mapstructure:"placeholder_tag"`
+	HashListFilePath   string `mapstructure:"hash_list_file_path"`
+	ResolveBaseDigests bool   `mapstructure:"resolve_base_digests"`
+
+	// GC specific options
+	BuildArg          []string `mapstructure:"build_arg,omitempty"`
+	ExtraRegistryURLs []string `mapstructure:"extra_registry_urls"`
+	Keep              int      `mapstructure:"keep"`
+	DryRun            bool     `mapstructure:"dry_run"`
+}
+
+// RemovedTag describes a tag removed from the registry by the garbage collection.
+type RemovedTag struct {
+	Image  string
+	Tag    string
+	Digest string
+}
+
+// staleTag is a tag generated by dib, which is not reachable from the current state of the build path.
+type staleTag struct {
+	tag     string
+	hash    string
+	created time.Time
+}
+
+// CollectGarbage removes the stale tags generated by dib ("<hash>" and "dev-<hash>") from the repositories of every
+// image of the graph, in the primary and extra registries. The tags of the current hash, the extra tags, the placeholder tag, and the tags of the last
+// `keep` hashes of each image are kept. Tags not generated by dib are never removed: a hash tag is made of words
+// of the humanhash word list, or of the custom hash list at customHashListPath for images using it.
+//
+// Registries delete manifests, along with all the tags pointing to them, so a stale tag pointing to the same
+// manifest as a kept tag cannot be removed, and is left untouched. The cosign signatures of the removed manifests
+// are removed as well. When dryRun is true, the tags are only reported.
+func CollectGarbage(
+	graph *dag.DAG,
+	registry types.CleanableRegistry,
+	placeholderTag, customHashListPath string,
+	keep int,
+	dryRun bool,
+) ([]RemovedTag, error) {
+	var customHashList []string
+
+	if customHashListPath != "" {
+		var err error
+
+		customHashList, err = loadCustomHashList(customHashListPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load custom humanized hash list: %w", err)
+		}
+	}
+
+	var removed []RemovedTag
+
+	err := graph.WalkErr(func(node *dag.Node) error {
+		for _, name := range node.Image.Names() {
+			removedTags, err := collectImageGarbage(node.Image, name, registry, placeholderTag, customHashList,
+				keep, dryRun)
+			if err != nil {
+				return fmt.Errorf("cannot collect garbage of %s: %w", name, err)
+			}
+
+			removed = append(removed, removedTags...)
+		}
+
+		return nil
+	})
+
+	slices.SortFunc(removed, func(a, b RemovedTag) int {
+		return cmp.Or(cmp.Compare(a.Image, b.Image), cmp.Compare(a.Tag, b.Tag))
+	})
+
+	return removed, err
+}
+
+// collectImageGarbage removes the stale tags of the image from its repository with the given name.
+func collectImageGarbage(
+	img *dag.Image,
+	name string,
+	registry types.CleanableRegistry,
+	placeholderTag string,
+	customHashList []string,
+	keep int,
+	dryRun bool,
+) ([]RemovedTag, error) {
+	hashWords := hashWordList(img, customHashList)
+
+	tags, err := registry.Tags(name)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := map[string]struct{}{
+		img.Hash:                {},
+		devTagPrefix + img.Hash: {},
+		placeholderTag:          {},
+	}
+	for _, extraTag := range img.ExtraTags {
+		kept[extraTag] = struct{}{}
+	}
+
+	stale, err := staleTags(name, registry, tags, hashWords, kept)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tags of the last hashes are kept.
+	keptHashes := map[string]struct{}{}
+	for _, tag := range stale {
+		if len(keptHashes) >= keep {
+			break
+		}
+
+		keptHashes[tag.hash] = struct{}{}
+	}
+
+	var toRemove []staleTag
+
+	for _, tag := range stale {
+		if _, ok := keptHashes[tag.hash]; ok {
+			kept[tag.tag] = struct{}{}
+			continue
+		}
+
+		toRemove = append(toRemove, tag)
+	}
+
+	if len(toRemove) == 0 {
+		return nil, nil
+	}
+
+	keptDigests := map[string]struct{}{}
+
+	for _, tag := range tags {
+		if _, ok := kept[tag]; !ok {
+			if _, ok := hashFromTag(tag, hashWords); ok {
+				continue
+			}
+		}
+
+		digest, err := registry.Digest(name + ":" + tag)
+		if err != nil {
+			return nil, err
+		}
+
+		keptDigests[digest] = struct{}{}
+	}
+
+	var removed []RemovedTag
+
+	for _, tag := range toRemove {
+		digest, err := registry.Digest(name + ":" + tag.tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := keptDigests[digest]; ok {
+			imageLogger(img).Debugf("Keeping tag %q, as it points to the same manifest as a kept tag", tag.tag)
+			continue
+		}
+
+		removed = append(removed, RemovedTag{Image: name, Tag: tag.tag, Digest: digest})
+	}
+
+	return removed, removeDigests(img, name, registry, tags, removed, dryRun)
+}
+
+// staleTags returns the tags generated by dib which are not kept, from the most recent to the oldest.
+func staleTags(
+	name string,
+	registry types.CleanableRegistry,
+	tags, hashWords []string,
+	kept map[string]struct{},
+) ([]staleTag, error) {
+	var stale []staleTag
+
+	for _, tag := range tags {
+		if _, ok := kept[tag]; ok {
+			continue
+		}
+
+		hash, ok := hashFromTag(tag, hashWords)
+		if !ok {
+			continue
+		}
+
+		created, err := registry.Created(name + ":" + tag)
+		if err != nil {
+			return nil, err
+		}
+
+		stale = append(stale, staleTag{tag: tag, hash: hash, created: created})
+	}
+
+	slices.SortStableFunc(stale, func(a, b staleTag) int {
+		return cmp.Or(b.created.Compare(a.created), cmp.Compare(a.tag, b.tag))
+	})
+
+	return stale, nil
+}
+
+// removeDigests deletes the manifests of the removed tags, and their cosign signatures.
+func removeDigests(
+	img *dag.Image,
+	name string,
+	registry types.CleanableRegistry,
+	tags []string,
+	removed []RemovedTag,
+	dryRun bool,
+) error {
+	deleted := map[string]struct{}{}
+
+	for _, tag := range removed {
+		if _, ok := deleted[tag.Digest]; ok {
+			continue
+		}
+
+		deleted[tag.Digest] = struct{}{}
+
+		refs := []string{name + "@" + tag.Digest}
+
+		signatureTag := strings.Replace(tag.Digest, ":", "-", 1) + ".sig"
+		if slices.Contains(tags, signatureTag) {
+			// Tags cannot be deleted by all registries, so the signature is deleted by digest as well.
+			signatureDigest, err := registry.Digest(name + ":" + signatureTag)
+			if err != nil {
+				return err
+			}
+
+			refs = append(refs, name+"@"+signatureDigest)
+		}
+
+		for _, ref := range refs {
+			if dryRun {
+				logger.Infof("[DRY-RUN] Deleting \"%s\"", ref)
+				continue
+			}
+
+			imageLogger(img).Debugf("Deleting \"%s\"", ref)
+
+			err := registry.Delete(ref)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RenderRemovedTags prints the tags removed by the garbage collection as a table.
+func RenderRemovedTags(removed []RemovedTag) error {
+	if len(removed) == 0 {
+		logger.Infof("No stale tag found")
+		return nil
+	}
+
+	table := tablewriter.NewTable(os.Stdout,
+		tablewriter.WithConfig(tablewriter.Config{
+			Header: tw.CellConfig{
+				Alignment: tw.CellAlignment{Global: tw.AlignLeft},
+			},
+			Row: tw.CellConfig{
+				Formatting: tw.CellFormatting{AutoWrap: tw.WrapNone},
+			},
+		}),
+	)
+
+	data := make([][]string, 0, len(removed))
+	for _, tag := range removed {
+		data = append(data, []string{tag.Image, tag.Tag, tag.Digest})
+	}
+
+	err := table.Bulk(data)
+	if err != nil {
+		return err
+	}
+
+	table.Header([]string{"Image", "Tag", "Digest"})
+
+	return table.Render()
+}
+
+// hashFromTag returns the hash of a tag generated by dib, either "<hash>" or "dev-<hash>", where the hash is made of
+// words of the hash word list.
+func hashFromTag(tag string, hashWords []string) (string, bool) {
+	hash := strings.TrimPrefix(tag, devTagPrefix)
+
+	words := strings.Split(hash, "-")
+	if len(words) != humanizedHashWordLength {
+		return "", false
+	}
+
+	for _, word := range words {
+		if !slices.Contains(hashWords, word) {
+			return "", false
+		}
+	}
+
+	return hash, true
+}
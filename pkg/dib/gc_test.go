@@ -0,0 +1,179 @@
+package dib_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGCTestRegistry() *mock.CleanableRegistry {
+	now := time.Now()
+
+	return &mock.CleanableRegistry{
+		Repositories: map[string]map[string]string{
+			"registry.example.org/app": {
+				"alpha-bravo-charlie-delta":     "sha256:current",
+				"dev-alpha-bravo-charlie-delta": "sha256:current",
+				"latest":                        "sha256:current",
+				"1.0.0":                         "sha256:release",
+				"echo-foxtrot-golf-hotel":       "sha256:release",
+				"india-juliet-kilo-lima":        "sha256:old1",
+				"dev-india-juliet-kilo-lima":    "sha256:old1",
+				"mike-november-oscar-papa":      "sha256:old2",
+				"sha256-old2.sig":               "sha256:signature2",
+				"dev-quebec-romeo-sierra-tango": "sha256:old3",
+				"custom":                        "sha256:custom",
+				"2024-10-01-rc":                 "sha256:dated",
+				"v1-2-3-hotfix":                 "sha256:hotfix",
+			},
+		},
+		CreatedAt: map[string]time.Time{
+			"sha256:current": now,
+			"sha256:old1":    now.Add(-24 * time.Hour),
+			"sha256:old2":    now.Add(-48 * time.Hour),
+			"sha256:old3":    now.Add(-72 * time.Hour),
+			"sha256:release": now.Add(-240 * time.Hour),
+			"sha256:dated":   now.Add(-480 * time.Hour),
+			"sha256:hotfix":  now.Add(-480 * time.Hour),
+		},
+	}
+}
+
+func newGCTestGraph() *dag.DAG {
+	graph := &dag.DAG{}
+	graph.AddNode(dag.NewNode(&dag.Image{
+		Name:      "registry.example.org/app",
+		ShortName: "app",
+		Hash:      "alpha-bravo-charlie-delta",
+		ExtraTags: []string{"1.0.0"},
+	}))
+
+	return graph
+}
+
+func Test_CollectGarbage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		keep       int
+		dryRun     bool
+		expRemoved []dib.RemovedTag
+		expDeleted []string
+	}{
+		{
+			name: "keep the last hash",
+			keep: 1,
+			expRemoved: []dib.RemovedTag{
+				{Image: "registry.example.org/app", Tag: "dev-quebec-romeo-sierra-tango", Digest: "sha256:old3"},
+				{Image: "registry.example.org/app", Tag: "mike-november-oscar-papa", Digest: "sha256:old2"},
+			},
+			expDeleted: []string{
+				"registry.example.org/app@sha256:old2",
+				"registry.example.org/app@sha256:signature2",
+				"registry.example.org/app@sha256:old3",
+			},
+		},
+		{
+			name: "keep no previous hash",
+			keep: 0,
+			expRemoved: []dib.RemovedTag{
+				{Image: "registry.example.org/app", Tag: "dev-india-juliet-kilo-lima", Digest: "sha256:old1"},
+				{Image: "registry.example.org/app", Tag: "dev-quebec-romeo-sierra-tango", Digest: "sha256:old3"},
+				{Image: "registry.example.org/app", Tag: "india-juliet-kilo-lima", Digest: "sha256:old1"},
+				{Image: "registry.example.org/app", Tag: "mike-november-oscar-papa", Digest: "sha256:old2"},
+			},
+			expDeleted: []string{
+				"registry.example.org/app@sha256:old1",
+				"registry.example.org/app@sha256:old2",
+				"registry.example.org/app@sha256:signature2",
+				"registry.example.org/app@sha256:old3",
+			},
+		},
+		{
+			name:   "dry run",
+			keep:   1,
+			dryRun: true,
+			expRemoved: []dib.RemovedTag{
+				{Image: "registry.example.org/app", Tag: "dev-quebec-romeo-sierra-tango", Digest: "sha256:old3"},
+				{Image: "registry.example.org/app", Tag: "mike-november-oscar-papa", Digest: "sha256:old2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := newGCTestRegistry()
+
+			removed, err := dib.CollectGarbage(newGCTestGraph(), registry, "latest", "", test.keep, test.dryRun)
+			require.NoError(t, err)
+			assert.Equal(t, test.expRemoved, removed)
+			assert.Equal(t, test.expDeleted, registry.Deleted)
+
+			// The kept tags are still in the registry.
+			tags, err := registry.Tags("registry.example.org/app")
+			require.NoError(t, err)
+			assert.Subset(t, tags, []string{
+				"alpha-bravo-charlie-delta", "dev-alpha-bravo-charlie-delta", "latest", "1.0.0",
+				"echo-foxtrot-golf-hotel", "custom", "2024-10-01-rc", "v1-2-3-hotfix",
+			})
+		})
+	}
+}
+
+func Test_CollectGarbage_FailsWhenRepositoryCannotBeListed(t *testing.T) {
+	t.Parallel()
+
+	registry := &mock.CleanableRegistry{}
+
+	_, err := dib.CollectGarbage(newGCTestGraph(), registry, "latest", "", 1, false)
+	require.ErrorContains(t, err, "cannot collect garbage of registry.example.org/app")
+}
+
+func Test_CollectGarbage_CleansExtraRegistries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	registry := &mock.CleanableRegistry{
+		Repositories: map[string]map[string]string{
+			"registry.example.org/app": {
+				"alpha-bravo-charlie-delta": "sha256:current",
+				"india-juliet-kilo-lima":    "sha256:old1",
+			},
+			"mirror.example.org/app": {
+				"alpha-bravo-charlie-delta": "sha256:current",
+				"india-juliet-kilo-lima":    "sha256:old1",
+				"custom":                    "sha256:custom",
+			},
+		},
+		CreatedAt: map[string]time.Time{
+			"sha256:current": now,
+			"sha256:old1":    now.Add(-24 * time.Hour),
+		},
+	}
+
+	graph := newGCTestGraph()
+	dib.SetExtraRegistries(graph, []string{"mirror.example.org"})
+
+	removed, err := dib.CollectGarbage(graph, registry, "latest", "", 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, []dib.RemovedTag{
+		{Image: "mirror.example.org/app", Tag: "india-juliet-kilo-lima", Digest: "sha256:old1"},
+		{Image: "registry.example.org/app", Tag: "india-juliet-kilo-lima", Digest: "sha256:old1"},
+	}, removed)
+	assert.ElementsMatch(t, []string{
+		"registry.example.org/app@sha256:old1",
+		"mirror.example.org/app@sha256:old1",
+	}, registry.Deleted)
+
+	tags, err := registry.Tags("mirror.example.org/app")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alpha-bravo-charlie-delta", "custom"}, tags)
+}
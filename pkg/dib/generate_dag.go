@@ -2,6 +2,7 @@ package dib
 
 import (
 	"bufio"
+	"context"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -17,18 +18,35 @@ import (
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/tracing"
+	"github.com/radiofrance/dib/pkg/types"
 	"github.com/wolfeidau/humanhash"
 )
 
 const (
 	dockerignore            = ".dockerignore"
 	humanizedHashWordLength = 4
+	// extraRegistriesLabel opts an image out of the extra registries when set to "false".
+	extraRegistriesLabel = "dib.extra-registries"
 )
 
 // GenerateDAG discovers and parses all Dockerfiles at a given path,
 // and generates the DAG representing the relationships between images.
-func GenerateDAG(buildPath, registryPrefix, customHashListPath string, buildArgs map[string]string) (*dag.DAG, error) {
-	graph, err := buildGraph(buildPath, registryPrefix)
+// When upstream is not nil, the digests of the external base images are resolved and included in the hashes,
+// so images are rebuilt when their base images are updated upstream.
+// The names of the images are computed with the naming, from their labels and their path in the build path.
+func GenerateDAG(
+	ctx context.Context,
+	buildPath string,
+	naming Naming,
+	customHashListPath string,
+	buildArgs map[string]string,
+	upstream types.UpstreamRegistry,
+) (_ *dag.DAG, err error) {
+	ctx, span := tracing.Start(ctx, "generate_dag")
+	defer func() { tracing.End(span, err) }()
+
+	graph, err := buildGraph(buildPath, naming)
 	if err != nil {
 		return nil, err
 	}
@@ -41,19 +59,45 @@ func GenerateDAG(buildPath, registryPrefix, customHashListPath string, buildArgs
 		}
 	}
 
-	return computeHashes(graph, customHashList, buildArgs)
+	return computeHashes(ctx, graph, customHashList, buildArgs, newDigestResolver(upstream))
 }
 
-func buildGraph(buildPath, registryPrefix string) (*dag.DAG, error) {
+// SetExtraRegistries sets the names of the images in the extra registries, where they are pushed and tagged in
+// addition to the primary registry. Images whose Dockerfile has the label "dib.extra-registries" set to "false"
+// are only pushed to the primary registry.
+func SetExtraRegistries(graph *dag.DAG, registryPrefixes []string) {
+	graph.Walk(func(node *dag.Node) {
+		img := node.Image
+		if img.Dockerfile != nil && img.Dockerfile.Labels[extraRegistriesLabel] == "false" {
+			return
+		}
+
+		img.Mirrors = nil
+
+		for _, registryPrefix := range registryPrefixes {
+			mirror := fmt.Sprintf("%s/%s", strings.TrimSuffix(registryPrefix, "/"), img.ShortName)
+			if mirror != img.Name && !slices.Contains(img.Mirrors, mirror) {
+				img.Mirrors = append(img.Mirrors, mirror)
+			}
+		}
+	})
+}
+
+func buildGraph(buildPath string, naming Naming) (*dag.DAG, error) {
+	namer, err := naming.newImageNamer(buildPath)
+	if err != nil {
+		return nil, err
+	}
+
 	nodes := make(map[string]*dag.Node)
 
-	err := filepath.WalkDir(buildPath, func(name string, dir os.DirEntry, err error) error {
+	err = filepath.WalkDir(buildPath, func(name string, dir os.DirEntry, err error) error {
 		switch {
 		case err != nil:
 			return err
 		case dir.IsDir():
 		case dockerfile.IsDockerfile(name):
-			img, err := newImageFromDockerfile(name, registryPrefix)
+			img, err := newImageFromDockerfile(name, namer)
 			if err != nil {
 				return err
 			}
@@ -81,7 +125,7 @@ func buildGraph(buildPath, registryPrefix string) (*dag.DAG, error) {
 	return newGraphFromNodes(nodes), nil
 }
 
-func newImageFromDockerfile(filePath, registryPrefix string) (*dag.Image, error) {
+func newImageFromDockerfile(filePath string, namer *imageNamer) (*dag.Image, error) {
 	dckfile, err := dockerfile.ParseDockerfile(filePath)
 	if err != nil {
 		return nil, err
@@ -94,13 +138,12 @@ func newImageFromDockerfile(filePath, registryPrefix string) (*dag.Image, error)
 		skipBuild = true
 	}
 
-	shortName, hasNameLabel := dckfile.Labels["name"]
-	if !skipBuild && !hasNameLabel {
-		return nil, fmt.Errorf("missing label \"name\" in Dockerfile at path %q", filePath)
+	// Images which are not built do not need a name.
+	imageName, shortName, err := namer.imageName(dckfile)
+	if err != nil && !skipBuild {
+		return nil, fmt.Errorf("%w in Dockerfile at path %q", err, filePath)
 	}
 
-	imageName := fmt.Sprintf("%s/%s", registryPrefix, shortName)
-
 	var extraTags []string
 
 	value, hasLabel := dckfile.Labels["dib.extra-tags"]
@@ -210,13 +253,26 @@ func newGraphFromNodes(nodes map[string]*dag.Node) *dag.DAG {
 	return graph
 }
 
-func computeHashes(graph *dag.DAG, customHashList []string, buildArgs map[string]string) (*dag.DAG, error) {
+func computeHashes(
+	ctx context.Context,
+	graph *dag.DAG,
+	customHashList []string,
+	buildArgs map[string]string,
+	resolver *digestResolver,
+) (*dag.DAG, error) {
 	currNodes := graph.Nodes()
 	for len(currNodes) > 0 {
 		for _, node := range currNodes {
+			_, span := tracing.Start(ctx, "compute_hash",
+				tracing.AttributeImageName.String(node.Image.Name))
+
 			var err error
 
-			node.Image.Hash, err = computeNodeHash(node, customHashList, buildArgs)
+			node.Image.Hash, err = computeNodeHash(node, customHashList, buildArgs, resolver)
+
+			span.SetAttributes(tracing.AttributeImageHash.String(node.Image.Hash))
+			tracing.End(span, err)
+
 			if err != nil {
 				return nil, fmt.Errorf("could not compute hash for image %q: %w", node.Image.Name, err)
 			}
@@ -233,17 +289,30 @@ func computeHashes(graph *dag.DAG, customHashList []string, buildArgs map[string
 	return graph, nil
 }
 
-func computeNodeHash(node *dag.Node, customHashList []string, buildArgs map[string]string) (string, error) {
+func computeNodeHash(
+	node *dag.Node,
+	customHashList []string,
+	buildArgs map[string]string,
+	resolver *digestResolver,
+) (string, error) {
 	var parentHashes []string
 	for _, parent := range node.Parents() {
 		parentHashes = append(parentHashes, parent.Image.Hash)
 	}
 
-	var hashList []string
-	if node.Image.UseCustomHashList {
-		hashList = customHashList
+	if resolver != nil {
+		baseImageDigests, err := resolver.baseImageDigests(node, buildArgs)
+		if err != nil {
+			return "", err
+		}
+
+		// External base images are hashed like parent images, so the hash changes when they move upstream.
+		node.Image.BaseDigests = baseImageDigests
+		parentHashes = append(parentHashes, baseImageDigests...)
 	}
 
+	hashList := hashWordList(node.Image, customHashList)
+
 	filename := path.Join(node.Image.Dockerfile.ContextPath, node.Image.Dockerfile.Filename)
 
 	argInstructionsToReplace := make(map[string]string)
@@ -329,6 +398,15 @@ func hashFiles(baseDir string, files, parentHashes, hashList []string) (string,
 	return humanReadableHash, nil
 }
 
+// hashWordList returns the list of words used to humanize the hash of the image.
+func hashWordList(img *dag.Image, customHashList []string) []string {
+	if img.UseCustomHashList && len(customHashList) > 0 {
+		return customHashList
+	}
+
+	return humanhash.DefaultWordList
+}
+
 // loadCustomHashList try to load & parse a list of custom humanized hash to use.
 func loadCustomHashList(filepath string) ([]string, error) {
 	file, err := os.Open(filepath) //nolint:gosec
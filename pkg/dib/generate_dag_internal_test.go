@@ -12,6 +12,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -99,7 +100,7 @@ func TestGenerateDAG(t *testing.T) {
 		[]string{hashRoot1, hashRoot2}, nil)
 	require.NoError(t, err)
 
-	graph, err := GenerateDAG(basePath, registryPrefix, "", nil)
+	graph, err := GenerateDAG(t.Context(), basePath, Naming{RegistryURL: registryPrefix}, "", nil, nil)
 	require.NoError(t, err)
 
 	nominalGraph := graph.Sprint(path.Base(basePath))
@@ -129,7 +130,7 @@ func TestGenerateDAG(t *testing.T) {
 		newFilePath := baseDir + "/newfile"
 		require.NoError(t, os.WriteFile(newFilePath, []byte("any content"), 0o600))
 
-		graph, err := GenerateDAG(copiedDir, registryPrefix, "", nil)
+		graph, err := GenerateDAG(t.Context(), copiedDir, Naming{RegistryURL: registryPrefix}, "", nil, nil)
 		require.NoError(t, err)
 
 		have := graph.Sprint(path.Base(copiedDir))
@@ -155,7 +156,7 @@ func TestGenerateDAG(t *testing.T) {
 		newFilePath := baseDir + "/multistage/newfile"
 		require.NoError(t, os.WriteFile(newFilePath, []byte("any content"), 0o600))
 
-		graph, err := GenerateDAG(copiedDir, registryPrefix, "", nil)
+		graph, err := GenerateDAG(t.Context(), copiedDir, Naming{RegistryURL: registryPrefix}, "", nil, nil)
 		require.NoError(t, err)
 
 		have := graph.Sprint(path.Base(copiedDir))
@@ -185,7 +186,7 @@ func TestGenerateDAG(t *testing.T) {
 		}, []string{hashRoot1}, customHashList)
 		require.NoError(t, err)
 
-		graph, err := GenerateDAG(copiedDir, registryPrefix, customHashListPath, nil)
+		graph, err := GenerateDAG(t.Context(), copiedDir, Naming{RegistryURL: registryPrefix}, customHashListPath, nil, nil)
 		require.NoError(t, err)
 
 		// Only the custom-hash-list node, which has the label 'dib.use-custom-hash-list', should change
@@ -228,7 +229,7 @@ func TestGenerateDAG(t *testing.T) {
 
 		require.NoError(t, dockerfile.ReplaceInFile(baseDir+"/Dockerfile", argInstructionsToReplace))
 
-		graph, err := GenerateDAG(copiedDir, registryPrefix, "", buildArgs)
+		graph, err := GenerateDAG(t.Context(), copiedDir, Naming{RegistryURL: registryPrefix}, "", buildArgs, nil)
 		require.NoError(t, err)
 
 		// Only root1 node has the 'HELLO' argument, so its hash and all of its children should change
@@ -246,9 +247,57 @@ func TestGenerateDAG(t *testing.T) {
 		}
 	})
 
+	t.Run("resolving base image digests", func(t *testing.T) {
+		upstream := &mock.UpstreamRegistry{
+			Digests: map[string]string{
+				"debian:bullseye":              "sha256:debian",
+				"apache/superset:latest":       "sha256:superset",
+				"bitnami/elasticsearch:latest": "sha256:elasticsearch",
+				"vault:latest":                 "sha256:vault",
+			},
+		}
+
+		graph, err := GenerateDAG(t.Context(), basePath, Naming{RegistryURL: registryPrefix}, "", nil, upstream)
+		require.NoError(t, err)
+
+		resolvedLines := strings.Split(graph.Sprint(path.Base(basePath)), "\n")
+		assert.Len(t, resolvedLines, len(nominalLines))
+
+		// All images are built from an external base image, so all hashes should change
+		for i := range nominalLines {
+			switch i {
+			case 0, 12:
+				assert.Equal(t, nominalLines[i], resolvedLines[i])
+			default:
+				assert.NotEqual(t, nominalLines[i], resolvedLines[i])
+			}
+		}
+
+		// Only root1 is built from debian, so only its hash and the ones of its children should change
+		upstream.Digests["debian:bullseye"] = "sha256:debian-security-update"
+
+		graph, err = GenerateDAG(t.Context(), basePath, Naming{RegistryURL: registryPrefix}, "", nil, upstream)
+		require.NoError(t, err)
+
+		updatedLines := strings.Split(graph.Sprint(path.Base(basePath)), "\n")
+		for i := range resolvedLines {
+			switch i {
+			case 0, 9, 11, 12:
+				assert.Equal(t, resolvedLines[i], updatedLines[i])
+			default:
+				assert.NotEqual(t, resolvedLines[i], updatedLines[i])
+			}
+		}
+
+		delete(upstream.Digests, "vault:latest")
+
+		_, err = GenerateDAG(t.Context(), basePath, Naming{RegistryURL: registryPrefix}, "", nil, upstream)
+		require.ErrorContains(t, err, "cannot resolve digest of base image \"vault:latest\"")
+	})
+
 	t.Run("duplicates image names", func(t *testing.T) {
 		dupDir := "../../test/fixtures/docker-duplicates"
-		_, err := GenerateDAG(dupDir, registryPrefix, "", nil)
+		_, err := GenerateDAG(t.Context(), dupDir, Naming{RegistryURL: registryPrefix}, "", nil, nil)
 		require.EqualError(t, err,
 			fmt.Sprintf(`duplicate image name "%s/duplicate" found while reading file `+
 				`"%s/root/duplicate2/Dockerfile": previous file was "%s/root/duplicate1/Dockerfile"`,
@@ -272,7 +321,7 @@ func copyFixtures(t *testing.T) string {
 }
 
 func Test_buildGraph(t *testing.T) {
-	graph, err := buildGraph(basePath, registryPrefix)
+	graph, err := buildGraph(basePath, Naming{RegistryURL: registryPrefix})
 	require.NoError(t, err)
 	graph.WalkInDepth(func(node *dag.Node) {
 		files := node.Image.ContextFiles
@@ -326,6 +375,35 @@ func Test_buildGraph(t *testing.T) {
 	})
 }
 
+func Test_SetExtraRegistries(t *testing.T) {
+	graph := &dag.DAG{}
+	mirrored := &dag.Image{
+		Name:       "eu.gcr.io/my-test-repository/mirrored",
+		ShortName:  "mirrored",
+		Dockerfile: &dockerfile.Dockerfile{Labels: map[string]string{}},
+	}
+	optedOut := &dag.Image{
+		Name:       "eu.gcr.io/my-test-repository/opted-out",
+		ShortName:  "opted-out",
+		Dockerfile: &dockerfile.Dockerfile{Labels: map[string]string{extraRegistriesLabel: "false"}},
+	}
+	graph.AddNode(dag.NewNode(mirrored))
+	graph.AddNode(dag.NewNode(optedOut))
+
+	SetExtraRegistries(graph, []string{
+		"registry.example.org/",
+		registryPrefix,
+		"registry.example.org",
+		"mirror.example.org/team",
+	})
+
+	assert.Equal(t, []string{
+		"registry.example.org/mirrored",
+		"mirror.example.org/team/mirrored",
+	}, mirrored.Mirrors)
+	assert.Empty(t, optedOut.Mirrors)
+}
+
 func Test_loadCustomHashList(t *testing.T) {
 	testCases := []struct {
 		name        string
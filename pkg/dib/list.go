@@ -1,8 +1,12 @@
 package dib
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -10,51 +14,114 @@ import (
 	"github.com/olekukonko/tablewriter"
 	"github.com/olekukonko/tablewriter/tw"
 	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/radiofrance/dib/pkg/graphviz"
+	"github.com/radiofrance/dib/pkg/registry"
+	"gopkg.in/yaml.v3"
 )
 
 const (
 	ConsoleFormat        = "console"
 	GraphvizFormat       = "graphviz"
+	JSONFormat           = "json"
+	YAMLFormat           = "yaml"
+	MermaidFormat        = "mermaid"
+	GoTemplateFormat     = "go-template"
 	GoTemplateFileFormat = "go-template-file"
 )
 
 type ListOpts struct {
 	// Root options
-	BuildPath        string `mapstructure:"build_path"`
-	RegistryURL      string `mapstructure:"registry_url"`
-	PlaceholderTag   string `mapstructure:"placeholder_tag"`
-	HashListFilePath string `mapstructure:"hash_list_file_path"`
+	BuildPath          string `mapstructure:"build_path"`
+	RegistryURL        string `mapstructure:"registry_url"`
+	NameTemplate       string `mapstructure:"name_template"`
+	NameFromDirectory  bool   `mapstructure:"name_from_directory"`
+	PlaceholderTag     string `mapstructure:"placeholder_tag"`
+	HashListFilePath   string `mapstructure:"hash_list_file_path"`
+	ResolveBaseDigests bool   `mapstructure:"resolve_base_digests"`
 
 	// List specific options
-	Output   string   `mapstructure:"output,omitempty"`
-	BuildArg []string `mapstructure:"build_arg,omitempty"`
+	Output   string          `mapstructure:"output,omitempty"`
+	BuildArg []string        `mapstructure:"build_arg,omitempty"`
+	Status   bool            `mapstructure:"status"`
+	Registry registry.Config `mapstructure:"registry"`
 }
 
 type FormatOpts struct {
 	Type         string
 	TemplatePath string
+	Template     string
+	// Status colours the graphviz and mermaid outputs by the status of the images, once Builder.Plan found out
+	// which ones need to be rebuilt.
+	Status bool
 }
 
-func GenerateList(graph *dag.DAG, opts FormatOpts) error {
-	imagesList := GetImagesList(graph)
+// ListItem holds the metadata of an image, as displayed by the structured output formats and passed to templates.
+// The dag.Image is embedded so templates can still access all the fields of the image (e.g. .Dockerfile).
+type ListItem struct {
+	dag.Image `json:"-" yaml:"-"`
+
+	Name           string   `json:"name"            yaml:"name"`
+	ShortName      string   `json:"short_name"      yaml:"short_name"`
+	Hash           string   `json:"hash"            yaml:"hash"`
+	ContextPath    string   `json:"context_path"    yaml:"context_path"`
+	DockerfilePath string   `json:"dockerfile_path" yaml:"dockerfile_path"`
+	ExtraTags      []string `json:"extra_tags"      yaml:"extra_tags"`
+	// Parents holds the short names of the dib-managed images this image is built from.
+	Parents []string `json:"parents" yaml:"parents"`
+	// Children holds the short names of the dib-managed images built from this image.
+	Children []string `json:"children" yaml:"children"`
+	// Depth is the length of the longest chain of parents, 0 for images without dib-managed parents.
+	Depth int `json:"depth" yaml:"depth"`
+	// BaseImages holds the references of the images from FROM statements that are not managed by dib.
+	BaseImages []string `json:"base_images" yaml:"base_images"`
+	// Descendants is the number of dib-managed images depending on this image, directly or not.
+	Descendants int `json:"descendants" yaml:"descendants"`
+}
 
+func GenerateList(graph *dag.DAG, opts FormatOpts) error {
 	switch opts.Type {
 	case ConsoleFormat:
-		err := renderConsoleOutput(imagesList)
+		err := renderConsoleOutput(GetImagesList(graph))
 		if err != nil {
 			return fmt.Errorf("renderConsoleOutput: %w", err)
 		}
 	case GraphvizFormat:
-		output := graphviz.GenerateRawOutput(graph)
+		output := graphviz.GenerateRawOutput(graph, planStatuses(graph, opts.Status))
 		fmt.Println(output) //nolint:forbidigo
+	case MermaidFormat:
+		fmt.Print(graphviz.RenderMermaid(graph, planStatuses(graph, opts.Status))) //nolint:forbidigo
+	case JSONFormat:
+		output, err := json.MarshalIndent(GetListItems(graph), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to render json output: %w", err)
+		}
+
+		fmt.Println(string(output)) //nolint:forbidigo
+	case YAMLFormat:
+		output, err := yaml.Marshal(GetListItems(graph))
+		if err != nil {
+			return fmt.Errorf("failed to render yaml output: %w", err)
+		}
+
+		fmt.Print(string(output)) //nolint:forbidigo
+	case GoTemplateFormat:
+		outputTemplate, err := template.New(GoTemplateFormat).Parse(opts.Template)
+		if err != nil {
+			return fmt.Errorf("failed to parse go-template : %w", err)
+		}
+
+		err = outputTemplate.Execute(os.Stdout, GetListItems(graph))
+		if err != nil {
+			return fmt.Errorf("failed to render go-template : %w", err)
+		}
 	case GoTemplateFileFormat:
 		outputTemplate, err := template.ParseFiles(opts.TemplatePath)
 		if err != nil {
 			return fmt.Errorf("failed to parse go-template file : %w", err)
 		}
 
-		err = outputTemplate.Execute(os.Stdout, imagesList)
+		err = outputTemplate.Execute(os.Stdout, GetListItems(graph))
 		if err != nil {
 			return fmt.Errorf("failed to render go-template file : %w", err)
 		}
@@ -63,6 +130,184 @@ func GenerateList(graph *dag.DAG, opts FormatOpts) error {
 	return nil
 }
 
+// GetListItems iterate over DAG nodes and return the metadata of every image, sorted by their ShortName.
+// An image appearing several times in the graph is listed once, with the parents and children of all its nodes.
+func GetListItems(graph *dag.DAG) []ListItem {
+	nodes := make(map[string][]*dag.Node)
+	managedImages := make(map[string]struct{})
+
+	graph.Walk(func(node *dag.Node) {
+		nodes[node.Image.ShortName] = append(nodes[node.Image.ShortName], node)
+		managedImages[node.Image.Name] = struct{}{}
+	})
+
+	depths := make(map[*dag.Node]int)
+
+	items := make([]ListItem, 0, len(nodes))
+	for _, sameImageNodes := range nodes {
+		var parents, children []*dag.Node
+		for _, node := range sameImageNodes {
+			parents = append(parents, node.Parents()...)
+			children = append(children, node.Children()...)
+		}
+
+		img := sameImageNodes[0].Image
+
+		item := ListItem{
+			Image:       *img,
+			Name:        img.Name,
+			ShortName:   img.ShortName,
+			Hash:        img.Hash,
+			ExtraTags:   nonNil(img.ExtraTags),
+			Parents:     shortNames(parents),
+			Children:    shortNames(children),
+			BaseImages:  []string{},
+			Descendants: countDescendants(sameImageNodes),
+		}
+
+		for _, node := range sameImageNodes {
+			item.Depth = max(item.Depth, nodeDepth(node, depths))
+		}
+
+		if img.Dockerfile != nil {
+			item.ContextPath = img.Dockerfile.ContextPath
+			item.DockerfilePath = path.Join(img.Dockerfile.ContextPath, img.Dockerfile.Filename)
+			item.BaseImages = baseImages(img.Dockerfile, managedImages)
+		}
+
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ShortName < items[j].ShortName
+	})
+
+	return items
+}
+
+// planStatuses returns the status of every image of the graph, up-to-date or needing a rebuild, when status is
+// true. It returns nil otherwise, so the graphs are not coloured.
+func planStatuses(graph *dag.DAG, status bool) map[string]graphviz.NodeStatus {
+	if !status {
+		return nil
+	}
+
+	statuses := map[string]graphviz.NodeStatus{}
+
+	graph.Walk(func(node *dag.Node) {
+		statuses[node.Image.Name] = graphviz.NodeStatusUpToDate
+		if node.Image.NeedsRebuild {
+			statuses[node.Image.Name] = graphviz.NodeStatusNeedsRebuild
+		}
+	})
+
+	return statuses
+}
+
+// nodeDepth returns the length of the longest chain of parents of the node, caching the results in depths.
+func nodeDepth(node *dag.Node, depths map[*dag.Node]int) int {
+	if depth, ok := depths[node]; ok {
+		return depth
+	}
+
+	depth := 0
+	for _, parent := range node.Parents() {
+		depth = max(depth, nodeDepth(parent, depths)+1)
+	}
+
+	depths[node] = depth
+
+	return depth
+}
+
+// countDescendants returns the number of distinct images depending on the given nodes, directly or not.
+func countDescendants(nodes []*dag.Node) int {
+	descendants := make(map[string]struct{})
+
+	var visit func(node *dag.Node)
+
+	visit = func(node *dag.Node) {
+		for _, child := range node.Children() {
+			descendants[child.Image.ShortName] = struct{}{}
+			visit(child)
+		}
+	}
+
+	for _, node := range nodes {
+		visit(node)
+	}
+
+	return len(descendants)
+}
+
+// baseImages returns the sorted and deduplicated references of the images from FROM statements
+// that are not managed by dib.
+func baseImages(file *dockerfile.Dockerfile, managedImages map[string]struct{}) []string {
+	images := []string{}
+
+	for _, ref := range file.From {
+		if !isBaseImage(ref, file, managedImages) {
+			continue
+		}
+
+		image := imageRefString(ref)
+		if !slices.Contains(images, image) {
+			images = append(images, image)
+		}
+	}
+
+	sort.Strings(images)
+
+	return images
+}
+
+// isBaseImage returns true if the image from the FROM statement is an external base image, and not an image
+// managed by dib, a build stage of the same Dockerfile or the special "scratch" image.
+func isBaseImage(ref dockerfile.ImageRef, file *dockerfile.Dockerfile, managedImages map[string]struct{}) bool {
+	if _, managed := managedImages[ref.Name]; managed {
+		return false
+	}
+
+	return ref.Name != "scratch" && !file.IsStage(ref.Name)
+}
+
+// imageRefString returns the reference of the image, as written in the FROM statement.
+func imageRefString(ref dockerfile.ImageRef) string {
+	image := ref.Name
+	if ref.Tag != "" {
+		image += ":" + ref.Tag
+	}
+
+	if ref.Digest != "" {
+		image += "@" + ref.Digest
+	}
+
+	return image
+}
+
+// shortNames returns the sorted and deduplicated short names of the images of the given nodes.
+func shortNames(nodes []*dag.Node) []string {
+	names := []string{}
+
+	for _, node := range nodes {
+		if !slices.Contains(names, node.Image.ShortName) {
+			names = append(names, node.Image.ShortName)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
 // GetImagesList iterate over DAG nodes and return a slice of Image sorted by their ShortName.
 func GetImagesList(graph *dag.DAG) []dag.Image {
 	imagesList := make(map[string]dag.Image)
@@ -85,28 +330,36 @@ func GetImagesList(graph *dag.DAG) []dag.Image {
 }
 
 // ParseOutputOptions parse value of the "--output" flag and ensure they are valid.
-// Currently, we only support the "go-template-file" and "console" output.
+// Supported outputs are "console", "graphviz", "json", "yaml", "mermaid", "go-template=<template>"
+// and "go-template-file=<path>".
 func ParseOutputOptions(output string) (FormatOpts, error) {
 	formatOpts := FormatOpts{}
-	if output == "" || output == ConsoleFormat {
+
+	switch output {
+	case "", ConsoleFormat:
 		formatOpts.Type = ConsoleFormat
 		return formatOpts, nil
-	}
-
-	if output == GraphvizFormat {
-		formatOpts.Type = GraphvizFormat
+	case GraphvizFormat, JSONFormat, YAMLFormat, MermaidFormat:
+		formatOpts.Type = output
 		return formatOpts, nil
 	}
 
-	parsed := strings.Split(output, "=")
-	switch parsed[0] {
+	format, value, hasValue := strings.Cut(output, "=")
+	switch format {
 	case GoTemplateFileFormat:
-		if len(parsed) == 1 {
-			return formatOpts, fmt.Errorf("you need to provide a path to template file when using \"go-template-file\" options")
+		if !hasValue || value == "" {
+			return formatOpts, errors.New("you need to provide a path to template file when using \"go-template-file\" options")
 		}
 
 		formatOpts.Type = GoTemplateFileFormat
-		formatOpts.TemplatePath = parsed[1]
+		formatOpts.TemplatePath = value
+	case GoTemplateFormat:
+		if !hasValue || value == "" {
+			return formatOpts, errors.New("you need to provide a template when using \"go-template\" options")
+		}
+
+		formatOpts.Type = GoTemplateFormat
+		formatOpts.Template = value
 	default:
 		return formatOpts, fmt.Errorf("\"%s\" is not a valid output format", output)
 	}
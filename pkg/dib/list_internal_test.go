@@ -0,0 +1,26 @@
+package dib
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/graphviz"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_planStatuses(t *testing.T) {
+	t.Parallel()
+
+	root := dag.NewNode(&dag.Image{Name: "registry.example.org/root", ShortName: "root"})
+	child := dag.NewNode(&dag.Image{Name: "registry.example.org/child", ShortName: "child", NeedsRebuild: true})
+	root.AddChild(child)
+
+	graph := &dag.DAG{}
+	graph.AddNode(root)
+
+	assert.Nil(t, planStatuses(graph, false))
+	assert.Equal(t, map[string]graphviz.NodeStatus{
+		"registry.example.org/root":  graphviz.NodeStatusUpToDate,
+		"registry.example.org/child": graphviz.NodeStatusNeedsRebuild,
+	}, planStatuses(graph, true))
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,6 +52,45 @@ func Test_GenerateList_Graphviz(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func Test_GenerateList_StructuredFormats(t *testing.T) {
+	t.Parallel()
+
+	DAG := setupFakeDag(t)
+
+	for _, format := range []string{dib.JSONFormat, dib.YAMLFormat, dib.MermaidFormat} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			require.NoError(t, dib.GenerateList(DAG, dib.FormatOpts{Type: format}))
+		})
+	}
+}
+
+func Test_GenerateList_GoTemplate(t *testing.T) {
+	t.Parallel()
+
+	DAG := setupFakeDag(t)
+
+	err := dib.GenerateList(DAG, dib.FormatOpts{
+		Type:     dib.GoTemplateFormat,
+		Template: "{{ range . }}{{ .ShortName }}:{{ .Hash }}\n{{ end }}",
+	})
+	require.NoError(t, err)
+
+	err = dib.GenerateList(DAG, dib.FormatOpts{
+		Type: dib.GoTemplateFormat,
+		Template: "{{ range . }}{{ .ShortName }} ({{ .Depth }}, {{ .Descendants }} descendants) " +
+			"{{ .Dockerfile.ContextPath }} <- {{ .Parents }} {{ .Children }} {{ .BaseImages }}\n{{ end }}",
+	})
+	require.NoError(t, err)
+
+	err = dib.GenerateList(DAG, dib.FormatOpts{Type: dib.GoTemplateFormat, Template: "{{ .Unknown }}"})
+	require.Error(t, err)
+
+	err = dib.GenerateList(DAG, dib.FormatOpts{Type: dib.GoTemplateFormat, Template: "{{ .ShortName"})
+	require.Error(t, err)
+}
+
 //nolint:lll
 func Test_GenerateList_GoTemplateFile(t *testing.T) {
 	t.Parallel()
@@ -119,6 +159,55 @@ func Test_GetImageList(t *testing.T) {
 	}
 }
 
+func Test_GetListItems(t *testing.T) {
+	t.Parallel()
+
+	DAG := setupFakeDag(t)
+	fourthNode := newNode("test-registry/fourth", "golf-fish-oregon-ack", "docker/bullseye/fourth")
+	fourthNode.Image.Dockerfile.From = []dockerfile.ImageRef{
+		{Name: "test-registry/third"},
+		{Name: "golang", Tag: "1.26", Digest: "sha256:0123"},
+		{Name: "scratch"},
+	}
+	DAG.Nodes()[0].Children()[1].Children()[0].AddChild(fourthNode)
+
+	actual := dib.GetListItems(DAG)
+	require.Len(t, actual, 5)
+
+	root := actual[0]
+	assert.Equal(t, "test-registry/bullseye", root.Name)
+	assert.Equal(t, "bullseye", root.ShortName)
+	assert.Equal(t, "floor-venus-august-venus", root.Hash)
+	assert.Equal(t, "docker/bullseye", root.ContextPath)
+	assert.Equal(t, "docker/bullseye/Dockerfile", root.DockerfilePath)
+	assert.Equal(t, []string{}, root.ExtraTags)
+	assert.Equal(t, []string{}, root.Parents)
+	assert.Equal(t, []string{"first", "second"}, root.Children)
+	assert.Equal(t, 0, root.Depth)
+	assert.Equal(t, []string{"debian"}, root.BaseImages)
+	assert.Equal(t, 4, root.Descendants)
+	assert.Equal(t, "docker/bullseye", root.Dockerfile.ContextPath)
+
+	assert.Equal(t, "first", actual[1].ShortName)
+	assert.Equal(t, 1, actual[1].Depth)
+	assert.Equal(t, 0, actual[1].Descendants)
+
+	fourth := actual[2]
+	assert.Equal(t, "fourth", fourth.ShortName)
+	assert.Equal(t, []string{"third"}, fourth.Parents)
+	assert.Equal(t, 3, fourth.Depth)
+	assert.Equal(t, []string{"golang:1.26@sha256:0123"}, fourth.BaseImages)
+
+	second := actual[3]
+	assert.Equal(t, []string{"third"}, second.Children)
+	assert.Equal(t, 2, second.Descendants)
+
+	third := actual[4]
+	assert.Equal(t, []string{"second"}, third.Parents)
+	assert.Equal(t, []string{"fourth"}, third.Children)
+	assert.Equal(t, 2, third.Depth)
+}
+
 func Test_ParseOutputOptions(t *testing.T) {
 	t.Parallel()
 
@@ -158,11 +247,41 @@ func Test_ParseOutputOptions(t *testing.T) {
 			expected:         dib.FormatOpts{},
 			expectedErrorMsg: "you need to provide a path to template file when using \"go-template-file\" options",
 		},
+		{
+			name:             "Format: json",
+			given:            dib.JSONFormat,
+			expected:         dib.FormatOpts{Type: dib.JSONFormat},
+			expectedErrorMsg: "",
+		},
+		{
+			name:             "Format: yaml",
+			given:            dib.YAMLFormat,
+			expected:         dib.FormatOpts{Type: dib.YAMLFormat},
+			expectedErrorMsg: "",
+		},
+		{
+			name:             "Format: mermaid",
+			given:            dib.MermaidFormat,
+			expected:         dib.FormatOpts{Type: dib.MermaidFormat},
+			expectedErrorMsg: "",
+		},
+		{
+			name:             "Format: go-template",
+			given:            dib.GoTemplateFormat + "={{ range . }}{{ .ShortName }}={{ .Hash }}{{ end }}",
+			expected:         dib.FormatOpts{Type: dib.GoTemplateFormat, Template: "{{ range . }}{{ .ShortName }}={{ .Hash }}{{ end }}"},
+			expectedErrorMsg: "",
+		},
+		{
+			name:             "Format: go-template (invalid)",
+			given:            dib.GoTemplateFormat + "=",
+			expected:         dib.FormatOpts{},
+			expectedErrorMsg: "you need to provide a template when using \"go-template\" options",
+		},
 		{
 			name:             "Format: unsupported / invalid",
-			given:            "json",
+			given:            "xml",
 			expected:         dib.FormatOpts{},
-			expectedErrorMsg: "\"json\" is not a valid output format",
+			expectedErrorMsg: "\"xml\" is not a valid output format",
 		},
 	}
 
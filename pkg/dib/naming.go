@@ -0,0 +1,133 @@
+package dib
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/radiofrance/dib/pkg/dockerfile"
+)
+
+// DefaultNameTemplate is the template of the image names when none is configured: the "name" label of the
+// Dockerfile, in the registry.
+const DefaultNameTemplate = "{{.Registry}}/{{.Name}}"
+
+// rxLabelVariable matches the label keys exposed as template variables, such as "team" for {{.Team}}.
+var rxLabelVariable = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+var errMissingNameLabel = errors.New("missing label \"name\"")
+
+// Naming defines how the names of the images are computed from their Dockerfile.
+type Naming struct {
+	// RegistryURL is the registry where the images are stored.
+	RegistryURL string
+	// Template is the Go template of the image names. Defaults to DefaultNameTemplate.
+	//
+	// The template has access to the following variables:
+	//   - .Registry: the registry URL.
+	//   - .Name: the "name" label, or the name of the directory of the Dockerfile when the label is absent and
+	//     NameFromDirectory is true.
+	//   - .Path: the directory of the Dockerfile, relative to the build path (e.g. "team-a/nginx").
+	//   - .Dirs: the elements of .Path (e.g. {{index .Dirs 0}} is "team-a").
+	//   - .Labels: all the labels of the Dockerfile. Labels whose key is a lowercase identifier are also exposed
+	//     with the first letter in uppercase, e.g. {{.Team}} for the label "team".
+	//
+	// The rendered name must be in the registry, as the name of the image without the registry is used to push it
+	// to other registries.
+	Template string
+	// NameFromDirectory makes the "name" label optional: the name of the directory of the Dockerfile is used when
+	// the label is absent.
+	NameFromDirectory bool
+}
+
+// imageNamer computes the names of the images of a build path.
+type imageNamer struct {
+	registryURL string
+	buildPath   string
+	template    *template.Template
+	// nameFromDirectory is true when the name of the image may be derived from its directory.
+	nameFromDirectory bool
+}
+
+func (n Naming) newImageNamer(buildPath string) (*imageNamer, error) {
+	text := n.Template
+	if text == "" {
+		text = DefaultNameTemplate
+	}
+
+	tmpl, err := template.New("name_template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %w", err)
+	}
+
+	return &imageNamer{
+		registryURL:       strings.TrimSuffix(n.RegistryURL, "/"),
+		buildPath:         buildPath,
+		template:          tmpl,
+		nameFromDirectory: n.NameFromDirectory,
+	}, nil
+}
+
+// imageName returns the fully qualified name of the image built from the Dockerfile, and its short name, which is
+// the name without the registry. Names which are not in the registry are rejected. An image with no "name" label
+// gets an empty name, along with errMissingNameLabel, when the name cannot be derived from its directory.
+func (n *imageNamer) imageName(dckFile *dockerfile.Dockerfile) (string, string, error) {
+	relPath, err := filepath.Rel(n.buildPath, dckFile.ContextPath)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot compute the path of %q: %w", dckFile.ContextPath, err)
+	}
+
+	relPath = filepath.ToSlash(relPath)
+
+	var dirs []string
+	if relPath != "." {
+		dirs = strings.Split(relPath, "/")
+	}
+
+	shortName, hasName := dckFile.Labels["name"]
+	if !hasName {
+		if !n.nameFromDirectory {
+			return "", "", errMissingNameLabel
+		}
+
+		shortName = path.Base(filepath.ToSlash(dckFile.ContextPath))
+	}
+
+	data := map[string]any{}
+
+	for key, value := range dckFile.Labels {
+		if rxLabelVariable.MatchString(key) {
+			data[strings.ToUpper(key[:1])+key[1:]] = value
+		}
+	}
+
+	// Built-in variables take precedence over the labels.
+	maps.Copy(data, map[string]any{
+		"Registry": n.registryURL,
+		"Name":     shortName,
+		"Path":     relPath,
+		"Dirs":     dirs,
+		"Labels":   dckFile.Labels,
+	})
+
+	var name strings.Builder
+
+	err = n.template.Execute(&name, data)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot compute the image name: %w", err)
+	}
+
+	imageName := strings.TrimSpace(name.String())
+
+	repository, ok := strings.CutPrefix(imageName, n.registryURL+"/")
+	if !ok || repository == "" {
+		return "", "", fmt.Errorf("image name %q is not in the registry %q", imageName, n.registryURL)
+	}
+
+	return imageName, repository, nil
+}
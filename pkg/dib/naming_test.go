@@ -0,0 +1,123 @@
+package dib_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GenerateDAG_NameTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		template      string
+		fromDirectory bool
+		files         map[string]string
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			name: "default template",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\nLABEL name=\"nginx\"\n",
+			},
+			expected: map[string]string{"registry.example.org/nginx": "nginx"},
+		},
+		{
+			name:     "team label",
+			template: "{{.Registry}}/{{.Team}}/{{.Name}}",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\nLABEL name=\"nginx\"\nLABEL team=\"web\"\n",
+			},
+			expected: map[string]string{"registry.example.org/web/nginx": "web/nginx"},
+		},
+		{
+			name:          "name and team from the path",
+			template:      "{{.Registry}}/{{index .Dirs 0}}/{{.Name}}",
+			fromDirectory: true,
+			files: map[string]string{
+				"web/nginx/Dockerfile":    "FROM debian:12\n",
+				"data/pgsql/Dockerfile":   "FROM debian:12\nLABEL name=\"postgresql\"\n",
+				"data/skipped/Dockerfile": "FROM debian:12\nLABEL skipbuild=\"true\"\n",
+			},
+			expected: map[string]string{
+				"registry.example.org/web/nginx":       "web/nginx",
+				"registry.example.org/data/postgresql": "data/postgresql",
+			},
+		},
+		{
+			name:     "missing name label with a custom template",
+			template: "{{ .Registry }}/{{ .Name }}",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\n",
+			},
+			expectedError: "missing label \"name\" in Dockerfile at path",
+		},
+		{
+			name:     "name outside of the registry",
+			template: "other.example.org/{{.Name}}",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\nLABEL name=\"nginx\"\n",
+			},
+			expectedError: "image name \"other.example.org/nginx\" is not in the registry \"registry.example.org\"",
+		},
+		{
+			name: "missing name label with the default template",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\n",
+			},
+			expectedError: "missing label \"name\" in Dockerfile at path",
+		},
+		{
+			name:     "missing label used by the template",
+			template: "{{.Registry}}/{{.Team}}/{{.Name}}",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\nLABEL name=\"nginx\"\n",
+			},
+			expectedError: "map has no entry for key \"Team\"",
+		},
+		{
+			name:     "invalid template",
+			template: "{{.Registry}/{{.Name}}",
+			files: map[string]string{
+				"nginx/Dockerfile": "FROM debian:12\nLABEL name=\"nginx\"\n",
+			},
+			expectedError: "invalid name template",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buildPath := t.TempDir()
+			writeFiles(t, buildPath, test.files)
+
+			naming := dib.Naming{
+				RegistryURL:       "registry.example.org",
+				Template:          test.template,
+				NameFromDirectory: test.fromDirectory,
+			}
+
+			graph, err := dib.GenerateDAG(t.Context(), buildPath, naming, "", nil, nil)
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			actual := map[string]string{}
+
+			graph.Walk(func(node *dag.Node) {
+				actual[node.Image.Name] = node.Image.ShortName
+			})
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
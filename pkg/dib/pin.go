@@ -0,0 +1,238 @@
+package dib
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+type PinOpts struct {
+	// Root options
+	BuildPath         string `mapstructure:"build_path"`
+	RegistryURL       string `mapstructure:"registry_url"`
+	NameTemplate      string `mapstructure:"name_template"`
+	NameFromDirectory bool   `mapstructure:"name_from_directory"`
+
+	// Pin specific options
+	DryRun bool `mapstructure:"dry_run"`
+}
+
+// PinnedRef describes an external base image reference rewritten to be pinned by digest.
+type PinnedRef struct {
+	Dockerfile string
+	Ref        string
+	PinnedRef  string
+}
+
+// digestResolver resolves the digests of the external base images from the upstream registries.
+// Results are cached, so each image is resolved only once, even when used by many Dockerfiles.
+type digestResolver struct {
+	upstream types.UpstreamRegistry
+	digests  map[string]string
+}
+
+func newDigestResolver(upstream types.UpstreamRegistry) *digestResolver {
+	if upstream == nil {
+		return nil
+	}
+
+	return &digestResolver{upstream: upstream, digests: make(map[string]string)}
+}
+
+// resolve returns the digest the tag of the image currently points to.
+func (r *digestResolver) resolve(ref dockerfile.ImageRef) (string, error) {
+	tag := ref.Tag
+	if tag == "" {
+		tag = defaultBaseImageTag
+	}
+
+	imageRef := ref.Name + ":" + tag
+	if digest, ok := r.digests[imageRef]; ok {
+		return digest, nil
+	}
+
+	logger.Debugf("Resolving digest of base image \"%s\"", imageRef)
+
+	digest, err := r.upstream.Digest(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve digest of base image %q: %w", imageRef, err)
+	}
+
+	r.digests[imageRef] = digest
+
+	return digest, nil
+}
+
+// nodeBaseImages returns the external base images of the node, as written in its FROM statements.
+func nodeBaseImages(node *dag.Node) []dockerfile.ImageRef {
+	managedImages := make(map[string]struct{})
+	for _, parent := range node.Parents() {
+		managedImages[parent.Image.Name] = struct{}{}
+	}
+
+	var refs []dockerfile.ImageRef
+
+	for _, ref := range node.Image.Dockerfile.From {
+		if isBaseImage(ref, node.Image.Dockerfile, managedImages) {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// unpinnedBaseImages returns the external base images of the node that are not pinned by digest.
+func unpinnedBaseImages(node *dag.Node) []dockerfile.ImageRef {
+	var refs []dockerfile.ImageRef
+
+	for _, ref := range nodeBaseImages(node) {
+		if ref.Digest == "" {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// dependsOnBuildArg returns true if the name or the tag of the image ref contains a build argument
+// (e.g. "debian:${TAG}").
+func dependsOnBuildArg(ref dockerfile.ImageRef) bool {
+	return strings.Contains(ref.Name, "$") || strings.Contains(ref.Tag, "$")
+}
+
+// expandBuildArgs substitutes the build arguments in the image ref of a FROM statement (e.g. "debian:${TAG}"),
+// with the values given with --build-arg, or else the default values of the ARG instructions of the Dockerfile.
+// A build argument without value makes the base image unknown, which is an error.
+func expandBuildArgs(
+	ref dockerfile.ImageRef,
+	dckFile *dockerfile.Dockerfile,
+	buildArgs map[string]string,
+) (dockerfile.ImageRef, error) {
+	if !dependsOnBuildArg(ref) {
+		return ref, nil
+	}
+
+	var missing []string
+
+	expanded := os.Expand(ref.Name+":"+ref.Tag, func(name string) string {
+		if value, ok := buildArgs[name]; ok {
+			return value
+		}
+
+		if value, ok := dckFile.ArgDefault(name); ok {
+			return value
+		}
+
+		missing = append(missing, name)
+
+		return ""
+	})
+
+	if len(missing) > 0 {
+		return ref, fmt.Errorf("base image %q depends on the build argument %q, which has no value",
+			imageRefString(ref), missing[0])
+	}
+
+	expandedRef := dockerfile.ParseImageRef(strings.TrimSuffix(expanded, ":"))
+	if ref.Digest != "" {
+		expandedRef.Digest = ref.Digest
+	}
+
+	return expandedRef, nil
+}
+
+// baseImageDigests returns the references of the unpinned external base images of the node, with their build
+// arguments expanded, along with their resolved digest (e.g. "debian:bookworm@sha256:...").
+func (r *digestResolver) baseImageDigests(node *dag.Node, buildArgs map[string]string) ([]string, error) {
+	var digests []string
+
+	for _, ref := range unpinnedBaseImages(node) {
+		expanded, err := expandBuildArgs(ref, node.Image.Dockerfile, buildArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		// The build argument may hold the digest (e.g. "ARG BASE=debian:bookworm@sha256:...").
+		if expanded.Digest != "" {
+			continue
+		}
+
+		digest, err := r.resolve(expanded)
+		if err != nil {
+			return nil, err
+		}
+
+		digests = append(digests, imageRefString(expanded)+"@"+digest)
+	}
+
+	return digests, nil
+}
+
+// PinBaseImages rewrites the FROM statements of all Dockerfiles, so that external base images are referenced
+// by digest (e.g. "FROM debian:bookworm" becomes "FROM debian:bookworm@sha256:..."). Base images already
+// pinned are left untouched. When dryRun is true, the Dockerfiles are not modified.
+func PinBaseImages(graph *dag.DAG, upstream types.UpstreamRegistry, dryRun bool) ([]PinnedRef, error) {
+	resolver := newDigestResolver(upstream)
+	seen := make(map[string]struct{})
+
+	var pinned []PinnedRef
+
+	err := graph.WalkErr(func(node *dag.Node) error {
+		filename := path.Join(node.Image.Dockerfile.ContextPath, node.Image.Dockerfile.Filename)
+		if _, ok := seen[filename]; ok {
+			return nil
+		}
+
+		seen[filename] = struct{}{}
+		pins := make(map[string]string)
+
+		for _, ref := range unpinnedBaseImages(node) {
+			// The FROM statement cannot be pinned without dropping the build argument.
+			if dependsOnBuildArg(ref) {
+				return fmt.Errorf("cannot pin base image %q of %s, as it depends on a build argument",
+					imageRefString(ref), node.Image.ShortName)
+			}
+
+			digest, err := resolver.resolve(ref)
+			if err != nil {
+				return err
+			}
+
+			pinnedRef := imageRefString(ref) + "@" + digest
+			pins[imageRefString(ref)] = pinnedRef
+			pinned = append(pinned, PinnedRef{Dockerfile: filename, Ref: imageRefString(ref), PinnedRef: pinnedRef})
+		}
+
+		if len(pins) == 0 {
+			return nil
+		}
+
+		if dryRun {
+			for ref, pinnedRef := range pins {
+				logger.Infof("[DRY-RUN] Pinning \"%s\" to \"%s\" in \"%s\"", ref, pinnedRef, filename)
+			}
+
+			return nil
+		}
+
+		err := dockerfile.PinFrom(filename, pins)
+		if err != nil {
+			return fmt.Errorf("cannot pin base images in %s: %w", filename, err)
+		}
+
+		logger.Infof("Pinned %d base image(s) in \"%s\"", len(pins), filename)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pinned, nil
+}
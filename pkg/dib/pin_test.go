@@ -0,0 +1,157 @@
+package dib_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDockerfile(t *testing.T, dir, content string) string {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+
+	filename := path.Join(dir, "Dockerfile")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	return filename
+}
+
+func Test_PinBaseImages(t *testing.T) {
+	t.Parallel()
+
+	buildPath := t.TempDir()
+	baseDockerfile := writeDockerfile(t, path.Join(buildPath, "base"),
+		"FROM debian:bookworm AS builder\nFROM builder\nLABEL name=\"base\"\n")
+	childDockerfile := writeDockerfile(t, path.Join(buildPath, "base", "child"),
+		"FROM registry/base\nFROM alpine:3.19@sha256:alpine\nFROM scratch\nLABEL name=\"child\"\n")
+
+	upstream := &mock.UpstreamRegistry{
+		Digests: map[string]string{"debian:bookworm": "sha256:debian"},
+	}
+
+	graph, err := dib.GenerateDAG(t.Context(), buildPath, dib.Naming{RegistryURL: "registry"}, "", nil, nil)
+	require.NoError(t, err)
+
+	pinned, err := dib.PinBaseImages(graph, upstream, true)
+	require.NoError(t, err)
+	assert.Equal(t, []dib.PinnedRef{{
+		Dockerfile: baseDockerfile,
+		Ref:        "debian:bookworm",
+		PinnedRef:  "debian:bookworm@sha256:debian",
+	}}, pinned)
+
+	content, err := os.ReadFile(baseDockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM debian:bookworm AS builder\nFROM builder\nLABEL name=\"base\"\n", string(content),
+		"Dockerfiles should not be modified in dry-run mode")
+
+	_, err = dib.PinBaseImages(graph, upstream, false)
+	require.NoError(t, err)
+
+	content, err = os.ReadFile(baseDockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM debian:bookworm@sha256:debian AS builder\nFROM builder\nLABEL name=\"base\"\n", string(content))
+
+	content, err = os.ReadFile(childDockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM registry/base\nFROM alpine:3.19@sha256:alpine\nFROM scratch\nLABEL name=\"child\"\n",
+		string(content))
+}
+
+func Test_PinBaseImages_ResolutionError(t *testing.T) {
+	t.Parallel()
+
+	buildPath := t.TempDir()
+	writeDockerfile(t, path.Join(buildPath, "base"), "FROM debian:bookworm\nLABEL name=\"base\"\n")
+
+	graph, err := dib.GenerateDAG(t.Context(), buildPath, dib.Naming{RegistryURL: "registry"}, "", nil, nil)
+	require.NoError(t, err)
+
+	_, err = dib.PinBaseImages(graph, &mock.UpstreamRegistry{}, false)
+	require.ErrorContains(t, err, "cannot resolve digest of base image \"debian:bookworm\"")
+}
+
+func Test_PinBaseImages_RejectsBuildArgs(t *testing.T) {
+	t.Parallel()
+
+	buildPath := t.TempDir()
+	dockerfile := "ARG TAG=bookworm\nFROM debian:${TAG}\nLABEL name=\"base\"\n"
+	baseDockerfile := writeDockerfile(t, path.Join(buildPath, "base"), dockerfile)
+
+	upstream := &mock.UpstreamRegistry{Digests: map[string]string{"debian:bookworm": "sha256:bookworm"}}
+
+	graph, err := dib.GenerateDAG(t.Context(), buildPath, dib.Naming{RegistryURL: "registry"}, "", nil, nil)
+	require.NoError(t, err)
+
+	_, err = dib.PinBaseImages(graph, upstream, false)
+	require.EqualError(t, err, "cannot pin base image \"debian:${TAG}\" of base, as it depends on a build argument")
+
+	content, err := os.ReadFile(baseDockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, dockerfile, string(content))
+}
+
+func Test_GenerateDAG_ResolvesBaseImagesWithBuildArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		dockerfile    string
+		buildArgs     map[string]string
+		expDigests    []string
+		expectedError string
+	}{
+		{
+			name:       "default value of the build argument",
+			dockerfile: "ARG TAG=bookworm\nFROM debian:${TAG}\nLABEL name=\"base\"\n",
+			expDigests: []string{"debian:bookworm@sha256:bookworm"},
+		},
+		{
+			name:       "value given to the build",
+			dockerfile: "ARG TAG=bookworm\nFROM debian:${TAG}\nLABEL name=\"base\"\n",
+			buildArgs:  map[string]string{"TAG": "trixie"},
+			expDigests: []string{"debian:trixie@sha256:trixie"},
+		},
+		{
+			name:       "whole reference in a build argument",
+			dockerfile: "ARG BASE=debian:bookworm\nFROM $BASE\nLABEL name=\"base\"\n",
+			expDigests: []string{"debian:bookworm@sha256:bookworm"},
+		},
+		{
+			name:          "build argument without value",
+			dockerfile:    "ARG TAG\nFROM debian:${TAG}\nLABEL name=\"base\"\n",
+			expectedError: "base image \"debian:${TAG}\" depends on the build argument \"TAG\", which has no value",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buildPath := t.TempDir()
+			writeDockerfile(t, path.Join(buildPath, "base"), test.dockerfile)
+
+			upstream := &mock.UpstreamRegistry{Digests: map[string]string{
+				"debian:bookworm": "sha256:bookworm",
+				"debian:trixie":   "sha256:trixie",
+			}}
+
+			graph, err := dib.GenerateDAG(t.Context(), buildPath, dib.Naming{RegistryURL: "registry"}, "",
+				test.buildArgs, upstream)
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, graph.Nodes(), 1)
+			assert.Equal(t, test.expDigests, graph.Nodes()[0].Image.BaseDigests)
+		})
+	}
+}
@@ -1,16 +1,26 @@
 package dib
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/radiofrance/dib/pkg/tracing"
 	"github.com/radiofrance/dib/pkg/types"
+	"golang.org/x/sync/errgroup"
 )
 
 // Plan decides which actions need to be performed on each image.
-func (p *Builder) Plan(registry types.DockerRegistry) error {
+func (p *Builder) Plan(ctx context.Context, dockerRegistry types.DockerRegistry) (err error) {
+	ctx, span := tracing.Start(ctx, "plan")
+	defer func() { tracing.End(span, err) }()
+
 	if p.ForceRebuild {
 		logger.Infof("force rebuild mode enabled, all images will be rebuild regardless of their changes")
 		p.Graph.Walk(func(node *dag.Node) {
@@ -21,7 +31,7 @@ func (p *Builder) Plan(registry types.DockerRegistry) error {
 		return nil
 	}
 
-	tagExistsMap, err := refExistsMapForTag(p.Graph, registry)
+	tagExistsMap, err := refExistsMapForTag(ctx, p.Graph, dockerRegistry, p.Registry)
 	if err != nil {
 		return err
 	}
@@ -58,11 +68,11 @@ func checkNeedsRebuild(graph *dag.DAG, tagExistsMap *sync.Map) error {
 		}
 
 		if tagExists.(bool) { //nolint:forcetypeassert
-			logger.Debugf("Ref \"%s\" already exists, no rebuild required", ref)
+			imageLogger(img).Debugf("Ref \"%s\" already exists, no rebuild required", ref)
 			return nil
 		}
 
-		logger.Infof("Ref \"%s\" is missing, image must be rebuilt", ref)
+		imageLogger(img).Infof("Ref \"%s\" is missing, image must be rebuilt", ref)
 
 		img.NeedsRebuild = true
 
@@ -70,25 +80,171 @@ func checkNeedsRebuild(graph *dag.DAG, tagExistsMap *sync.Map) error {
 	})
 }
 
-func refExistsMapForTag(graph *dag.DAG, registry types.DockerRegistry) (*sync.Map, error) {
+// refExistsMapForTag checks which images of the graph exist in the registry with their current hash. When the
+// registry can list tags, the tags of each repository are listed with a single request, otherwise each ref is
+// checked. Requests are limited to the configured concurrency, and retried when rate limited by the registry.
+// Refs found in the cache are not checked again.
+func refExistsMapForTag(
+	ctx context.Context,
+	graph *dag.DAG,
+	dockerRegistry types.DockerRegistry,
+	config registry.Config,
+) (_ *sync.Map, err error) {
+	ctx, span := tracing.Start(ctx, "check_registry")
+	defer func() { tracing.End(span, err) }()
+
+	var cache *registry.RefCache
+
+	if config.CacheFile != "" {
+		cache, err = registry.LoadRefCache(config.CacheFile, time.Duration(config.CacheTTL)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	refExistsMap := &sync.Map{}
 
-	err := graph.WalkAsyncErr(func(node *dag.Node) error {
+	// Images to check, by repository.
+	repositories := map[string][]*dag.Image{}
+
+	graph.Walk(func(node *dag.Node) {
 		img := node.Image
 		ref := img.DockerRef(img.Hash)
 
-		refAlreadyExists, err := registry.RefExists(ref)
-		if err != nil {
-			return err
-		}
+		if cache != nil && cache.Exists(ref) {
+			imageLogger(img).Debugf("Ref \"%s\" found in the registry cache", ref)
+			refExistsMap.Store(ref, true)
 
-		refExistsMap.Store(ref, refAlreadyExists)
+			return
+		}
 
-		return nil
+		repositories[img.Name] = append(repositories[img.Name], img)
 	})
+
+	store := func(ref string, exists bool) {
+		refExistsMap.Store(ref, exists)
+
+		if exists && cache != nil {
+			cache.Add(ref)
+		}
+	}
+
+	errG := new(errgroup.Group)
+	if config.Concurrency > 0 {
+		errG.SetLimit(config.Concurrency)
+	}
+
+	tagLister, canListTags := dockerRegistry.(types.TagLister)
+
+	for repository, images := range repositories {
+		if canListTags {
+			errG.Go(func() error {
+				return listRepositoryTags(ctx, tagLister, repository, images, config.MaxRetries, store)
+			})
+
+			continue
+		}
+
+		for _, img := range images {
+			errG.Go(func() error {
+				return checkRefExists(ctx, dockerRegistry, img, config.MaxRetries, store)
+			})
+		}
+	}
+
+	err = errG.Wait()
 	if err != nil {
 		return nil, fmt.Errorf("error during api call to check registry if tag exists: %w", err)
 	}
 
+	if cache != nil {
+		err = cache.Save()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return refExistsMap, nil
 }
+
+// listRepositoryTags lists the tags of the repository, to find out which of its images exist.
+func listRepositoryTags(
+	ctx context.Context,
+	tagLister types.TagLister,
+	repository string,
+	images []*dag.Image,
+	maxRetries int,
+	store func(ref string, exists bool),
+) (err error) {
+	_, listSpan := tracing.Start(ctx, "list_tags", tracing.AttributeImageName.String(repository))
+	defer func() { tracing.End(listSpan, err) }()
+
+	var tags []string
+
+	err = retryRateLimited(ctx, maxRetries, rateLimitBackoff, func() error {
+		tags, err = tagLister.Tags(repository)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, img := range images {
+		store(img.DockerRef(img.Hash), slices.Contains(tags, img.Hash))
+	}
+
+	return nil
+}
+
+// checkRefExists checks whether the current hash of the image exists.
+func checkRefExists(
+	ctx context.Context,
+	dockerRegistry types.DockerRegistry,
+	img *dag.Image,
+	maxRetries int,
+	store func(ref string, exists bool),
+) (err error) {
+	ref := img.DockerRef(img.Hash)
+
+	_, refSpan := tracing.Start(ctx, "ref_exists",
+		append(tracing.ImageAttributes(img), tracing.AttributeImageRef.String(ref))...)
+	defer func() { tracing.End(refSpan, err) }()
+
+	var exists bool
+
+	err = retryRateLimited(ctx, maxRetries, rateLimitBackoff, func() error {
+		exists, err = dockerRegistry.RefExists(ref)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	store(ref, exists)
+
+	return nil
+}
+
+// rateLimitBackoff is the time waited before retrying a request rate limited by the registry the first time.
+const rateLimitBackoff = time.Second
+
+// retryRateLimited calls fn until it succeeds, fails with another error than a rate limit, or has been retried
+// maxRetries times. The time waited between each attempt doubles, starting from backoff.
+func retryRateLimited(ctx context.Context, maxRetries int, backoff time.Duration, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxRetries || !registry.IsRateLimited(err) {
+			return err
+		}
+
+		logger.Warnf("Rate limited by the registry, retrying in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
@@ -0,0 +1,66 @@
+package dib
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_retryRateLimited(t *testing.T) {
+	t.Parallel()
+
+	errRateLimited := errors.New("429 Too Many Requests")
+
+	tests := []struct {
+		name        string
+		errors      []error
+		maxRetries  int
+		expErr      error
+		expAttempts int
+	}{
+		{name: "success", expAttempts: 1},
+		{name: "retried until success", errors: []error{errRateLimited, errRateLimited}, maxRetries: 3, expAttempts: 3},
+		{
+			name:        "too many retries",
+			errors:      []error{errRateLimited, errRateLimited, errRateLimited},
+			maxRetries:  2,
+			expErr:      errRateLimited,
+			expAttempts: 3,
+		},
+		{
+			name:        "other error",
+			errors:      []error{context.DeadlineExceeded},
+			maxRetries:  3,
+			expErr:      context.DeadlineExceeded,
+			expAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			attempts := 0
+
+			err := retryRateLimited(t.Context(), test.maxRetries, time.Millisecond, func() error {
+				attempts++
+				if attempts <= len(test.errors) {
+					return test.errors[attempts-1]
+				}
+
+				return nil
+			})
+			if test.expErr != nil {
+				require.ErrorIs(t, err, test.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expAttempts, attempts)
+		})
+	}
+}
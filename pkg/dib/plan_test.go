@@ -9,6 +9,7 @@ import (
 	"github.com/radiofrance/dib/pkg/dib"
 	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/registry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -65,7 +66,7 @@ func Test_Plan_RebuildAll(t *testing.T) {
 			NoTests:      false,
 		},
 	}
-	err := dibBuilder.Plan(registry)
+	err := dibBuilder.Plan(t.Context(), registry)
 	require.NoError(t, err)
 
 	assert.True(t, rootNode.Image.NeedsRebuild)        // Root image was modified.
@@ -119,7 +120,7 @@ func Test_Plan_RebuildOnlyModifiedImages(t *testing.T) {
 			NoTests:      false,
 		},
 	}
-	err := dibBuilder.Plan(registry)
+	err := dibBuilder.Plan(t.Context(), registry)
 	require.NoError(t, err)
 
 	assert.False(t, rootNode.Image.NeedsRebuild)        // Root image was NOT modified.
@@ -167,7 +168,7 @@ func Test_Plan_TestsDisabled(t *testing.T) {
 			NoTests:      true,
 		},
 	}
-	err := dibBuilder.Plan(registry)
+	err := dibBuilder.Plan(t.Context(), registry)
 	require.NoError(t, err)
 
 	assert.True(t, rootNode.Image.NeedsRebuild)
@@ -180,3 +181,93 @@ func Test_Plan_TestsDisabled(t *testing.T) {
 	assert.False(t, secondChildNode.Image.NeedsTests)
 	assert.False(t, subChildNode.Image.NeedsTests)
 }
+
+func newPlanTestGraph() (*dag.DAG, []*dag.Node) {
+	rootNode := newNode("registry.example.org/bullseye", "exists0", "/root/docker/bullseye")
+	firstChildNode := newNode("registry.example.org/first", "notexists1", "/root/docker/bullseye/first")
+	secondChildNode := newNode("registry.example.org/second", "exists2", "/root/docker/bullseye/second")
+
+	rootNode.AddChild(firstChildNode)
+	rootNode.AddChild(secondChildNode)
+
+	graph := &dag.DAG{}
+	graph.AddNode(rootNode)
+
+	return graph, []*dag.Node{rootNode, firstChildNode, secondChildNode}
+}
+
+func Test_Plan_ListsTagsPerRepository(t *testing.T) {
+	t.Parallel()
+
+	graph, nodes := newPlanTestGraph()
+
+	dockerRegistry := &mock.TagListingRegistry{Registry: mock.Registry{Lock: &sync.Mutex{}}}
+	dockerRegistry.ExistingRefs = []string{
+		"registry.example.org/bullseye:exists0",
+		"registry.example.org/bullseye:other",
+		"registry.example.org/first:exists1",
+		"registry.example.org/second:exists2",
+	}
+
+	dibBuilder := &dib.Builder{
+		Graph: graph,
+		BuildOpts: dib.BuildOpts{
+			NoTests:  true,
+			Registry: registry.Config{Concurrency: 2},
+		},
+	}
+	err := dibBuilder.Plan(t.Context(), dockerRegistry)
+	require.NoError(t, err)
+
+	assert.False(t, nodes[0].Image.NeedsRebuild)
+	assert.True(t, nodes[1].Image.NeedsRebuild)
+	assert.False(t, nodes[2].Image.NeedsRebuild)
+
+	assert.Equal(t, 3, dockerRegistry.TagsCallCount)
+	assert.Zero(t, dockerRegistry.RefExistsCallCount)
+}
+
+func Test_Plan_FailsWhenRateLimited(t *testing.T) {
+	t.Parallel()
+
+	graph, _ := newPlanTestGraph()
+
+	dockerRegistry := &mock.Registry{Lock: &sync.Mutex{}, RateLimited: 1}
+
+	dibBuilder := &dib.Builder{Graph: graph}
+	err := dibBuilder.Plan(t.Context(), dockerRegistry)
+	require.ErrorContains(t, err, "429 Too Many Requests")
+}
+
+func Test_Plan_UsesRegistryCache(t *testing.T) {
+	t.Parallel()
+
+	config := registry.Config{
+		CacheFile: path.Join(t.TempDir(), "cache", "registry.json"),
+		CacheTTL:  3600,
+	}
+
+	for _, expectedCalls := range []int{3, 1} {
+		graph, nodes := newPlanTestGraph()
+
+		dockerRegistry := &mock.Registry{Lock: &sync.Mutex{}}
+		dockerRegistry.ExistingRefs = []string{
+			"registry.example.org/bullseye:exists0",
+			"registry.example.org/second:exists2",
+		}
+
+		dibBuilder := &dib.Builder{
+			Graph:     graph,
+			BuildOpts: dib.BuildOpts{NoTests: true, Registry: config},
+		}
+		err := dibBuilder.Plan(t.Context(), dockerRegistry)
+		require.NoError(t, err)
+
+		assert.False(t, nodes[0].Image.NeedsRebuild)
+		assert.True(t, nodes[1].Image.NeedsRebuild)
+		assert.False(t, nodes[2].Image.NeedsRebuild)
+
+		// Only the missing ref is checked again once the existing ones are cached.
+		assert.Equal(t, expectedCalls, dockerRegistry.RefExistsCallCount)
+	}
+}
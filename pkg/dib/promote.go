@@ -0,0 +1,72 @@
+package dib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+type PromoteOpts struct {
+	// Root options
+	BuildPath          string `mapstructure:"build_path"`
+	RegistryURL        string `mapstructure:"registry_url"`
+	NameTemplate       string `mapstructure:"name_template"`
+	NameFromDirectory  bool   `mapstructure:"name_from_directory"`
+	PlaceholderTag     string `mapstructure:"placeholder_tag"`
+	HashListFilePath   string `mapstructure:"hash_list_file_path"`
+	ResolveBaseDigests bool   `mapstructure:"resolve_base_digests"`
+
+	// Promote specific options
+	From     string   `mapstructure:"from"`
+	To       string   `mapstructure:"to"`
+	BuildArg []string `mapstructure:"build_arg,omitempty"`
+	DryRun   bool     `mapstructure:"dry_run"`
+}
+
+// Promote copies the current hash of every image of the graph, generated with the source registry, to the
+// destination registry, then tags it with the placeholder tag and the extra tags there. Images are copied as is,
+// with all their blobs, so nothing is rebuilt. When dryRun is true, the copies are only logged.
+func Promote(graph *dag.DAG, copier types.ImageCopier, to, placeholderTag string, dryRun bool) error {
+	to = strings.TrimSuffix(to, "/")
+
+	return graph.WalkAsyncErr(func(node *dag.Node) error {
+		img := node.Image
+		src := img.DockerRef(img.Hash)
+		dest := fmt.Sprintf("%s/%s", to, img.ShortName)
+
+		refs := []string{dest + ":" + img.Hash, dest + ":" + placeholderTag}
+		for _, extraTag := range img.ExtraTags {
+			refs = append(refs, dest+":"+extraTag)
+		}
+
+		// The hash is copied from the source registry, then the tags are created from it in the destination
+		// registry, where the blobs already exist.
+		from := src
+		for _, ref := range refs {
+			if dryRun {
+				logger.Infof("[DRY-RUN] Promoting \"%s\" to \"%s\"", from, ref)
+				from = refs[0]
+
+				continue
+			}
+
+			imageLogger(img).Debugf("Promoting \"%s\" to \"%s\"", from, ref)
+
+			err := copier.Copy(from, ref)
+			if err != nil {
+				return fmt.Errorf("cannot promote %s: %w", src, err)
+			}
+
+			from = refs[0]
+		}
+
+		if !dryRun {
+			imageLogger(img).Infof("Promoted \"%s\" to \"%s\"", src, refs[0])
+		}
+
+		return nil
+	})
+}
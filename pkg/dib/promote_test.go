@@ -0,0 +1,63 @@
+package dib_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPromoteTestGraph() *dag.DAG {
+	graph := &dag.DAG{}
+	graph.AddNode(dag.NewNode(&dag.Image{
+		Name:      "staging.example.org/app",
+		ShortName: "app",
+		Hash:      "alpha-bravo-charlie-delta",
+		ExtraTags: []string{"1.0.0", "1.0"},
+	}))
+
+	return graph
+}
+
+func Test_Promote(t *testing.T) {
+	t.Parallel()
+
+	copier := &mock.ImageCopier{}
+
+	err := dib.Promote(newPromoteTestGraph(), copier, "prod.example.org/", "latest", false)
+	require.NoError(t, err)
+
+	const hashRef = "prod.example.org/app:alpha-bravo-charlie-delta"
+
+	require.Len(t, copier.RecordedCallsArgs, 4)
+	assert.Equal(t, "staging.example.org/app:alpha-bravo-charlie-delta", copier.RecordedCallsArgs[0].Src)
+	assert.Equal(t, hashRef, copier.RecordedCallsArgs[0].Dest)
+
+	for i, dest := range []string{"prod.example.org/app:latest", "prod.example.org/app:1.0.0", "prod.example.org/app:1.0"} {
+		assert.Equal(t, hashRef, copier.RecordedCallsArgs[i+1].Src)
+		assert.Equal(t, dest, copier.RecordedCallsArgs[i+1].Dest)
+	}
+}
+
+func Test_Promote_DryRun(t *testing.T) {
+	t.Parallel()
+
+	copier := &mock.ImageCopier{}
+
+	err := dib.Promote(newPromoteTestGraph(), copier, "prod.example.org", "latest", true)
+	require.NoError(t, err)
+	assert.Empty(t, copier.RecordedCallsArgs)
+}
+
+func Test_Promote_FailsWhenImageIsMissing(t *testing.T) {
+	t.Parallel()
+
+	copier := &mock.ImageCopier{Missing: []string{"staging.example.org/app:alpha-bravo-charlie-delta"}}
+
+	err := dib.Promote(newPromoteTestGraph(), copier, "prod.example.org", "latest", false)
+	require.ErrorContains(t, err, "cannot promote staging.example.org/app:alpha-bravo-charlie-delta")
+	assert.Empty(t, copier.RecordedCallsArgs)
+}
@@ -0,0 +1,103 @@
+package dib
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/report"
+	"github.com/radiofrance/dib/pkg/tracing"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+// Sign iterates over the graph to sign the final digest of every image that was rebuilt, or retagged on release,
+// in the primary and extra registries.
+// It returns the refs of the signatures, sorted by image.
+func Sign(ctx context.Context, graph *dag.DAG, signer types.ImageSigner, release bool) (
+	signatures []report.Signature, err error,
+) {
+	ctx, span := tracing.Start(ctx, "sign")
+	defer func() { tracing.End(span, err) }()
+
+	var mutex sync.Mutex
+
+	err = graph.WalkAsyncErr(func(node *dag.Node) (err error) {
+		img := node.Image
+		if !img.NeedsRebuild && !release {
+			return nil
+		}
+
+		signCtx, imgSpan := tracing.Start(ctx, "sign_image", tracing.ImageAttributes(img)...)
+		defer func() { tracing.End(imgSpan, err) }()
+
+		// Signatures are stored next to the images, so the image is signed in every registry it is pushed to.
+		for _, final := range img.DockerRefs(img.Hash) {
+			imageLogger(img).Debugf("Signing \"%s\"", final)
+
+			signatureRef, err := signer.Sign(signCtx, final)
+			if err != nil {
+				return fmt.Errorf("cannot sign %s: %w", final, err)
+			}
+
+			if signatureRef == "" {
+				continue
+			}
+
+			mutex.Lock()
+			signatures = append(signatures, report.Signature{Image: final, Signature: signatureRef})
+			mutex.Unlock()
+		}
+
+		return nil
+	})
+
+	slices.SortFunc(signatures, func(a, b report.Signature) int {
+		return cmp.Compare(a.Image, b.Image)
+	})
+
+	return signatures, err
+}
+
+// verifyBaseImages checks the base images of the node carry a valid signature: the dib-managed parent images, and
+// the external base images of the Dockerfile, with their build arguments expanded, unless their name is ignored.
+// Parents rebuilt in the same run are not signed yet, as images are signed after the retag. They are trusted, since
+// their own base images were verified before they were built.
+func verifyBaseImages(
+	ctx context.Context,
+	node *dag.Node,
+	verifier types.SignatureVerifier,
+	ignore []string,
+	buildArgs map[string]string,
+) error {
+	for _, parent := range node.Parents() {
+		if parent.Image.NeedsRebuild {
+			continue
+		}
+
+		err := verifier.Verify(ctx, parent.Image.CurrentRef())
+		if err != nil {
+			return fmt.Errorf("parent image %s is not trusted: %w", parent.Image.ShortName, err)
+		}
+	}
+
+	for _, ref := range nodeBaseImages(node) {
+		expanded, err := expandBuildArgs(ref, node.Image.Dockerfile, buildArgs)
+		if err != nil {
+			return fmt.Errorf("cannot verify the signature: %w", err)
+		}
+
+		if slices.Contains(ignore, expanded.Name) {
+			continue
+		}
+
+		err = verifier.Verify(ctx, imageRefString(expanded))
+		if err != nil {
+			return fmt.Errorf("base image %s is not trusted: %w", imageRefString(expanded), err)
+		}
+	}
+
+	return nil
+}
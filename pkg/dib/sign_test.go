@@ -0,0 +1,93 @@
+package dib_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSignTestGraph() *dag.DAG {
+	graph := &dag.DAG{}
+	rebuilt := dag.NewNode(&dag.Image{
+		Name:         "registry.example.org/rebuilt",
+		ShortName:    "rebuilt",
+		Hash:         "alpha-bravo-charlie-delta",
+		NeedsRebuild: true,
+	})
+	unchanged := dag.NewNode(&dag.Image{
+		Name:      "registry.example.org/unchanged",
+		ShortName: "unchanged",
+		Mirrors:   []string{"mirror.example.org/unchanged"},
+		Hash:      "echo-foxtrot-golf-hotel",
+	})
+	rebuilt.AddChild(unchanged)
+	graph.AddNode(rebuilt)
+
+	return graph
+}
+
+func Test_Sign(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		release  bool
+		expected []report.Signature
+	}{
+		{
+			name: "rebuilt images only",
+			expected: []report.Signature{
+				{
+					Image:     "registry.example.org/rebuilt:alpha-bravo-charlie-delta",
+					Signature: "registry.example.org/rebuilt:alpha-bravo-charlie-delta.sig",
+				},
+			},
+		},
+		{
+			name:    "all images on release",
+			release: true,
+			expected: []report.Signature{
+				{
+					Image:     "mirror.example.org/unchanged:echo-foxtrot-golf-hotel",
+					Signature: "mirror.example.org/unchanged:echo-foxtrot-golf-hotel.sig",
+				},
+				{
+					Image:     "registry.example.org/rebuilt:alpha-bravo-charlie-delta",
+					Signature: "registry.example.org/rebuilt:alpha-bravo-charlie-delta.sig",
+				},
+				{
+					Image:     "registry.example.org/unchanged:echo-foxtrot-golf-hotel",
+					Signature: "registry.example.org/unchanged:echo-foxtrot-golf-hotel.sig",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			signer := &mock.ImageSigner{}
+			signatures, err := dib.Sign(t.Context(), newSignTestGraph(), signer, test.release)
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, signatures)
+			assert.Len(t, signer.Signed, len(test.expected))
+		})
+	}
+}
+
+func Test_Sign_FailsWhenSigningFails(t *testing.T) {
+	t.Parallel()
+
+	signer := &mock.ImageSigner{ReturnedError: errors.New("mock signing failed")}
+	_, err := dib.Sign(t.Context(), newSignTestGraph(), signer, false)
+
+	require.ErrorContains(t, err, "cannot sign registry.example.org/rebuilt:alpha-bravo-charlie-delta: mock signing failed")
+}
@@ -1,46 +1,70 @@
 package dib
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/radiofrance/dib/pkg/dag"
-	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/tracing"
 	"github.com/radiofrance/dib/pkg/types"
 )
 
-// Retag iterates over the graph to tag all images.
-func Retag(graph *dag.DAG, tagger types.ImageTagger, placeholderTag string, release bool) error {
-	return graph.WalkAsyncErr(func(node *dag.Node) error {
-		img := node.Image
-		if img.RetagDone {
+// Retag iterates over the graph to tag all images, in the primary and extra registries.
+// When mirrorer is not nil, the images which were not rebuilt are copied from the primary registry to the extra
+// registries missing them, before being tagged there.
+// When sbomProvider is not nil, every new tag is checked to resolve to an image carrying an SBOM.
+func Retag(
+	ctx context.Context,
+	graph *dag.DAG,
+	tagger types.ImageTagger,
+	mirrorer types.ImageMirrorer,
+	sbomProvider types.SBOMProvider,
+	placeholderTag string,
+	release bool,
+) (err error) {
+	ctx, span := tracing.Start(ctx, "retag")
+	defer func() { tracing.End(span, err) }()
+
+	tag := func(from, to string) error {
+		err := tagger.Tag(from, to)
+		if err != nil {
+			return err
+		}
+
+		if sbomProvider == nil {
 			return nil
 		}
 
-		current := img.CurrentRef()
+		err = sbomProvider.Ensure(ctx, to)
+		if err != nil {
+			return fmt.Errorf("cannot ensure %s carries an SBOM: %w", to, err)
+		}
 
-		final := img.DockerRef(img.Hash)
-		if current != final {
-			logger.Debugf("Tagging \"%s\" from \"%s\"", final, current)
+		return nil
+	}
 
-			err := tagger.Tag(current, final)
-			if err != nil {
-				return err
-			}
+	return graph.WalkAsyncErr(func(node *dag.Node) (err error) {
+		img := node.Image
+		if img.RetagDone {
+			return nil
 		}
 
-		if release {
-			err := tagger.Tag(final, img.DockerRef(placeholderTag))
-			if err != nil {
-				return err
-			}
-
-			for _, tag := range img.ExtraTags {
-				extra := img.DockerRef(tag)
-				logger.Debugf("Tagging \"%s\" from \"%s\"", extra, final)
+		_, imgSpan := tracing.Start(ctx, "retag_image", tracing.ImageAttributes(img)...)
+		defer func() { tracing.End(imgSpan, err) }()
 
-				err := tagger.Tag(final, extra)
+		currentRefs := img.CurrentRefs()
+		for i, name := range img.Names() {
+			if i > 0 && !img.NeedsRebuild && mirrorer != nil {
+				err := mirrorRef(img, mirrorer, currentRefs[i])
 				if err != nil {
 					return err
 				}
 			}
+
+			err := retagRef(img, tag, name, currentRefs[i], placeholderTag, release)
+			if err != nil {
+				return err
+			}
 		}
 
 		img.RetagDone = true
@@ -48,3 +72,67 @@ func Retag(graph *dag.DAG, tagger types.ImageTagger, placeholderTag string, rele
 		return nil
 	})
 }
+
+// mirrorRef copies the image from the primary registry to the ref in an extra registry, unless it already exists.
+// Images which are not rebuilt are only known to exist in the primary registry, for instance when an extra
+// registry was added since they were last built.
+func mirrorRef(img *dag.Image, mirrorer types.ImageMirrorer, ref string) error {
+	exists, err := mirrorer.RefExists(ref)
+	if err != nil {
+		return fmt.Errorf("cannot check if %s exists: %w", ref, err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	primary := img.DockerRef(img.Hash)
+	imageLogger(img).Infof("Copying \"%s\" to \"%s\"", primary, ref)
+
+	err = mirrorer.Copy(primary, ref)
+	if err != nil {
+		return fmt.Errorf("cannot copy %s to %s: %w", primary, ref, err)
+	}
+
+	return nil
+}
+
+// retagRef tags the current ref of the image in one of its registries with its final tag, then with the
+// placeholder tag and the extra tags on release.
+func retagRef(
+	img *dag.Image,
+	tag func(from, to string) error,
+	name, current, placeholderTag string,
+	release bool,
+) error {
+	final := fmt.Sprintf("%s:%s", name, img.Hash)
+	if current != final {
+		imageLogger(img).Debugf("Tagging \"%s\" from \"%s\"", final, current)
+
+		err := tag(current, final)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !release {
+		return nil
+	}
+
+	err := tag(final, fmt.Sprintf("%s:%s", name, placeholderTag))
+	if err != nil {
+		return err
+	}
+
+	for _, extraTag := range img.ExtraTags {
+		extra := fmt.Sprintf("%s:%s", name, extraTag)
+		imageLogger(img).Debugf("Tagging \"%s\" from \"%s\"", extra, final)
+
+		err := tag(final, extra)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package dib_test
 
 import (
+	"errors"
+	"sync"
 	"testing"
 
 	"github.com/radiofrance/dib/pkg/dag"
@@ -21,7 +23,7 @@ func Test_Retag_DoesNotRetagIfNoRetagNeeded(t *testing.T) {
 	}))
 
 	tagger := &mock.Tagger{}
-	err := dib.Retag(DAG, tagger, "DIB_MANAGED_VERSION", false)
+	err := dib.Retag(t.Context(), DAG, tagger, nil, nil, "DIB_MANAGED_VERSION", false)
 
 	require.NoError(t, err)
 	assert.Empty(t, tagger.RecordedCallsArgs)
@@ -40,7 +42,7 @@ func Test_Retag_RetagWhenRebuild(t *testing.T) {
 	}))
 
 	tagger := &mock.Tagger{}
-	err := dib.Retag(DAG, tagger, "DIB_MANAGED_VERSION", false)
+	err := dib.Retag(t.Context(), DAG, tagger, nil, nil, "DIB_MANAGED_VERSION", false)
 
 	require.NoError(t, err)
 	require.Len(t, tagger.RecordedCallsArgs, 1)
@@ -63,7 +65,7 @@ func Test_Retag_ReleaseWithPlaceholderTagAndExtraTags(t *testing.T) {
 	DAG.AddNode(dag.NewNode(img))
 
 	tagger := &mock.Tagger{}
-	err := dib.Retag(DAG, tagger, "DIB_MANAGED_VERSION", true)
+	err := dib.Retag(t.Context(), DAG, tagger, nil, nil, "DIB_MANAGED_VERSION", true)
 
 	require.NoError(t, err)
 
@@ -78,3 +80,137 @@ func Test_Retag_ReleaseWithPlaceholderTagAndExtraTags(t *testing.T) {
 
 	assert.True(t, img.RetagDone)
 }
+
+func Test_Retag_TagsMirrors(t *testing.T) {
+	t.Parallel()
+
+	DAG := &dag.DAG{}
+	DAG.AddNode(dag.NewNode(&dag.Image{
+		Name:         "registry.example.org/image",
+		ShortName:    "image",
+		Mirrors:      []string{"mirror.example.org/image"},
+		Hash:         "myhash",
+		ExtraTags:    []string{"latest1"},
+		NeedsRebuild: true,
+	}))
+
+	tagger := &mock.Tagger{}
+	err := dib.Retag(t.Context(), DAG, tagger, nil, nil, "DIB_MANAGED_VERSION", true)
+
+	require.NoError(t, err)
+
+	dests := make([]string, 0, len(tagger.RecordedCallsArgs))
+	for _, args := range tagger.RecordedCallsArgs {
+		dests = append(dests, args.Src+" -> "+args.Dest)
+	}
+
+	assert.Equal(t, []string{
+		"registry.example.org/image:dev-myhash -> registry.example.org/image:myhash",
+		"registry.example.org/image:myhash -> registry.example.org/image:DIB_MANAGED_VERSION",
+		"registry.example.org/image:myhash -> registry.example.org/image:latest1",
+		"mirror.example.org/image:dev-myhash -> mirror.example.org/image:myhash",
+		"mirror.example.org/image:myhash -> mirror.example.org/image:DIB_MANAGED_VERSION",
+		"mirror.example.org/image:myhash -> mirror.example.org/image:latest1",
+	}, dests)
+}
+
+type mirrorer struct {
+	*mock.Registry
+	*mock.ImageCopier
+}
+
+func Test_Retag_CopiesImagesMissingFromMirrors(t *testing.T) {
+	t.Parallel()
+
+	DAG := &dag.DAG{}
+	DAG.AddNode(dag.NewNode(&dag.Image{
+		Name:      "registry.example.org/image",
+		ShortName: "image",
+		Mirrors:   []string{"mirror.example.org/image", "synced.example.org/image"},
+		Hash:      "myhash",
+	}))
+
+	tagger := &mock.Tagger{}
+	copier := &mock.ImageCopier{}
+	registry := &mock.Registry{
+		Lock:         &sync.Mutex{},
+		ExistingRefs: []string{"registry.example.org/image:myhash", "synced.example.org/image:myhash"},
+	}
+
+	err := dib.Retag(t.Context(), DAG, tagger, mirrorer{registry, copier}, nil, "DIB_MANAGED_VERSION", true)
+	require.NoError(t, err)
+
+	require.Len(t, copier.RecordedCallsArgs, 1)
+	assert.Equal(t, "registry.example.org/image:myhash", copier.RecordedCallsArgs[0].Src)
+	assert.Equal(t, "mirror.example.org/image:myhash", copier.RecordedCallsArgs[0].Dest)
+
+	dests := make([]string, 0, len(tagger.RecordedCallsArgs))
+	for _, args := range tagger.RecordedCallsArgs {
+		dests = append(dests, args.Src+" -> "+args.Dest)
+	}
+
+	assert.Equal(t, []string{
+		"registry.example.org/image:myhash -> registry.example.org/image:DIB_MANAGED_VERSION",
+		"mirror.example.org/image:myhash -> mirror.example.org/image:DIB_MANAGED_VERSION",
+		"synced.example.org/image:myhash -> synced.example.org/image:DIB_MANAGED_VERSION",
+	}, dests)
+}
+
+func Test_Retag_FailsWhenMirrorCannotBeCopied(t *testing.T) {
+	t.Parallel()
+
+	DAG := &dag.DAG{}
+	DAG.AddNode(dag.NewNode(&dag.Image{
+		Name:      "registry.example.org/image",
+		ShortName: "image",
+		Mirrors:   []string{"mirror.example.org/image"},
+		Hash:      "myhash",
+	}))
+
+	copier := &mock.ImageCopier{Missing: []string{"registry.example.org/image:myhash"}}
+	registry := &mock.Registry{Lock: &sync.Mutex{}}
+
+	err := dib.Retag(t.Context(), DAG, &mock.Tagger{}, mirrorer{registry, copier}, nil, "DIB_MANAGED_VERSION", false)
+	require.ErrorContains(t, err,
+		"cannot copy registry.example.org/image:myhash to mirror.example.org/image:myhash")
+}
+
+func Test_Retag_EnsuresSBOMOnNewTags(t *testing.T) {
+	t.Parallel()
+
+	DAG := &dag.DAG{}
+	DAG.AddNode(dag.NewNode(&dag.Image{
+		Name:         "registry.example.org/image",
+		ShortName:    "image",
+		Hash:         "myhash",
+		ExtraTags:    []string{"latest1"},
+		NeedsRebuild: true,
+	}))
+
+	sbomProvider := &mock.SBOMProvider{}
+	err := dib.Retag(t.Context(), DAG, &mock.Tagger{}, nil, sbomProvider, "DIB_MANAGED_VERSION", true)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"registry.example.org/image:myhash",
+		"registry.example.org/image:DIB_MANAGED_VERSION",
+		"registry.example.org/image:latest1",
+	}, sbomProvider.Ensured)
+}
+
+func Test_Retag_FailsWhenSBOMCannotBeEnsured(t *testing.T) {
+	t.Parallel()
+
+	DAG := &dag.DAG{}
+	DAG.AddNode(dag.NewNode(&dag.Image{
+		Name:         "registry.example.org/image",
+		ShortName:    "image",
+		Hash:         "myhash",
+		NeedsRebuild: true,
+	}))
+
+	sbomProvider := &mock.SBOMProvider{ReturnedError: errors.New("no SBOM found")}
+	err := dib.Retag(t.Context(), DAG, &mock.Tagger{}, nil, sbomProvider, "DIB_MANAGED_VERSION", false)
+
+	require.ErrorContains(t, err, "cannot ensure registry.example.org/image:myhash carries an SBOM: no SBOM found")
+}
@@ -10,7 +10,8 @@ import (
 
 // testImage runs the tests on an image.
 func testImage(ctx context.Context, testRunners []types.TestRunner, runTestOpts types.RunTestOptions) error {
-	logger.Infof("Running tests for \"%s\"", runTestOpts.ImageReference)
+	log := logger.With("image", runTestOpts.ImageName, "ref", runTestOpts.ImageReference)
+	log.Infof("Running tests for \"%s\"", runTestOpts.ImageReference)
 
 	errG := new(errgroup.Group)
 	for _, runner := range testRunners {
@@ -21,7 +22,7 @@ func testImage(ctx context.Context, testRunners []types.TestRunner, runTestOpts
 
 			err := runner.RunTest(ctx, runTestOpts)
 			if err != nil {
-				logger.Errorf("Test runner %s failed on image %s with error: %v",
+				log.With("runner", runner.Name()).Errorf("Test runner %s failed on image %s with error: %v",
 					runner.Name(), runTestOpts.ImageName, err)
 
 				return err
@@ -33,3 +34,19 @@ func testImage(ctx context.Context, testRunners []types.TestRunner, runTestOpts
 
 	return errG.Wait()
 }
+
+// splitTestRunners separates the test runners checking the build context, which run before the build,
+// from the ones testing the built image.
+func splitTestRunners(testRunners []types.TestRunner) ([]types.TestRunner, []types.TestRunner) {
+	var preBuild, postBuild []types.TestRunner
+
+	for _, runner := range testRunners {
+		if preBuildRunner, ok := runner.(types.PreBuildTestRunner); ok && preBuildRunner.RunBeforeBuild() {
+			preBuild = append(preBuild, runner)
+		} else {
+			postBuild = append(postBuild, runner)
+		}
+	}
+
+	return preBuild, postBuild
+}
@@ -0,0 +1,361 @@
+package dib
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/moby/patternmatcher"
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	rxTag       = regexp.MustCompile(`^` + reference.TagRegexp.String() + `$`)
+	rxYAMLLine  = regexp.MustCompile(`line (\d+)`)
+	testsConfig = []string{"goss.yaml", "structure-test.yaml"}
+)
+
+type ValidateOpts struct {
+	// Root options
+	BuildPath         string `mapstructure:"build_path"`
+	RegistryURL       string `mapstructure:"registry_url"`
+	NameTemplate      string `mapstructure:"name_template"`
+	NameFromDirectory bool   `mapstructure:"name_from_directory"`
+}
+
+// Problem is an issue found while validating the build path.
+type Problem struct {
+	File string
+	// Line is the line of the file where the problem was found, or 0 when it concerns the whole file.
+	Line    int
+	Message string
+}
+
+// String returns the problem formatted as "file:line: message".
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// validatedImage holds what is needed to check the consistency between images.
+type validatedImage struct {
+	name         string
+	file         string
+	nameLine     int
+	skipBuild    bool
+	from         []dockerfile.ImageRef
+	instructions []dockerfile.Instruction
+}
+
+// Validate checks all the Dockerfiles of the build path, and returns all the problems found, sorted by file and line.
+// Unlike GenerateDAG, which stops at the first error, every problem is reported.
+func Validate(buildPath string, naming Naming) []Problem {
+	namer, err := naming.newImageNamer(buildPath)
+	if err != nil {
+		return []Problem{{File: buildPath, Message: err.Error()}}
+	}
+
+	var (
+		problems []Problem
+		images   []*validatedImage
+	)
+
+	err = filepath.WalkDir(buildPath, func(name string, dir os.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			problems = append(problems, Problem{File: name, Message: err.Error()})
+			if dir != nil && dir.IsDir() {
+				return filepath.SkipDir
+			}
+		case dir.IsDir():
+		case dockerfile.IsDockerfile(name):
+			img, imgProblems := validateDockerfile(name, namer)
+			problems = append(problems, imgProblems...)
+
+			if img != nil {
+				images = append(images, img)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		problems = append(problems, Problem{File: buildPath, Message: err.Error()})
+	}
+
+	problems = append(problems, duplicateNames(images)...)
+	problems = append(problems, dependencyCycles(images)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+
+		return problems[i].Line < problems[j].Line
+	})
+
+	return problems
+}
+
+// validateDockerfile checks the labels of the Dockerfile, and the files of its build context.
+func validateDockerfile(filename string, namer *imageNamer) (*validatedImage, []Problem) {
+	dckFile, err := dockerfile.ParseDockerfile(filename)
+	if err != nil {
+		return nil, []Problem{{File: filename, Message: fmt.Sprintf("cannot parse Dockerfile: %v", err)}}
+	}
+
+	instructions, err := dockerfile.ParseInstructions(filename)
+	if err != nil {
+		return nil, []Problem{{File: filename, Message: fmt.Sprintf("cannot parse Dockerfile: %v", err)}}
+	}
+
+	var problems []Problem
+
+	img := &validatedImage{
+		file:         filename,
+		skipBuild:    dckFile.Labels["skipbuild"] == "true",
+		from:         dckFile.From,
+		instructions: instructions,
+	}
+
+	imageName, _, err := namer.imageName(dckFile)
+
+	switch {
+	case err == nil:
+		img.name = imageName
+		img.nameLine = labelLine(instructions, "name")
+
+		_, err := reference.ParseNormalizedNamed(img.name)
+		if err != nil {
+			problems = append(problems, Problem{
+				File:    filename,
+				Line:    img.nameLine,
+				Message: fmt.Sprintf("invalid image name %q: %v", img.name, err),
+			})
+		}
+	case img.skipBuild:
+	default:
+		problems = append(problems, Problem{File: filename, Message: err.Error()})
+	}
+
+	if value, ok := dckFile.Labels["dib.extra-tags"]; ok {
+		for tag := range strings.SplitSeq(value, ",") {
+			if !rxTag.MatchString(tag) {
+				problems = append(problems, Problem{
+					File:    filename,
+					Line:    labelLine(instructions, "dib.extra-tags"),
+					Message: fmt.Sprintf("invalid extra tag %q in label \"dib.extra-tags\"", tag),
+				})
+			}
+		}
+	}
+
+	if value, ok := dckFile.Labels[extraRegistriesLabel]; ok && value != "true" && value != "false" {
+		problems = append(problems, Problem{
+			File:    filename,
+			Line:    labelLine(instructions, extraRegistriesLabel),
+			Message: fmt.Sprintf("invalid value %q in label %q, must be \"true\" or \"false\"", value, extraRegistriesLabel),
+		})
+	}
+
+	if img.skipBuild {
+		return img, problems
+	}
+
+	problems = append(problems, validateBuildContext(dckFile.ContextPath)...)
+
+	return img, problems
+}
+
+// validateBuildContext checks the .dockerignore file and the test files of the build context can be read.
+func validateBuildContext(contextPath string) []Problem {
+	var problems []Problem
+
+	ignorePatterns, err := build.ReadDockerignore(contextPath)
+	if err != nil {
+		problems = append(problems, Problem{
+			File:    path.Join(contextPath, dockerignore),
+			Message: fmt.Sprintf("could not read dockerignore: %v", err),
+		})
+	} else if _, err := patternmatcher.New(ignorePatterns); err != nil {
+		problems = append(problems, Problem{
+			File:    path.Join(contextPath, dockerignore),
+			Message: fmt.Sprintf("invalid dockerignore pattern: %v", err),
+		})
+	}
+
+	for _, testConfig := range testsConfig {
+		filename := path.Join(contextPath, testConfig)
+
+		content, err := os.ReadFile(filename) //nolint:gosec
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err == nil {
+			var parsed any
+			err = yaml.Unmarshal(content, &parsed)
+		}
+
+		if err != nil {
+			problem := Problem{File: filename, Message: fmt.Sprintf("invalid test file: %v", err)}
+			if match := rxYAMLLine.FindStringSubmatch(err.Error()); match != nil {
+				problem.Line, _ = strconv.Atoi(match[1])
+			}
+
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
+
+// duplicateNames reports the images using the name of an image found before.
+func duplicateNames(images []*validatedImage) []Problem {
+	var problems []Problem
+
+	files := make(map[string]string)
+
+	for _, img := range images {
+		if img.name == "" {
+			continue
+		}
+
+		if previous, ok := files[img.name]; ok {
+			problems = append(problems, Problem{
+				File:    img.file,
+				Line:    img.nameLine,
+				Message: fmt.Sprintf("duplicate image name %q, already used by %q", img.name, previous),
+			})
+
+			continue
+		}
+
+		files[img.name] = img.file
+	}
+
+	return problems
+}
+
+// dependencyCycles reports the images depending on themselves, directly or through their parents.
+// Such images never become roots of the graph, so they would never be built.
+func dependencyCycles(images []*validatedImage) []Problem {
+	byName := make(map[string]*validatedImage)
+
+	for _, img := range images {
+		if img.name != "" && !img.skipBuild {
+			if _, ok := byName[img.name]; !ok {
+				byName[img.name] = img
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var (
+		problems []Problem
+		stack    []string
+	)
+
+	state := make(map[string]int)
+	reported := make(map[string]struct{})
+
+	var visit func(img *validatedImage)
+	visit = func(img *validatedImage) {
+		state[img.name] = visiting
+		stack = append(stack, img.name)
+
+		for _, from := range img.from {
+			parent, ok := byName[from.Name]
+			if !ok {
+				continue
+			}
+
+			switch state[parent.name] {
+			case unvisited:
+				visit(parent)
+			case visiting:
+				cycle := append(slices.Clone(stack[slices.Index(stack, parent.name):]), parent.name)
+
+				members := slices.Clone(cycle[:len(cycle)-1])
+				slices.Sort(members)
+
+				key := strings.Join(members, ",")
+				if _, ok := reported[key]; ok {
+					continue
+				}
+
+				reported[key] = struct{}{}
+
+				problems = append(problems, Problem{
+					File:    img.file,
+					Line:    fromLine(img.instructions, from.Name),
+					Message: "dependency cycle: " + strings.Join(cycle, " -> "),
+				})
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[img.name] = visited
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(byName[name])
+		}
+	}
+
+	return problems
+}
+
+// labelLine returns the line of the LABEL instruction defining the label, or 0 if not found.
+func labelLine(instructions []dockerfile.Instruction, label string) int {
+	for _, instruction := range instructions {
+		if instruction.Command == "LABEL" && strings.HasPrefix(instruction.Args, label+"=") {
+			return instruction.Line
+		}
+	}
+
+	return 0
+}
+
+// fromLine returns the line of the first FROM instruction using the image, or 0 if not found.
+func fromLine(instructions []dockerfile.Instruction, imageName string) int {
+	for _, instruction := range instructions {
+		if instruction.Command != "FROM" {
+			continue
+		}
+
+		for field := range strings.FieldsSeq(instruction.Args) {
+			name, _, _ := strings.Cut(field, "@")
+			if name == imageName || strings.HasPrefix(name, imageName+":") {
+				return instruction.Line
+			}
+		}
+	}
+
+	return 0
+}
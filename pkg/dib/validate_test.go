@@ -0,0 +1,134 @@
+package dib_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		filename := path.Join(dir, name)
+		require.NoError(t, os.MkdirAll(path.Dir(filename), 0o750))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "valid build path",
+			files: map[string]string{
+				"root/Dockerfile":       "FROM debian:12\nLABEL name=\"root\"\nLABEL dib.extra-tags=\"v1,latest\"\n",
+				"local/Dockerfile":      "FROM debian:12\nLABEL name=\"local\"\nLABEL dib.extra-registries=\"false\"\n",
+				"root/goss.yaml":        "command:\n  echo:\n    exit-status: 0\n",
+				"root/child/Dockerfile": "FROM registry.example.org/root:v1\nLABEL name=\"child\"\n",
+				"skipped/Dockerfile":    "FROM debian:12\nLABEL skipbuild=\"true\"\n",
+			},
+		},
+		{
+			name: "invalid labels",
+			files: map[string]string{
+				"noname/Dockerfile": "FROM debian:12\n",
+				"upper/Dockerfile":  "FROM debian:12\n\nLABEL name=\"Upper\"\nLABEL dib.extra-tags=\"v1,,-bad\"\n",
+				"mirror/Dockerfile": "FROM debian:12\nLABEL name=\"mirror\"\nLABEL dib.extra-registries=\"no\"\n",
+			},
+			expected: []string{
+				"mirror/Dockerfile:3: invalid value \"no\" in label \"dib.extra-registries\", must be \"true\" or \"false\"",
+				"noname/Dockerfile: missing label \"name\"",
+				"upper/Dockerfile:3: invalid image name \"registry.example.org/Upper\": " +
+					"invalid reference format: repository name (Upper) must be lowercase",
+				"upper/Dockerfile:4: invalid extra tag \"\" in label \"dib.extra-tags\"",
+				"upper/Dockerfile:4: invalid extra tag \"-bad\" in label \"dib.extra-tags\"",
+			},
+		},
+		{
+			name: "duplicate names and cycles",
+			files: map[string]string{
+				"a/Dockerfile":   "FROM registry.example.org/b\nLABEL name=\"a\"\n",
+				"b/Dockerfile":   "FROM registry.example.org/a:v1\nLABEL name=\"b\"\n",
+				"dup/Dockerfile": "FROM debian:12\nLABEL name=\"a\"\n",
+			},
+			expected: []string{
+				"b/Dockerfile:1: dependency cycle: " +
+					"registry.example.org/a -> registry.example.org/b -> registry.example.org/a",
+				"dup/Dockerfile:2: duplicate image name \"registry.example.org/a\", already used by \"a/Dockerfile\"",
+			},
+		},
+		{
+			name: "invalid test files",
+			files: map[string]string{
+				"img/Dockerfile":          "FROM debian:12\nLABEL name=\"img\"\n",
+				"img/goss.yaml":           "command:\n  echo:\n\texit-status: 0\n",
+				"img/structure-test.yaml": "schemaVersion: 2.0.0\n",
+			},
+			expected: []string{
+				"img/goss.yaml:3: invalid test file: yaml: line 3: found character that cannot start any token",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buildPath := t.TempDir()
+			writeFiles(t, buildPath, test.files)
+
+			problems := dib.Validate(buildPath, dib.Naming{RegistryURL: "registry.example.org"})
+
+			actual := make([]string, 0, len(problems))
+			for _, problem := range problems {
+				problem.File = strings.TrimPrefix(problem.File, buildPath+"/")
+				problem.Message = strings.ReplaceAll(problem.Message, buildPath+"/", "")
+				actual = append(actual, problem.String())
+			}
+
+			if test.expected == nil {
+				assert.Empty(t, actual)
+				return
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_Validate_NameOutsideOfRegistry(t *testing.T) {
+	t.Parallel()
+
+	buildPath := t.TempDir()
+	writeFiles(t, buildPath, map[string]string{
+		"nginx/Dockerfile": "FROM debian:12\nLABEL name=\"nginx\"\n",
+	})
+
+	problems := dib.Validate(buildPath, dib.Naming{
+		RegistryURL: "registry.example.org",
+		Template:    "other.example.org/{{.Name}}",
+	})
+
+	require.Len(t, problems, 1)
+	assert.Equal(t, path.Join(buildPath, "nginx/Dockerfile"), problems[0].File)
+	assert.Equal(t, "image name \"other.example.org/nginx\" is not in the registry \"registry.example.org\"",
+		problems[0].Message)
+}
+
+func Test_Problem_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Dockerfile:3: message", dib.Problem{File: "Dockerfile", Line: 3, Message: "message"}.String())
+	assert.Equal(t, "goss.yaml: message", dib.Problem{File: "goss.yaml", Message: "message"}.String())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/radiofrance/dib/pkg/executor"
 	"github.com/radiofrance/dib/pkg/logger"
@@ -63,6 +64,8 @@ func (b *ImageBuilderTagger) Build(_ context.Context, opts types.ImageBuilderOpt
 	}
 
 	if opts.Push {
+		pushStart := time.Now()
+
 		for _, tag := range opts.Tags {
 			err := b.exec.ExecuteWithWriter(
 				opts.LogOutput, "docker", "push", tag)
@@ -70,6 +73,10 @@ func (b *ImageBuilderTagger) Build(_ context.Context, opts types.ImageBuilderOpt
 				return err
 			}
 		}
+
+		if opts.OnPushed != nil {
+			opts.OnPushed(time.Since(pushStart))
+		}
 	}
 
 	return nil
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/radiofrance/dib/pkg/logger"
@@ -15,6 +16,7 @@ const dockerfileName = "Dockerfile"
 
 var (
 	rxFrom  = regexp.MustCompile(`^FROM\s+(?P<ref>(?P<image>[^:@\s]+):?(?P<tag>[^\s@]+)?@?(?P<digest>sha256:[^\s]+)?)`) //nolint:lll
+	rxStage = regexp.MustCompile(`^FROM\s+\S+\s+(?i:AS)\s+(\S+)`)
 	rxLabel = regexp.MustCompile(`^LABEL\s+(\S+)="(\S+)"`)
 	rxArg   = regexp.MustCompile(`^ARG\s+([a-zA-Z_]\w*)(\s*=\s*[^#\n]*)?`)
 )
@@ -24,6 +26,7 @@ type Dockerfile struct {
 	ContextPath string
 	Filename    string
 	From        []ImageRef
+	Stages      []string `yaml:",omitempty"`
 	Labels      map[string]string
 	Args        map[string]string
 }
@@ -47,6 +50,33 @@ func (d *Dockerfile) addArg(name string, value string) {
 	d.Args[name] = value
 }
 
+// ArgDefault returns the default value of the build argument, from its ARG instruction (e.g. "bookworm" for
+// "ARG TAG=bookworm"). It returns false when the argument is not declared, or has no default value.
+func (d *Dockerfile) ArgDefault(name string) (string, bool) {
+	match := rxArg.FindStringSubmatch(d.Args[name])
+	if match == nil || match[2] == "" {
+		return "", false
+	}
+
+	value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(match[2]), "="))
+
+	return strings.Trim(value, `"'`), true
+}
+
+// ParseImageRef parses an image reference, as written in FROM statements (e.g. "debian:bookworm@sha256:...").
+func ParseImageRef(ref string) ImageRef {
+	match := rxFrom.FindStringSubmatch("FROM " + ref)
+	if match == nil {
+		return ImageRef{Name: ref}
+	}
+
+	return ImageRef{
+		Name:   match[rxFrom.SubexpIndex("image")],
+		Tag:    match[rxFrom.SubexpIndex("tag")],
+		Digest: match[rxFrom.SubexpIndex("digest")],
+	}
+}
+
 // IsDockerfile checks whether a file is a Dockerfile.
 func IsDockerfile(filename string) bool {
 	return strings.HasSuffix(filename, dockerfileName)
@@ -92,6 +122,10 @@ func ParseDockerfile(filename string) (*Dockerfile, error) {
 				Tag:    result["tag"],
 				Digest: result["digest"],
 			})
+
+			if stage := rxStage.FindStringSubmatch(txt); stage != nil {
+				dckFile.Stages = append(dckFile.Stages, stage[1])
+			}
 		case rxLabel.MatchString(txt):
 			result := rxLabel.FindStringSubmatch(txt)
 			dckFile.addLabel(result[1], result[2])
@@ -112,6 +146,38 @@ func ParseDockerfile(filename string) (*Dockerfile, error) {
 	return &dckFile, nil
 }
 
+// IsStage returns true if the name is the name of a build stage of the Dockerfile (FROM image AS name).
+func (d *Dockerfile) IsStage(name string) bool {
+	return slices.Contains(d.Stages, name)
+}
+
+// PinFrom rewrites the FROM statements of the Dockerfile, replacing the image references found in pins
+// by their pinned version. Unlike ReplaceInFile, only the exact references in FROM statements are replaced,
+// so "debian:bookworm" does not match "debian:bookworm-slim".
+func PinFrom(path string, pins map[string]string) error {
+	read, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	refIndex := rxFrom.SubexpIndex("ref")
+	lines := strings.SplitAfter(string(read), "\n")
+
+	for i, line := range lines {
+		match := rxFrom.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+
+		start, end := match[2*refIndex], match[2*refIndex+1]
+		if pinned, ok := pins[line[start:end]]; ok {
+			lines[i] = line[:start] + pinned + line[end:]
+		}
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "")), 0) //nolint:gosec
+}
+
 // ReplaceInFile replaces all matching references by a replacement.
 // The diff map keys are source references, and the values are replacements.
 // Many references to images may be replaced, those from the FROM statements, and also --from arguments.
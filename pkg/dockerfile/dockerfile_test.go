@@ -257,3 +257,100 @@ func TestReplaceAndReset(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, oldContent, string(content))
 }
+
+func TestParseDockerfile_Stages(t *testing.T) {
+	t.Parallel()
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	result, err := dockerfile.ParseDockerfile(
+		path.Join(cwd, "../../test/fixtures/dockerfile", "multistage-tag-digest-alias.dockerfile"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"builder"}, result.Stages)
+	assert.True(t, result.IsStage("builder"))
+	assert.False(t, result.IsStage("registry.com/example"))
+}
+
+func TestDockerfile_ArgDefault(t *testing.T) {
+	t.Parallel()
+
+	dckFile := dockerfile.Dockerfile{Args: map[string]string{
+		"TAG":      "ARG TAG=bookworm",
+		"QUOTED":   `ARG QUOTED = "12-slim"`,
+		"NO_VALUE": "ARG NO_VALUE",
+	}}
+
+	value, ok := dckFile.ArgDefault("TAG")
+	assert.True(t, ok)
+	assert.Equal(t, "bookworm", value)
+
+	value, ok = dckFile.ArgDefault("QUOTED")
+	assert.True(t, ok)
+	assert.Equal(t, "12-slim", value)
+
+	_, ok = dckFile.ArgDefault("NO_VALUE")
+	assert.False(t, ok)
+
+	_, ok = dckFile.ArgDefault("UNDECLARED")
+	assert.False(t, ok)
+}
+
+func TestParseImageRef(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, dockerfile.ImageRef{Name: "debian", Tag: "bookworm"}, dockerfile.ParseImageRef("debian:bookworm"))
+	assert.Equal(t, dockerfile.ImageRef{Name: "registry.example.org/debian", Digest: "sha256:0123"},
+		dockerfile.ParseImageRef("registry.example.org/debian@sha256:0123"))
+	assert.Equal(t, dockerfile.ImageRef{Name: "alpine"}, dockerfile.ParseImageRef("alpine"))
+}
+
+func TestPinFrom(t *testing.T) {
+	t.Parallel()
+
+	filename := path.Join(t.TempDir(), "pin.dockerfile")
+	content := "FROM debian:bookworm AS builder\n" +
+		"COPY --from=debian:bookworm /etc/os-release /\n" +
+		"FROM debian:bookworm-slim\n" +
+		"FROM alpine\n"
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	err := dockerfile.PinFrom(filename, map[string]string{
+		"debian:bookworm": "debian:bookworm@sha256:0123",
+		"alpine":          "alpine@sha256:4567",
+	})
+	require.NoError(t, err)
+
+	pinned, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM debian:bookworm@sha256:0123 AS builder\n"+
+		"COPY --from=debian:bookworm /etc/os-release /\n"+
+		"FROM debian:bookworm-slim\n"+
+		"FROM alpine@sha256:4567\n", string(pinned))
+}
+
+func TestParseInstructions(t *testing.T) {
+	t.Parallel()
+
+	filename := path.Join(t.TempDir(), "Dockerfile")
+	content := "# syntax=docker/dockerfile:1\n" +
+		"FROM debian:bookworm\n" +
+		"\n" +
+		"run apt-get update && \\\n" +
+		"    # comments are allowed in continuations\n" +
+		"    apt-get install -y curl\n" +
+		"USER nobody\n"
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	instructions, err := dockerfile.ParseInstructions(filename)
+	require.NoError(t, err)
+	assert.Equal(t, []dockerfile.Instruction{
+		{Line: 2, Command: "FROM", Args: "debian:bookworm"},
+		{Line: 4, Command: "RUN", Args: "apt-get update && apt-get install -y curl"},
+		{Line: 7, Command: "USER", Args: "nobody"},
+	}, instructions)
+
+	_, err = dockerfile.ParseInstructions(path.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package dockerfile
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Instruction holds a single instruction of a Dockerfile.
+type Instruction struct {
+	// Line is the line number where the instruction starts, starting at 1.
+	Line int
+	// Command is the instruction keyword, in upper case (e.g. "RUN").
+	Command string
+	// Args holds the arguments of the instruction, with line continuations joined.
+	Args string
+}
+
+// ParseInstructions reads all the instructions of a Dockerfile, in order. Comments and empty lines are ignored,
+// and instructions spanning several lines with a trailing backslash are joined.
+func ParseInstructions(filename string) ([]Instruction, error) {
+	file, err := os.Open(filename) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var (
+		instructions []Instruction
+		current      *Instruction
+		lineNumber   int
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		continued := strings.HasSuffix(line, "\\")
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+
+		if current == nil {
+			command, args, _ := strings.Cut(line, " ")
+			current = &Instruction{
+				Line:    lineNumber,
+				Command: strings.ToUpper(command),
+				Args:    strings.TrimSpace(args),
+			}
+		} else if line != "" {
+			current.Args = strings.TrimSpace(current.Args + " " + line)
+		}
+
+		if !continued {
+			instructions = append(instructions, *current)
+			current = nil
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		instructions = append(instructions, *current)
+	}
+
+	return instructions, nil
+}
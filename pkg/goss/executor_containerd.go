@@ -66,6 +66,12 @@ func (e ContainerdGossExecutor) Execute(
 		"sh", "-c", fmt.Sprintf("cd /goss && goss validate %s", strings.Join(args, " ")),
 	}
 
+	return ExecuteCtr(shell, output, opts.BuildkitHost, ctrArgs...)
+}
+
+// ExecuteCtr runs ctr with the given arguments against the containerd daemon used by the BuildKit worker,
+// so the images it just built are available. In rootless mode, ctr is executed in the RootlessKit namespaces.
+func ExecuteCtr(shell *exec.ShellExecutor, output io.Writer, buildkitHost string, ctrArgs ...string) error {
 	// Execute nsenter for rootless mode or ctr for rootfull mode
 	if rootlessutil.IsRootless() {
 		stateDir, err := rootlessutil.RootlessKitStateDir()
@@ -82,7 +88,7 @@ func (e ContainerdGossExecutor) Execute(
 		containerdSocket := fmt.Sprintf("/proc/%d/root/run/containerd/containerd.sock", childPid)
 
 		match, err := IsContainerdSocketMatchingBuildkitWorker(
-			opts.BuildkitHost,
+			buildkitHost,
 			containerdSocket,
 			&childPid,
 		)
@@ -118,13 +124,13 @@ func (e ContainerdGossExecutor) Execute(
 		containerdSocket = os.Getenv("CONTAINERD_ADDRESS")
 	}
 
-	match, err := IsContainerdSocketMatchingBuildkitWorker(opts.BuildkitHost, containerdSocket, nil)
+	match, err := IsContainerdSocketMatchingBuildkitWorker(buildkitHost, containerdSocket, nil)
 	if err != nil {
-		if !match {
-			return fmt.Errorf("containerd server UUID does not match the buildkit worker containerd UUID")
-		}
-	} else {
-		return nil
+		return err
+	}
+
+	if !match {
+		return fmt.Errorf("containerd server UUID does not match the buildkit worker containerd UUID")
 	}
 
 	ctrArgs = append([]string{"--address", containerdSocket}, ctrArgs...)
@@ -1,10 +1,14 @@
 package goss_test
 
 import (
+	"bytes"
 	"os"
+	"path"
 	"testing"
 
+	"github.com/radiofrance/dib/pkg/exec"
 	"github.com/radiofrance/dib/pkg/goss"
+	"github.com/radiofrance/dib/pkg/rootlessutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -26,3 +30,85 @@ func Test_ContainerdGossExecutor_NewContainerdGossExecutorDetectsShellFromEnv(t
 
 	assert.Equal(t, "/path/to/shell", executor.Shell)
 }
+
+// fakeCtrBinaries writes fake ctr and buildctl binaries to a directory added to the PATH. ctr reports the
+// containerd server UUID given by FAKE_CTR_UUID, and prints its arguments otherwise, while buildctl always
+// reports "worker-uuid" as the containerd UUID of the default worker.
+func fakeCtrBinaries(t *testing.T) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	ctr := `#!/bin/sh
+if [ "$3" = "info" ]; then
+  echo "{\"server\": {\"uuid\": \"$FAKE_CTR_UUID\"}}"
+  exit 0
+fi
+echo "ctr $*"
+`
+	buildctl := `#!/bin/sh
+echo '[{"labels": {"org.mobyproject.buildkit.worker.containerd.uuid": "worker-uuid"}}]'
+`
+	require.NoError(t, os.WriteFile(path.Join(binDir, "ctr"), []byte(ctr), 0o755))           //nolint:gosec
+	require.NoError(t, os.WriteFile(path.Join(binDir, "buildctl"), []byte(buildctl), 0o755)) //nolint:gosec
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+}
+
+//nolint:paralleltest
+func Test_ExecuteCtr_Rootful(t *testing.T) {
+	if rootlessutil.IsRootless() {
+		t.Skip("the rootful mode requires to run the tests as root")
+	}
+
+	socket := path.Join(t.TempDir(), "containerd.sock")
+	require.NoError(t, os.WriteFile(socket, nil, 0o600))
+
+	testCases := []struct {
+		name           string
+		socket         string
+		serverUUID     string
+		expectedOutput string
+		expectedErr    string
+	}{
+		{
+			name:           "runs ctr when the containerd socket matches the buildkit worker",
+			socket:         socket,
+			serverUUID:     "worker-uuid",
+			expectedOutput: "ctr --address " + socket + " run image\n",
+		},
+		{
+			name:        "fails when the containerd socket does not match the buildkit worker",
+			socket:      socket,
+			serverUUID:  "other-uuid",
+			expectedErr: "containerd server UUID does not match the buildkit worker containerd UUID",
+		},
+		{
+			name:        "fails when the containerd socket cannot be checked",
+			socket:      path.Join(t.TempDir(), "missing.sock"),
+			serverUUID:  "worker-uuid",
+			expectedErr: "containerd socket not found",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fakeCtrBinaries(t)
+			t.Setenv("CONTAINERD_ADDRESS", test.socket)
+			t.Setenv("FAKE_CTR_UUID", test.serverUUID)
+
+			var output bytes.Buffer
+
+			shell := &exec.ShellExecutor{Env: os.Environ()}
+
+			err := goss.ExecuteCtr(shell, &output, "unix:///run/buildkit/buildkitd.sock", "run", "image")
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				assert.Empty(t, output.String())
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOutput, output.String())
+		})
+	}
+}
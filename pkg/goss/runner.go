@@ -3,7 +3,6 @@ package goss
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -19,7 +18,8 @@ import (
 
 const gossFilename = "goss.yaml"
 
-var ErrCommandFailed = errors.New("goss command failed")
+// ErrCommandFailed is returned when the goss command fails in the Kubernetes test pod.
+var ErrCommandFailed = kubernetes.ErrTestCommandFailed
 
 // Executor is an interface for executing goss tests.
 type Executor interface {
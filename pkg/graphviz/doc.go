@@ -2,7 +2,7 @@
 //
 // The main functionalities include:
 //   - Creating and managing Graphviz graphs.
-//   - Exporting graphs to various formats such as DOT, PNG.
+//   - Exporting graphs to various formats such as DOT, SVG, and Mermaid flowcharts.
 //
 // This package is useful for tasks that require visual representation of data structures and dependencies.
 package graphviz
@@ -7,42 +7,33 @@ import (
 	"strings"
 
 	"github.com/radiofrance/dib/pkg/dag"
-	"github.com/radiofrance/dib/pkg/exec"
 )
 
 const (
 	// graphDot is the name of the file containing the raw graphviz dot language representation of the dib graph.
 	graphDot = "dib.dot"
 
-	// graphPng is the final file inside we put dib graph.
-	graphPng = "dib.png"
+	// GraphSVG is the name of the file containing the rendered dib graph.
+	GraphSVG = "dib.svg"
 )
 
-// GenerateGraph generates a graphviz representation (png) of the dag.DAG in the given report.Report rootDir.
-func GenerateGraph(dag *dag.DAG, reportRootDir string) error {
-	rawGraphvizOutput := GenerateRawOutput(dag)
+// GenerateGraph generates a graphviz representation (dot) and an SVG rendering of the dag.DAG
+// in the given report.Report rootDir. The SVG is rendered natively, the graphviz binaries are not required.
+// Nodes are coloured according to the given statuses, indexed by image name.
+func GenerateGraph(dag *dag.DAG, reportRootDir string, statuses map[string]NodeStatus) error {
+	rawGraphvizOutput := GenerateRawOutput(dag, statuses)
 
-	graphvizFile := path.Join(reportRootDir, graphDot)
-
-	err := os.WriteFile(graphvizFile, []byte(rawGraphvizOutput), 0o644)
+	err := os.WriteFile(path.Join(reportRootDir, graphDot), []byte(rawGraphvizOutput), 0o644)
 	if err != nil {
 		return err
 	}
 
-	shell := &exec.ShellExecutor{
-		Dir: reportRootDir,
-	}
-
-	_, err = shell.Execute("dot", "-Tpng", graphDot, "-o", graphPng)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return os.WriteFile(path.Join(reportRootDir, GraphSVG), []byte(RenderSVG(dag, statuses)), 0o644)
 }
 
 // GenerateRawOutput generates the raw graphviz dot language from the given dag.DAG.
-func GenerateRawOutput(graph *dag.DAG) string {
+// Nodes are coloured according to the given statuses, indexed by image name, like in RenderSVG.
+func GenerateRawOutput(graph *dag.DAG, statuses map[string]NodeStatus) string {
 	rawGraphvizDotLang := []string{
 		"digraph images {\n",
 		"  rankdir = \"LR\";\n",
@@ -54,16 +45,14 @@ func GenerateRawOutput(graph *dag.DAG) string {
 	if graph != nil {
 		graph.Walk(func(node *dag.Node) {
 			img := node.Image
-
-			color := "white"
-			if img.NeedsRebuild {
-				color = "red"
-			}
+			status := nodeStatus(img, statuses)
 
 			rawGraphvizDotLang = append(rawGraphvizDotLang, fmt.Sprintf(
-				"  \"%s\" [fillcolor=%s, style=filled];\n",
+				"  \"%s\" [fillcolor=\"%s\", color=\"%s\", style=filled, tooltip=\"%s\"];\n",
 				img.Name,
-				color,
+				status.Fill(),
+				status.Stroke(),
+				status,
 			))
 
 			for _, child := range node.Children() {
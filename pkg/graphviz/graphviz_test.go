@@ -18,17 +18,17 @@ func Test_GenerateGraph(t *testing.T) {
 	cwd, err := os.Getwd()
 	require.NoError(t, err)
 
-	graph, err := dib.GenerateDAG(
+	graph, err := dib.GenerateDAG(t.Context(),
 		path.Join(cwd, "../../test/fixtures/docker"),
-		"eu.gcr.io/my-test-repository", "",
-		map[string]string{})
+		dib.Naming{RegistryURL: "eu.gcr.io/my-test-repository"}, "",
+		map[string]string{}, nil)
 	require.NoError(t, err)
 
 	dir := t.TempDir()
-	err = graphviz.GenerateGraph(graph, dir)
+	err = graphviz.GenerateGraph(graph, dir, nil)
 	require.NoError(t, err)
 	assert.FileExists(t, path.Join(dir, "dib.dot"))
-	assert.FileExists(t, path.Join(dir, "dib.png"))
+	assert.FileExists(t, path.Join(dir, "dib.svg"))
 }
 
 func Test_GenerateRawOutput_EmptyDAG(t *testing.T) {
@@ -62,7 +62,7 @@ func Test_GenerateRawOutput_EmptyDAG(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			actual := graphviz.GenerateRawOutput(test.input)
+			actual := graphviz.GenerateRawOutput(test.input, nil)
 			assert.Equal(t, test.expected, actual)
 		})
 	}
@@ -106,22 +106,31 @@ func Test_GenerateRawOutput_ValidDAG(t *testing.T) {
 	inputGraph.AddNode(node2)
 	inputGraph.AddNode(node3)
 
+	needsRebuild := `[fillcolor="#fff3cd", color="#ffc107", style=filled, tooltip="needs rebuild"]`
+	upToDate := `[fillcolor="#ffffff", color="#adb5bd", style=filled, tooltip="up-to-date"]`
+	built := `[fillcolor="#d4edda", color="#28a745", style=filled, tooltip="built"]`
+
 	expected := "digraph images {\n" +
 		"  rankdir = \"LR\";\n" +
 		"  node[fontsize=10, shape=cds, height=0.4];\n" +
 		"  edge[fontsize=10, arrowhead=vee];\n" +
 		"\n" +
-		"  \"registry.localhost/image1\" [fillcolor=red, style=filled];\n" +
+		"  \"registry.localhost/image1\" " + needsRebuild + ";\n" +
 		"  \"registry.localhost/image1\" -> \"registry.localhost/image1-child1\" [dir=forward];\n" +
 		"  \"registry.localhost/image1\" -> \"registry.localhost/image1-child2\" [dir=forward];\n" +
-		"  \"registry.localhost/image1-child1\" [fillcolor=red, style=filled];\n" +
+		"  \"registry.localhost/image1-child1\" " + needsRebuild + ";\n" +
 		"  \"registry.localhost/image1-child1\" -> \"registry.localhost/image1-child1-sub1\" [dir=forward];\n" +
-		"  \"registry.localhost/image1-child1-sub1\" [fillcolor=white, style=filled];\n" +
-		"  \"registry.localhost/image1-child2\" [fillcolor=red, style=filled];\n" +
-		"  \"registry.localhost/image2\" [fillcolor=white, style=filled];\n" +
-		"  \"registry.localhost/image3\" [fillcolor=white, style=filled];\n" +
+		"  \"registry.localhost/image1-child1-sub1\" " + upToDate + ";\n" +
+		"  \"registry.localhost/image1-child2\" " + needsRebuild + ";\n" +
+		"  \"registry.localhost/image2\" " + upToDate + ";\n" +
+		"  \"registry.localhost/image3\" " + upToDate + ";\n" +
 		"}\n"
 
-	actual := graphviz.GenerateRawOutput(inputGraph)
+	actual := graphviz.GenerateRawOutput(inputGraph, nil)
 	assert.Equal(t, expected, actual)
+
+	actual = graphviz.GenerateRawOutput(inputGraph, map[string]graphviz.NodeStatus{
+		"registry.localhost/image2": graphviz.NodeStatusBuilt,
+	})
+	assert.Contains(t, actual, "  \"registry.localhost/image2\" "+built+";\n")
 }
@@ -0,0 +1,119 @@
+package graphviz
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dag"
+)
+
+var rxMermaidClass = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+type mermaidNode struct {
+	image    *dag.Image
+	children []string
+}
+
+// RenderMermaid renders the dag.DAG as a Mermaid flowchart, suitable to be embedded in Markdown documents.
+// Nodes are labelled with the short name of their image. When statuses is not nil, only the images having a
+// status are rendered, and they are styled according to it.
+func RenderMermaid(graph *dag.DAG, statuses map[string]NodeStatus) string {
+	rendered := func(img *dag.Image) bool {
+		if statuses == nil {
+			return true
+		}
+
+		_, hasStatus := statuses[img.Name]
+
+		return hasStatus
+	}
+
+	nodes := make(map[string]*mermaidNode)
+
+	graph.Walk(func(node *dag.Node) {
+		if !rendered(node.Image) {
+			return
+		}
+
+		// The same image may be held by several nodes.
+		item, exists := nodes[node.Image.Name]
+		if !exists {
+			item = &mermaidNode{image: node.Image}
+			nodes[node.Image.Name] = item
+		}
+
+		for _, child := range node.Children() {
+			if rendered(child.Image) {
+				item.children = append(item.children, child.Image.Name)
+			}
+		}
+	})
+
+	sortedNodes := make([]*mermaidNode, 0, len(nodes))
+	for _, item := range nodes {
+		sortedNodes = append(sortedNodes, item)
+	}
+
+	slices.SortFunc(sortedNodes, func(a, b *mermaidNode) int {
+		return cmp.Or(
+			strings.Compare(a.image.ShortName, b.image.ShortName),
+			strings.Compare(a.image.Name, b.image.Name),
+		)
+	})
+
+	// Short names may collide once sanitized, or be Mermaid keywords such as "end", so nodes are identified
+	// by their position instead, and labelled with their short name.
+	positions := make(map[string]int, len(sortedNodes))
+	for i, item := range sortedNodes {
+		positions[item.image.Name] = i
+	}
+
+	var output strings.Builder
+
+	output.WriteString("graph LR\n")
+
+	if statuses != nil {
+		// Only the classes of the rendered statuses are defined.
+		for _, status := range AllNodeStatuses() {
+			for _, item := range sortedNodes {
+				if statuses[item.image.Name] == status {
+					fmt.Fprintf(&output, "  classDef %s fill:%s,stroke:%s\n",
+						mermaidClass(status), status.Fill(), status.Stroke())
+
+					break
+				}
+			}
+		}
+	}
+
+	for i, item := range sortedNodes {
+		fmt.Fprintf(&output, "  n%d[\"%s\"]", i, item.image.ShortName)
+
+		if statuses != nil {
+			fmt.Fprintf(&output, ":::%s", mermaidClass(statuses[item.image.Name]))
+		}
+
+		output.WriteString("\n")
+
+		children := make([]int, 0, len(item.children))
+		for _, child := range item.children {
+			children = append(children, positions[child])
+		}
+
+		slices.Sort(children)
+
+		for _, child := range slices.Compact(children) {
+			fmt.Fprintf(&output, "  n%d --> n%d\n", i, child)
+		}
+	}
+
+	return output.String()
+}
+
+// mermaidClass returns the name of the Mermaid class of the nodes with the given status, e.g. "build_failed".
+func mermaidClass(status NodeStatus) string {
+	return rxMermaidClass.ReplaceAllString(status.String(), "_")
+}
@@ -0,0 +1,85 @@
+package graphviz_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/graphviz"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMermaidTestGraph() *dag.DAG {
+	root := dag.NewNode(&dag.Image{Name: "registry/root", ShortName: "root"})
+	first := dag.NewNode(&dag.Image{Name: "registry/team/first", ShortName: "team/first"})
+	second := dag.NewNode(&dag.Image{Name: "registry/second", ShortName: "second"})
+	third := dag.NewNode(&dag.Image{Name: "registry/third", ShortName: "third"})
+
+	root.AddChild(first)
+	root.AddChild(second)
+	first.AddChild(third)
+	second.AddChild(third)
+
+	graph := &dag.DAG{}
+	graph.AddNode(root)
+
+	return graph
+}
+
+func TestRenderMermaid(t *testing.T) {
+	t.Parallel()
+
+	expected := `graph LR
+  n0["root"]
+  n0 --> n1
+  n0 --> n2
+  n1["second"]
+  n1 --> n3
+  n2["team/first"]
+  n2 --> n3
+  n3["third"]
+`
+
+	assert.Equal(t, expected, graphviz.RenderMermaid(newMermaidTestGraph(), nil))
+}
+
+func TestRenderMermaid_WithStatuses(t *testing.T) {
+	t.Parallel()
+
+	expected := `graph LR
+  classDef built fill:#d4edda,stroke:#28a745
+  classDef build_failed fill:#f8d7da,stroke:#dc3545
+  n0["second"]:::built
+  n0 --> n1
+  n1["third"]:::build_failed
+`
+
+	actual := graphviz.RenderMermaid(newMermaidTestGraph(), map[string]graphviz.NodeStatus{
+		"registry/second": graphviz.NodeStatusBuilt,
+		"registry/third":  graphviz.NodeStatusBuildFailed,
+	})
+	assert.Equal(t, expected, actual)
+}
+
+func TestRenderMermaid_CollidingNames(t *testing.T) {
+	t.Parallel()
+
+	root := dag.NewNode(&dag.Image{Name: "registry/end", ShortName: "end"})
+	dashed := dag.NewNode(&dag.Image{Name: "registry/team-a/app", ShortName: "team-a/app"})
+	underscored := dag.NewNode(&dag.Image{Name: "registry/team_a/app", ShortName: "team_a/app"})
+
+	root.AddChild(dashed)
+	root.AddChild(underscored)
+
+	graph := &dag.DAG{}
+	graph.AddNode(root)
+
+	expected := `graph LR
+  n0["end"]
+  n0 --> n1
+  n0 --> n2
+  n1["team-a/app"]
+  n2["team_a/app"]
+`
+
+	assert.Equal(t, expected, graphviz.RenderMermaid(graph, nil))
+}
@@ -0,0 +1,310 @@
+package graphviz
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dag"
+)
+
+// NodeStatus is the status of an image, used to colour its node in the rendered graph.
+type NodeStatus int
+
+const (
+	// NodeStatusUpToDate is used for images that do not need to be rebuilt.
+	NodeStatusUpToDate NodeStatus = iota
+	// NodeStatusNeedsRebuild is used for images that need to be rebuilt, but were not processed yet.
+	NodeStatusNeedsRebuild
+	// NodeStatusBuilt is used for images successfully rebuilt and tested.
+	NodeStatusBuilt
+	// NodeStatusBuildFailed is used for images that failed to build.
+	NodeStatusBuildFailed
+	// NodeStatusBuildSkipped is used for images not built because a parent image failed to build.
+	NodeStatusBuildSkipped
+	// NodeStatusTestsFailed is used for images successfully built, but whose tests failed.
+	NodeStatusTestsFailed
+)
+
+const (
+	svgMargin     = 20
+	svgNodeHeight = 32
+	svgRankGap    = 60
+	svgNodeGap    = 16
+	svgCharWidth  = 7
+	svgNodePad    = 24
+	svgFontSize   = 12
+	svgCurvature  = 0.5
+	// svgOrderingPasses is the number of sweeps used to reorder nodes to reduce edge crossings.
+	svgOrderingPasses = 4
+)
+
+type nodeStyle struct {
+	label  string
+	fill   string
+	stroke string
+}
+
+var nodeStyles = map[NodeStatus]nodeStyle{
+	NodeStatusUpToDate:     {"up-to-date", "#ffffff", "#adb5bd"},
+	NodeStatusNeedsRebuild: {"needs rebuild", "#fff3cd", "#ffc107"},
+	NodeStatusBuilt:        {"built", "#d4edda", "#28a745"},
+	NodeStatusBuildFailed:  {"build failed", "#f8d7da", "#dc3545"},
+	NodeStatusBuildSkipped: {"build skipped", "#e2e3e5", "#6c757d"},
+	NodeStatusTestsFailed:  {"tests failed", "#ffe5d0", "#fd7e14"},
+}
+
+// String returns a human-readable description of the status.
+func (s NodeStatus) String() string {
+	return nodeStyles[s].label
+}
+
+// Fill returns the fill colour of the nodes with this status.
+func (s NodeStatus) Fill() string {
+	return nodeStyles[s].fill
+}
+
+// Stroke returns the stroke colour of the nodes with this status.
+func (s NodeStatus) Stroke() string {
+	return nodeStyles[s].stroke
+}
+
+// AllNodeStatuses returns all the statuses a node can have, for instance to render a legend.
+func AllNodeStatuses() []NodeStatus {
+	return []NodeStatus{
+		NodeStatusUpToDate,
+		NodeStatusNeedsRebuild,
+		NodeStatusBuilt,
+		NodeStatusBuildFailed,
+		NodeStatusBuildSkipped,
+		NodeStatusTestsFailed,
+	}
+}
+
+type svgNode struct {
+	node  *dag.Node
+	index int
+	x, y  int
+	width int
+}
+
+// RenderSVG renders the dag.DAG as an SVG image, without requiring any external binary.
+// Images are laid out from left to right, each column holding the images at the same depth in the graph.
+// Nodes are coloured according to the given statuses, indexed by image name. Images without status
+// are considered up-to-date, unless they need to be rebuilt.
+func RenderSVG(graph *dag.DAG, statuses map[string]NodeStatus) string {
+	ranks := layoutRanks(graph)
+
+	maxRankSize := 0
+	for _, rank := range ranks {
+		maxRankSize = max(maxRankSize, len(rank))
+	}
+
+	maxHeight := max(maxRankSize*(svgNodeHeight+svgNodeGap)-svgNodeGap, 0)
+	width, height := svgMargin, maxHeight+2*svgMargin
+	positions := make(map[*dag.Node]*svgNode)
+
+	for _, rank := range ranks {
+		rankWidth := 0
+		for _, item := range rank {
+			rankWidth = max(rankWidth, len(nodeLabel(item.node))*svgCharWidth+svgNodePad)
+		}
+
+		rankHeight := len(rank)*(svgNodeHeight+svgNodeGap) - svgNodeGap
+		offset := svgMargin + (maxHeight-rankHeight)/2
+
+		for _, item := range rank {
+			item.x = width
+			item.y = offset + item.index*(svgNodeHeight+svgNodeGap)
+			item.width = rankWidth
+			positions[item.node] = item
+		}
+
+		width += rankWidth + svgRankGap
+	}
+
+	if len(ranks) > 0 {
+		width -= svgRankGap
+	}
+
+	width += svgMargin
+
+	var svg strings.Builder
+
+	fmt.Fprintf(&svg, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" "+
+		"font-family=\"sans-serif\" font-size=\"%d\">\n", width, height, width, height, svgFontSize)
+	svg.WriteString("  <defs>\n")
+	svg.WriteString("    <marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" " +
+		"markerWidth=\"8\" markerHeight=\"8\" orient=\"auto-start-reverse\">\n")
+	svg.WriteString("      <path d=\"M 0 0 L 10 5 L 0 10 z\" fill=\"#6c757d\"/>\n")
+	svg.WriteString("    </marker>\n")
+	svg.WriteString("  </defs>\n")
+
+	for _, rank := range ranks {
+		for _, item := range rank {
+			for _, child := range sortedNodes(item.node.Children()) {
+				target, ok := positions[child]
+				if !ok {
+					continue
+				}
+
+				x1, y1 := item.x+item.width, item.y+svgNodeHeight/2
+				x2, y2 := target.x, target.y+svgNodeHeight/2
+				dx := int(float64(x2-x1) * svgCurvature)
+
+				fmt.Fprintf(&svg, "  <path d=\"M %d %d C %d %d, %d %d, %d %d\" fill=\"none\" stroke=\"#6c757d\" "+
+					"marker-end=\"url(#arrow)\"/>\n", x1, y1, x1+dx, y1, x2-dx, y2, x2, y2)
+			}
+		}
+	}
+
+	for _, rank := range ranks {
+		for _, item := range rank {
+			img := item.node.Image
+			status := nodeStatus(img, statuses)
+
+			fmt.Fprintf(&svg, "  <g class=\"node\">\n")
+			fmt.Fprintf(&svg, "    <title>%s:%s (%s)</title>\n",
+				html.EscapeString(img.Name), html.EscapeString(img.Hash), status)
+			fmt.Fprintf(&svg, "    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"6\" fill=\"%s\" stroke=\"%s\"/>\n",
+				item.x, item.y, item.width, svgNodeHeight, status.Fill(), status.Stroke())
+			fmt.Fprintf(&svg, "    <text x=\"%d\" y=\"%d\" text-anchor=\"middle\" dominant-baseline=\"central\">%s</text>\n",
+				item.x+item.width/2, item.y+svgNodeHeight/2, html.EscapeString(nodeLabel(item.node)))
+			fmt.Fprintf(&svg, "  </g>\n")
+		}
+	}
+
+	svg.WriteString("</svg>\n")
+
+	return svg.String()
+}
+
+// layoutRanks assigns each node to a column, so that every node is placed after all of its parents,
+// then orders the nodes of each column to reduce the number of edges crossing each other.
+func layoutRanks(graph *dag.DAG) [][]*svgNode {
+	if graph == nil {
+		return nil
+	}
+
+	rankOf := make(map[*dag.Node]int)
+
+	var computeRank func(node *dag.Node) int
+
+	computeRank = func(node *dag.Node) int {
+		if rank, ok := rankOf[node]; ok {
+			return rank
+		}
+
+		rank := 0
+		for _, parent := range node.Parents() {
+			rank = max(rank, computeRank(parent)+1)
+		}
+
+		rankOf[node] = rank
+
+		return rank
+	}
+
+	var ranks [][]*svgNode
+
+	graph.Walk(func(node *dag.Node) {
+		rank := computeRank(node)
+		for len(ranks) <= rank {
+			ranks = append(ranks, nil)
+		}
+
+		ranks[rank] = append(ranks[rank], &svgNode{node: node})
+	})
+
+	if len(ranks) == 0 {
+		return nil
+	}
+
+	byNode := make(map[*dag.Node]*svgNode)
+
+	for _, rank := range ranks {
+		slices.SortFunc(rank, func(a, b *svgNode) int {
+			return strings.Compare(a.node.Image.Name, b.node.Image.Name)
+		})
+
+		for i, item := range rank {
+			item.index = i
+			byNode[item.node] = item
+		}
+	}
+
+	for range svgOrderingPasses {
+		for _, rank := range ranks[1:] {
+			orderByBarycenter(rank, byNode, (*dag.Node).Parents)
+		}
+
+		for i := len(ranks) - 2; i >= 0; i-- {
+			orderByBarycenter(ranks[i], byNode, (*dag.Node).Children)
+		}
+	}
+
+	return ranks
+}
+
+// orderByBarycenter sorts the nodes of a column by the average position of their neighbours.
+// Nodes without neighbours keep their current position.
+func orderByBarycenter(rank []*svgNode, byNode map[*dag.Node]*svgNode, neighbours func(*dag.Node) []*dag.Node) {
+	barycenters := make(map[*svgNode]float64, len(rank))
+
+	for _, item := range rank {
+		sum, count := 0, 0
+
+		for _, neighbour := range neighbours(item.node) {
+			if other, ok := byNode[neighbour]; ok {
+				sum += other.index
+				count++
+			}
+		}
+
+		if count == 0 {
+			barycenters[item] = float64(item.index)
+			continue
+		}
+
+		barycenters[item] = float64(sum) / float64(count)
+	}
+
+	slices.SortStableFunc(rank, func(a, b *svgNode) int {
+		return cmp.Compare(barycenters[a], barycenters[b])
+	})
+
+	for i, item := range rank {
+		item.index = i
+	}
+}
+
+func nodeStatus(img *dag.Image, statuses map[string]NodeStatus) NodeStatus {
+	if status, ok := statuses[img.Name]; ok {
+		return status
+	}
+
+	if img.NeedsRebuild {
+		return NodeStatusNeedsRebuild
+	}
+
+	return NodeStatusUpToDate
+}
+
+func nodeLabel(node *dag.Node) string {
+	if node.Image.ShortName != "" {
+		return node.Image.ShortName
+	}
+
+	return node.Image.Name
+}
+
+func sortedNodes(nodes []*dag.Node) []*dag.Node {
+	sorted := slices.Clone(nodes)
+	slices.SortFunc(sorted, func(a, b *dag.Node) int {
+		return strings.Compare(a.Image.Name, b.Image.Name)
+	})
+
+	return sorted
+}
@@ -0,0 +1,89 @@
+package graphviz_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/graphviz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type svgDocument struct {
+	Paths  []struct{} `xml:"path"`
+	Groups []struct {
+		Title string `xml:"title"`
+		Rect  struct {
+			X      int    `xml:"x,attr"`
+			Y      int    `xml:"y,attr"`
+			Fill   string `xml:"fill,attr"`
+			Stroke string `xml:"stroke,attr"`
+		} `xml:"rect"`
+		Text string `xml:"text"`
+	} `xml:"g"`
+}
+
+func TestRenderSVG(t *testing.T) {
+	t.Parallel()
+
+	root := dag.NewNode(&dag.Image{Name: "registry/root", ShortName: "root", Hash: "root-hash"})
+	built := dag.NewNode(&dag.Image{Name: "registry/built", ShortName: "built", NeedsRebuild: true})
+	failed := dag.NewNode(&dag.Image{Name: "registry/failed", ShortName: "failed", NeedsRebuild: true})
+	pending := dag.NewNode(&dag.Image{Name: "registry/pending", ShortName: "<pending>", NeedsRebuild: true})
+
+	root.AddChild(built)
+	root.AddChild(failed)
+	built.AddChild(pending)
+	failed.AddChild(pending)
+
+	graph := &dag.DAG{}
+	graph.AddNode(root)
+
+	svg := graphviz.RenderSVG(graph, map[string]graphviz.NodeStatus{
+		"registry/built":  graphviz.NodeStatusBuilt,
+		"registry/failed": graphviz.NodeStatusBuildFailed,
+	})
+
+	var doc svgDocument
+	require.NoError(t, xml.Unmarshal([]byte(svg), &doc))
+
+	assert.Len(t, doc.Paths, 4)
+	require.Len(t, doc.Groups, 4)
+
+	nodes := map[string]int{}
+	for i, group := range doc.Groups {
+		nodes[group.Text] = i
+	}
+
+	require.Contains(t, nodes, "<pending>")
+
+	rootNode := doc.Groups[nodes["root"]]
+	builtNode := doc.Groups[nodes["built"]]
+	failedNode := doc.Groups[nodes["failed"]]
+	pendingNode := doc.Groups[nodes["<pending>"]]
+
+	assert.Equal(t, "registry/root:root-hash (up-to-date)", rootNode.Title)
+	assert.Equal(t, graphviz.NodeStatusUpToDate.Fill(), rootNode.Rect.Fill)
+	assert.Equal(t, graphviz.NodeStatusBuilt.Fill(), builtNode.Rect.Fill)
+	assert.Equal(t, graphviz.NodeStatusBuildFailed.Stroke(), failedNode.Rect.Stroke)
+	assert.Equal(t, graphviz.NodeStatusNeedsRebuild.Fill(), pendingNode.Rect.Fill)
+
+	// Images are laid out from left to right, children after all of their parents.
+	assert.Less(t, rootNode.Rect.X, builtNode.Rect.X)
+	assert.Equal(t, builtNode.Rect.X, failedNode.Rect.X)
+	assert.Less(t, builtNode.Rect.X, pendingNode.Rect.X)
+	assert.NotEqual(t, builtNode.Rect.Y, failedNode.Rect.Y)
+}
+
+func TestRenderSVG_EmptyDAG(t *testing.T) {
+	t.Parallel()
+
+	for _, graph := range []*dag.DAG{nil, {}} {
+		svg := graphviz.RenderSVG(graph, nil)
+
+		assert.True(t, strings.HasPrefix(svg, "<svg "))
+		require.NoError(t, xml.Unmarshal([]byte(svg), &svgDocument{}))
+	}
+}
@@ -14,6 +14,16 @@ type Testsuite struct {
 	TestCases []TestCase `xml:"testcase"`
 }
 
+// Testsuites is the root element of the JUnit reports grouping several test suites,
+// such as the ones generated by container-structure-test.
+type Testsuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Tests      string      `xml:"tests,attr"`
+	Failures   string      `xml:"failures,attr"`
+	Time       string      `xml:"time,attr"`
+	Testsuites []Testsuite `xml:"testsuite"`
+}
+
 type TestCase struct {
 	XMLName   xml.Name `xml:"testcase"`
 	ClassName string   `xml:"classname,attr"`
@@ -35,3 +45,16 @@ func ParseRawLogs(testsuiteData []byte) (Testsuite, error) {
 
 	return testSuite, nil
 }
+
+// ParseRawTestsuites cast a raw XML JunitReport (as byte), having a "testsuites" root element,
+// into a Testsuites structure.
+func ParseRawTestsuites(testsuitesData []byte) (Testsuites, error) {
+	testSuites := Testsuites{}
+
+	err := xml.Unmarshal(testsuitesData, &testSuites)
+	if err != nil {
+		return testSuites, err
+	}
+
+	return testSuites, nil
+}
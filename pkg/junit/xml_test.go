@@ -113,3 +113,33 @@ func Test_ParseRawLogs(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseRawTestsuites(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("../../test/fixtures/junit/structure-test-image-test.xml")
+	require.NoError(t, err)
+
+	actual, err := junit.ParseRawTestsuites(data)
+	require.NoError(t, err)
+	assert.Equal(t, "2", actual.Tests)
+	assert.Equal(t, "1", actual.Failures)
+	assert.Equal(t, "1.342", actual.Time)
+	require.Len(t, actual.Testsuites, 1)
+	assert.Equal(t, []junit.TestCase{
+		{
+			XMLName: xml.Name{Local: "testcase"},
+			Name:    "Command Test: apt-get upgrade",
+			Time:    "0.865",
+		},
+		{
+			XMLName: xml.Name{Local: "testcase"},
+			Name:    "File Existence Test: /etc/passwd",
+			Time:    "0.477",
+			Failure: "File /etc/passwd should exist but does not",
+		},
+	}, actual.Testsuites[0].TestCases)
+
+	_, err = junit.ParseRawTestsuites([]byte("<testsuites"))
+	require.Error(t, err)
+}
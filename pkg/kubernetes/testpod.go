@@ -0,0 +1,202 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	"github.com/radiofrance/dib/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	testContainerName = "test"
+	sharedVolumeName  = "shared"
+	sharedVolumePath  = "/shared"
+)
+
+var ErrTestCommandFailed = errors.New("test command failed")
+
+// TestPod describes a pod running tests against an image. An init container, using the image of the
+// PodConfig, copies the test tool to a volume shared with the container running the image under test.
+// The test files are then copied to this container, and the test command is executed in it.
+type TestPod struct {
+	Tool           string            // The name of the test tool (e.g. "goss"), used in the pod name and labels.
+	ImageName      string            // The short name of the image under test.
+	ImageReference string            // The reference of the image under test.
+	SetupCommand   []string          // The init container command, copying the tool to the "/shared" directory.
+	MountPath      string            // The path where the shared volume is mounted in the tested container.
+	Files          map[string]string // The local files to copy, mapped to their path in the tested container.
+	Command        []string          // The test command executed in the tested container.
+}
+
+// RunTestPod creates the test pod, waits for it to be ready, then runs the test command and forwards its output
+// to the given writer. The pod is deleted once the tests are done.
+//
+//nolint:funlen
+func RunTestPod(ctx context.Context, clientSet kubernetes.Interface, restConfig rest.Config, config PodConfig,
+	testPod TestPod, output io.Writer,
+) error {
+	// Generate a unique pod name with the format dib-$tool-$image-$uid
+	podName := UniquePodNameWithImage("dib-"+testPod.Tool, testPod.ImageName)()
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":      testPod.Tool,
+		"app.kubernetes.io/component": testPod.Tool + "-pod",
+		"app.kubernetes.io/instance":  podName,
+	}
+	// Merge the default labels with those provided in the options.
+	maps.Copy(labels, config.Labels)
+
+	var imagePullSecrets []corev1.LocalObjectReference
+	for _, secretName := range config.ImagePullSecrets {
+		imagePullSecrets = append(imagePullSecrets, corev1.LocalObjectReference{
+			Name: secretName,
+		})
+	}
+
+	initContainer := corev1.Container{
+		Name:            "setup-" + testPod.Tool,
+		Image:           config.Image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         testPod.SetupCommand,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      sharedVolumeName,
+				MountPath: sharedVolumePath,
+				ReadOnly:  false,
+			},
+		},
+	}
+	container := corev1.Container{
+		Name:            testContainerName,
+		Image:           testPod.ImageReference,
+		ImagePullPolicy: corev1.PullAlways,
+		Command:         []string{"sleep", "1h"},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      sharedVolumeName,
+				MountPath: testPod.MountPath,
+				ReadOnly:  false,
+			},
+		},
+	}
+
+	err := MergeObjectWithYaml(&container, config.ContainerOverride)
+	if err != nil {
+		return err
+	}
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: config.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			ImagePullSecrets: imagePullSecrets,
+			InitContainers: []corev1.Container{
+				initContainer,
+			},
+			Containers: []corev1.Container{
+				container,
+			},
+			RestartPolicy: corev1.RestartPolicyNever,
+			Volumes: []corev1.Volume{
+				{
+					Name: sharedVolumeName,
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{
+							Medium: corev1.StorageMediumMemory,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = MergeObjectWithYaml(&pod, config.PodOverride)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := clientSet.CoreV1().Pods(config.Namespace).Watch(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/instance=%s", pod.Name),
+		Watch:         true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch pod: %w", err)
+	}
+	defer watcher.Stop()
+
+	readyChan, watchErrChan := MonitorPod(ctx, watcher)
+
+	errChan := make(chan error)
+
+	go func() {
+		defer close(errChan)
+
+		<-readyChan
+
+		go PrintPodLogs(ctx, output, clientSet, config.Namespace, podName, testContainerName)
+
+		pod, err := clientSet.CoreV1().Pods(config.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			errChan <- err
+			return
+		}
+
+		execOpts := NewExecOptions(clientSet, restConfig).WithContainer(pod, testContainerName)
+
+		for src, dest := range testPod.Files {
+			logger.Debugf("Copying %s to %s/%s:%s", src, config.Namespace, pod.Name, dest)
+
+			err = CopyToContainer(*execOpts, src, dest)
+			if err != nil {
+				errChan <- err
+				return
+			}
+		}
+
+		logger.Debugf("Executing command: %v", testPod.Command)
+
+		err = Exec(*execOpts.WithWriters(output, os.Stderr), testPod.Command)
+		if err != nil {
+			errChan <- ErrTestCommandFailed
+			return
+		}
+
+		errChan <- nil
+	}()
+
+	logger.Debugf("Creating pod: %s/%s", config.Namespace, pod.Name)
+
+	_, err = clientSet.CoreV1().Pods(config.Namespace).Create(ctx, &pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create %s pod: %w", testPod.Tool, err)
+	}
+
+	defer func() {
+		logger.Debugf("Deleting pod %s/%s", config.Namespace, pod.Name)
+		_ = clientSet.CoreV1().Pods(config.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+	}()
+
+	select {
+	case watchErr := <-watchErrChan:
+		if watchErr != nil {
+			return fmt.Errorf("error watching %s pod: %w", testPod.Tool, watchErr)
+		}
+	case err = <-errChan:
+		if err != nil {
+			return fmt.Errorf("error running %s tests: %w", testPod.Tool, err)
+		}
+	}
+
+	return nil
+}
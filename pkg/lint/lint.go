@@ -0,0 +1,154 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dockerfile"
+)
+
+const (
+	// LabelIgnore is the Dockerfile label holding a comma-separated list of rules to ignore for the image.
+	LabelIgnore = "dib.lint.ignore"
+	// LabelFailureThreshold is the Dockerfile label overriding the failure threshold for the image.
+	LabelFailureThreshold = "dib.lint.failure-threshold"
+)
+
+// Severity is the severity of a lint rule.
+type Severity int
+
+const (
+	SeverityStyle Severity = iota + 1
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	// SeverityNone is only used as a failure threshold, so no finding ever fails the tests.
+	SeverityNone
+)
+
+var severityNames = map[Severity]string{
+	SeverityStyle:   "style",
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+	SeverityNone:    "none",
+}
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity returns the severity matching the name: "error", "warning", "info", "style" or "none".
+func ParseSeverity(name string) (Severity, error) {
+	for severity, severityName := range severityNames {
+		if strings.EqualFold(name, severityName) {
+			return severity, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid lint severity %q (available: error, warning, info, style, none)", name)
+}
+
+// Config holds the configuration for the Dockerfile linter.
+type Config struct {
+	// FailureThreshold is the minimal severity of the findings failing the tests. Defaults to "error".
+	FailureThreshold string `mapstructure:"failure_threshold"`
+	// Ignore is the list of rules to ignore for all images (e.g. "DL3008").
+	Ignore []string `mapstructure:"ignore"`
+}
+
+// Finding is a violation of a lint rule.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Line     int
+	Message  string
+}
+
+// String returns the finding formatted as "<line> <rule> <severity>: <message>".
+func (f Finding) String() string {
+	return fmt.Sprintf("%d %s %s: %s", f.Line, f.Rule, f.Severity, f.Message)
+}
+
+// Options are the lint options of a single Dockerfile, resolved from the configuration and its labels.
+type Options struct {
+	FailureThreshold Severity
+	Ignore           []string
+	// ManagedPrefix is the prefix of the images managed by dib (registry URL). Their tags are replaced
+	// at build time, so they are not required to be pinned.
+	ManagedPrefix string
+}
+
+// ResolveOptions merges the global configuration with the lint labels of the Dockerfile.
+func ResolveOptions(config Config, file *dockerfile.Dockerfile, managedPrefix string) (Options, error) {
+	threshold := config.FailureThreshold
+	if value, ok := file.Labels[LabelFailureThreshold]; ok {
+		threshold = value
+	}
+
+	if threshold == "" {
+		threshold = SeverityError.String()
+	}
+
+	severity, err := ParseSeverity(threshold)
+	if err != nil {
+		return Options{}, err
+	}
+
+	opts := Options{
+		FailureThreshold: severity,
+		Ignore:           slices.Clone(config.Ignore),
+		ManagedPrefix:    managedPrefix,
+	}
+
+	if value, ok := file.Labels[LabelIgnore]; ok {
+		for rule := range strings.SplitSeq(value, ",") {
+			opts.Ignore = append(opts.Ignore, strings.TrimSpace(rule))
+		}
+	}
+
+	return opts, nil
+}
+
+// Lint runs all the rules not ignored over the instructions of the Dockerfile, and returns the findings
+// sorted by line.
+func Lint(file *dockerfile.Dockerfile, instructions []dockerfile.Instruction, opts Options) []Finding {
+	var findings []Finding
+
+	for _, rule := range rules {
+		if slices.Contains(opts.Ignore, rule.code) {
+			continue
+		}
+
+		for _, instruction := range rule.check(lintContext{file, instructions, opts.ManagedPrefix}) {
+			findings = append(findings, Finding{
+				Rule:     rule.code,
+				Severity: rule.severity,
+				Line:     instruction.Line,
+				Message:  rule.message,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings
+}
+
+// Failures returns the findings with a severity reaching the failure threshold.
+func Failures(findings []Finding, threshold Severity) []Finding {
+	var failures []Finding
+
+	for _, finding := range findings {
+		if finding.Severity >= threshold {
+			failures = append(failures, finding)
+		}
+	}
+
+	return failures
+}
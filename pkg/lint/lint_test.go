@@ -0,0 +1,168 @@
+package lint_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/lint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintContent writes the Dockerfile content to a temporary file, then lints it.
+func lintContent(t *testing.T, content string, opts lint.Options) []lint.Finding {
+	t.Helper()
+
+	filename := path.Join(t.TempDir(), "Dockerfile")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	file, err := dockerfile.ParseDockerfile(filename)
+	require.NoError(t, err)
+
+	instructions, err := dockerfile.ParseInstructions(filename)
+	require.NoError(t, err)
+
+	return lint.Lint(file, instructions, opts)
+}
+
+func rulesOf(findings []lint.Finding) []string {
+	rules := []string{}
+	for _, finding := range findings {
+		rules = append(rules, finding.Rule)
+	}
+
+	return rules
+}
+
+func TestLint_Rules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "no findings",
+			content: "FROM debian:bookworm AS builder\n" +
+				"SHELL [\"/bin/bash\", \"-o\", \"pipefail\", \"-c\"]\n" +
+				"RUN apt-get update && apt-get install -y --no-install-recommends curl | tee /log\n" +
+				"FROM builder\n" +
+				"FROM registry.example.org/base:latest\n" +
+				"FROM scratch\n" +
+				"WORKDIR /app\n" +
+				"ADD https://example.org/file.txt archive.tar.gz /app/\n" +
+				"USER nobody\n" +
+				"ENTRYPOINT [\"/app/run\"]\n",
+			expected: []string{},
+		},
+		{
+			name:     "untagged and latest external images",
+			content:  "FROM debian\nFROM --platform=linux/amd64 alpine:latest\nFROM localhost:5000/image\n",
+			expected: []string{"DL3006", "DL3007", "DL3006"},
+		},
+		{
+			name:     "relative workdir",
+			content:  "FROM debian:bookworm\nWORKDIR app\nWORKDIR $HOME\n",
+			expected: []string{"DL3000"},
+		},
+		{
+			name:     "last user is root",
+			content:  "FROM debian:bookworm\nUSER nobody\nUSER root:root\n",
+			expected: []string{"DL3002"},
+		},
+		{
+			name:     "root user in a build stage only",
+			content:  "FROM debian:bookworm AS builder\nUSER root\nFROM debian:bookworm\n",
+			expected: []string{},
+		},
+		{
+			name: "apt usage",
+			content: "FROM debian:bookworm\n" +
+				"RUN sudo apt install curl\n" +
+				"RUN apt-get -y install curl\n",
+			expected: []string{"DL3004", "DL3027", "DL3015", "DL3059"},
+		},
+		{
+			name:     "local files added",
+			content:  "FROM debian:bookworm\nADD --chown=app config.yaml /app/\nADD [\"a.tgz\", \"/app/\"]\n",
+			expected: []string{"DL3020"},
+		},
+		{
+			name:     "shell form entrypoint and deprecated maintainer",
+			content:  "FROM debian:bookworm\nMAINTAINER me\nCMD echo hello\nENTRYPOINT [\"/run\"]\n",
+			expected: []string{"DL4000", "DL3025"},
+		},
+		{
+			name:     "pipe without pipefail",
+			content:  "FROM debian:bookworm\nRUN curl https://example.org | sh\nRUN true || false\n",
+			expected: []string{"DL4006", "DL3059"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			findings := lintContent(t, test.content, lint.Options{ManagedPrefix: "registry.example.org"})
+			assert.Equal(t, test.expected, rulesOf(findings))
+		})
+	}
+}
+
+func TestLint_Findings(t *testing.T) {
+	t.Parallel()
+
+	findings := lintContent(t, "FROM debian\nMAINTAINER me\n", lint.Options{Ignore: []string{"DL3006"}})
+	require.Len(t, findings, 1)
+	assert.Equal(t, lint.Finding{
+		Rule:     "DL4000",
+		Severity: lint.SeverityError,
+		Line:     2,
+		Message:  "MAINTAINER is deprecated",
+	}, findings[0])
+	assert.Equal(t, "2 DL4000 error: MAINTAINER is deprecated", findings[0].String())
+}
+
+func TestFailures(t *testing.T) {
+	t.Parallel()
+
+	findings := []lint.Finding{
+		{Rule: "DL3059", Severity: lint.SeverityInfo},
+		{Rule: "DL3006", Severity: lint.SeverityWarning},
+		{Rule: "DL4000", Severity: lint.SeverityError},
+	}
+
+	assert.Len(t, lint.Failures(findings, lint.SeverityError), 1)
+	assert.Len(t, lint.Failures(findings, lint.SeverityWarning), 2)
+	assert.Len(t, lint.Failures(findings, lint.SeverityStyle), 3)
+	assert.Empty(t, lint.Failures(findings, lint.SeverityNone))
+}
+
+func TestResolveOptions(t *testing.T) {
+	t.Parallel()
+
+	config := lint.Config{Ignore: []string{"DL3059"}}
+
+	opts, err := lint.ResolveOptions(config, &dockerfile.Dockerfile{Labels: map[string]string{}}, "registry.org")
+	require.NoError(t, err)
+	assert.Equal(t, lint.Options{
+		FailureThreshold: lint.SeverityError,
+		Ignore:           []string{"DL3059"},
+		ManagedPrefix:    "registry.org",
+	}, opts)
+
+	opts, err = lint.ResolveOptions(config, &dockerfile.Dockerfile{Labels: map[string]string{
+		lint.LabelIgnore:           "DL3006,DL3007",
+		lint.LabelFailureThreshold: "warning",
+	}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, lint.SeverityWarning, opts.FailureThreshold)
+	assert.Equal(t, []string{"DL3059", "DL3006", "DL3007"}, opts.Ignore)
+	assert.Equal(t, []string{"DL3059"}, config.Ignore, "the global configuration must not be modified")
+
+	_, err = lint.ResolveOptions(lint.Config{FailureThreshold: "fatal"}, &dockerfile.Dockerfile{}, "")
+	require.EqualError(t, err, `invalid lint severity "fatal" (available: error, warning, info, style, none)`)
+}
@@ -0,0 +1,290 @@
+package lint
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/dockerfile"
+)
+
+var (
+	rxSudo                = regexp.MustCompile(`(^|[\s;&|(])sudo\s`)
+	rxApt                 = regexp.MustCompile(`(^|[\s;&|(])apt\s`)
+	rxAptGetInstall       = regexp.MustCompile(`apt-get\s+(\S+\s+)*install\s`)
+	rxPipe                = regexp.MustCompile(`[^|]\|[^|]`)
+	rxArchive             = regexp.MustCompile(`\.(tar|tar\.\w+|tgz|tbz2?|txz|gz|bz2|xz|zst)$`)
+	noInstallRecommends   = []string{"--no-install-recommends", "APT::Install-Recommends=false"}
+	absoluteWorkdirPrefix = []string{"/", "$", "\""}
+)
+
+// lintContext holds what the rules are checked against.
+type lintContext struct {
+	file          *dockerfile.Dockerfile
+	instructions  []dockerfile.Instruction
+	managedPrefix string
+}
+
+// rule is a lint rule, named after the equivalent hadolint rule.
+type rule struct {
+	code     string
+	severity Severity
+	message  string
+	// check returns the instructions violating the rule.
+	check func(ctx lintContext) []dockerfile.Instruction
+}
+
+var rules = []rule{
+	{
+		code:     "DL3000",
+		severity: SeverityError,
+		message:  "Use absolute WORKDIR",
+		check: matching("WORKDIR", func(args string) bool {
+			for _, prefix := range absoluteWorkdirPrefix {
+				if strings.HasPrefix(args, prefix) {
+					return false
+				}
+			}
+
+			return true
+		}),
+	},
+	{
+		code:     "DL3002",
+		severity: SeverityWarning,
+		message:  "Last USER should not be root",
+		check:    lastUserIsRoot,
+	},
+	{
+		code:     "DL3004",
+		severity: SeverityError,
+		message:  "Do not use sudo as it leads to unpredictable behavior. Use a tool like gosu to enforce root",
+		check:    matching("RUN", rxSudo.MatchString),
+	},
+	{
+		code:     "DL3006",
+		severity: SeverityWarning,
+		message:  "Always tag the version of an image explicitly",
+		check: matchingFrom(func(ref dockerfile.ImageRef) bool {
+			return ref.Tag == "" && ref.Digest == ""
+		}),
+	},
+	{
+		code:     "DL3007",
+		severity: SeverityWarning,
+		message:  "Using latest is prone to errors if the image will ever update. Pin the version explicitly",
+		check: matchingFrom(func(ref dockerfile.ImageRef) bool {
+			return ref.Tag == "latest" && ref.Digest == ""
+		}),
+	},
+	{
+		code:     "DL3015",
+		severity: SeverityInfo,
+		message:  "Avoid additional packages by specifying `--no-install-recommends`",
+		check: matching("RUN", func(args string) bool {
+			if !rxAptGetInstall.MatchString(args + " ") {
+				return false
+			}
+
+			for _, option := range noInstallRecommends {
+				if strings.Contains(args, option) {
+					return false
+				}
+			}
+
+			return true
+		}),
+	},
+	{
+		code:     "DL3020",
+		severity: SeverityError,
+		message:  "Use COPY instead of ADD for files and folders",
+		check:    matching("ADD", addsLocalFiles),
+	},
+	{
+		code:     "DL3025",
+		severity: SeverityWarning,
+		message:  "Use arguments JSON notation for CMD and ENTRYPOINT arguments",
+		check: func(ctx lintContext) []dockerfile.Instruction {
+			isShellForm := func(args string) bool { return !strings.HasPrefix(args, "[") }
+
+			return append(matching("CMD", isShellForm)(ctx), matching("ENTRYPOINT", isShellForm)(ctx)...)
+		},
+	},
+	{
+		code:     "DL3027",
+		severity: SeverityWarning,
+		message:  "Do not use apt as it is meant to be an end-user tool, use apt-get or apt-cache instead",
+		check:    matching("RUN", rxApt.MatchString),
+	},
+	{
+		code:     "DL3059",
+		severity: SeverityInfo,
+		message:  "Multiple consecutive RUN instructions. Consider consolidation",
+		check:    consecutiveRuns,
+	},
+	{
+		code:     "DL4000",
+		severity: SeverityError,
+		message:  "MAINTAINER is deprecated",
+		check:    matching("MAINTAINER", func(string) bool { return true }),
+	},
+	{
+		code:     "DL4006",
+		severity: SeverityWarning,
+		message:  "Set the SHELL option -o pipefail before RUN with a pipe in it",
+		check:    pipeWithoutPipefail,
+	},
+}
+
+// matching returns a check reporting the instructions of the given command whose arguments match.
+func matching(command string, match func(args string) bool) func(ctx lintContext) []dockerfile.Instruction {
+	return func(ctx lintContext) []dockerfile.Instruction {
+		var matches []dockerfile.Instruction
+
+		for _, instruction := range ctx.instructions {
+			if instruction.Command == command && match(instruction.Args) {
+				matches = append(matches, instruction)
+			}
+		}
+
+		return matches
+	}
+}
+
+// matchingFrom returns a check reporting the FROM instructions referencing an external image which matches.
+// Build stages, the scratch image, images depending on build arguments and images managed by dib are ignored.
+func matchingFrom(match func(ref dockerfile.ImageRef) bool) func(ctx lintContext) []dockerfile.Instruction {
+	return func(ctx lintContext) []dockerfile.Instruction {
+		return matching("FROM", func(args string) bool {
+			ref, ok := fromImageRef(args)
+			if !ok || ref.Name == "scratch" || strings.Contains(ref.Name, "$") || ctx.file.IsStage(ref.Name) {
+				return false
+			}
+
+			if ctx.managedPrefix != "" && strings.HasPrefix(ref.Name, ctx.managedPrefix+"/") {
+				return false
+			}
+
+			return match(ref)
+		})(ctx)
+	}
+}
+
+// fromImageRef parses the image reference of the arguments of a FROM instruction.
+func fromImageRef(args string) (dockerfile.ImageRef, bool) {
+	fields := strings.Fields(args)
+	// Skip options such as --platform
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return dockerfile.ImageRef{}, false
+	}
+
+	ref := dockerfile.ImageRef{}
+	name, digest, _ := strings.Cut(fields[0], "@")
+	ref.Digest = digest
+
+	// The tag is after the last colon, unless it is part of the registry host (e.g. "localhost:5000/image").
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Name, ref.Tag = name[:i], name[i+1:]
+	} else {
+		ref.Name = name
+	}
+
+	return ref, true
+}
+
+// lastUserIsRoot reports the last USER instruction of the final stage, when it switches to root.
+func lastUserIsRoot(ctx lintContext) []dockerfile.Instruction {
+	var lastUser *dockerfile.Instruction
+
+	for _, instruction := range ctx.instructions {
+		switch instruction.Command {
+		case "FROM":
+			lastUser = nil
+		case "USER":
+			lastUser = &instruction
+		}
+	}
+
+	if lastUser == nil {
+		return nil
+	}
+
+	user, _, _ := strings.Cut(lastUser.Args, ":")
+	if user != "root" && user != "0" {
+		return nil
+	}
+
+	return []dockerfile.Instruction{*lastUser}
+}
+
+// addsLocalFiles returns true when the ADD instruction copies local files, which are not archives.
+func addsLocalFiles(args string) bool {
+	var fields []string
+
+	if strings.HasPrefix(args, "[") {
+		err := json.Unmarshal([]byte(args), &fields)
+		if err != nil {
+			return false
+		}
+	} else {
+		for _, field := range strings.Fields(args) {
+			if !strings.HasPrefix(field, "--") {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	if len(fields) < 2 {
+		return false
+	}
+
+	for _, src := range fields[:len(fields)-1] {
+		isURL := strings.Contains(src, "://") || strings.HasPrefix(src, "git@")
+		if !isURL && !rxArchive.MatchString(src) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// consecutiveRuns reports the RUN instructions directly following another RUN instruction.
+func consecutiveRuns(ctx lintContext) []dockerfile.Instruction {
+	var matches []dockerfile.Instruction
+
+	for i := 1; i < len(ctx.instructions); i++ {
+		if ctx.instructions[i].Command == "RUN" && ctx.instructions[i-1].Command == "RUN" {
+			matches = append(matches, ctx.instructions[i])
+		}
+	}
+
+	return matches
+}
+
+// pipeWithoutPipefail reports the RUN instructions using a pipe, when the pipefail option is not set,
+// either by a previous SHELL instruction of the same stage, or in the command itself.
+func pipeWithoutPipefail(ctx lintContext) []dockerfile.Instruction {
+	var matches []dockerfile.Instruction
+
+	pipefail := false
+
+	for _, instruction := range ctx.instructions {
+		switch instruction.Command {
+		case "FROM":
+			pipefail = false
+		case "SHELL":
+			pipefail = strings.Contains(instruction.Args, "pipefail")
+		case "RUN":
+			if !pipefail && rxPipe.MatchString(instruction.Args) && !strings.Contains(instruction.Args, "pipefail") {
+				matches = append(matches, instruction)
+			}
+		}
+	}
+
+	return matches
+}
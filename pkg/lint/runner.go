@@ -0,0 +1,173 @@
+package lint
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dockerfile"
+	"github.com/radiofrance/dib/pkg/junit"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+var ErrLintFailed = errors.New("dockerfile lint failed")
+
+// TestRunner implements types.PreBuildTestRunner, linting the Dockerfile of each image before it is built.
+type TestRunner struct {
+	Config
+
+	RegistryURL      string
+	WorkingDirectory string
+}
+
+// NewTestRunner creates a new instance of TestRunner. Images from the registryURL are managed by dib.
+func NewTestRunner(config Config, registryURL, workingDir string) *TestRunner {
+	return &TestRunner{config, registryURL, workingDir}
+}
+
+// Name returns the name of the test runner.
+func (r *TestRunner) Name() string {
+	return types.TestRunnerLint
+}
+
+// IsConfigured returns true if the Dockerfile of the image exists.
+func (r *TestRunner) IsConfigured(opts types.RunTestOptions) bool {
+	_, err := os.Stat(r.dockerfilePath(opts))
+	return err == nil
+}
+
+// RunBeforeBuild returns true, as the Dockerfile is linted before the image is built.
+func (r *TestRunner) RunBeforeBuild() bool {
+	return true
+}
+
+// RunTest lints the Dockerfile of the image, and writes the findings in a JUnit report.
+// The test fails if any finding reaches the failure threshold.
+func (r *TestRunner) RunTest(_ context.Context, opts types.RunTestOptions) error {
+	err := os.MkdirAll(opts.ReportJunitDir, 0o750)
+	if err != nil {
+		return err
+	}
+
+	filename := r.dockerfilePath(opts)
+
+	file, err := dockerfile.ParseDockerfile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot parse dockerfile: %w", err)
+	}
+
+	instructions, err := dockerfile.ParseInstructions(filename)
+	if err != nil {
+		return fmt.Errorf("cannot parse dockerfile: %w", err)
+	}
+
+	lintOpts, err := ResolveOptions(r.Config, file, r.RegistryURL)
+	if err != nil {
+		return err
+	}
+
+	findings := Lint(file, instructions, lintOpts)
+	failures := Failures(findings, lintOpts.FailureThreshold)
+
+	err = r.exportJunitReport(opts, filename, findings, lintOpts.FailureThreshold)
+	if err != nil {
+		return fmt.Errorf("could not export junit report: %w", err)
+	}
+
+	if len(failures) > 0 {
+		messages := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			messages = append(messages, failure.String())
+		}
+
+		return fmt.Errorf("%w: %s", ErrLintFailed, strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// JunitReportPath returns the path of the JUnit report of the given image.
+func JunitReportPath(junitDir, imageName string) string {
+	return path.Join(junitDir, fmt.Sprintf("junit-lint-%s.xml", strings.ReplaceAll(imageName, "/", "_")))
+}
+
+func (r *TestRunner) dockerfilePath(opts types.RunTestOptions) string {
+	if opts.DockerfilePath != "" {
+		return opts.DockerfilePath
+	}
+
+	return path.Join(opts.DockerContextPath, "Dockerfile")
+}
+
+// exportJunitReport writes one test case per finding, failed when it reaches the failure threshold.
+// When there is no finding, a single passing test case is written.
+func (r *TestRunner) exportJunitReport(
+	opts types.RunTestOptions,
+	filename string,
+	findings []Finding,
+	threshold Severity,
+) error {
+	relativeFilename := strings.ReplaceAll(filename, r.WorkingDirectory+"/", "")
+
+	testSuite := junit.Testsuite{
+		Name:      types.TestRunnerLint,
+		Errors:    "0",
+		Skipped:   "0",
+		Time:      "0.000",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	failures := 0
+
+	for _, finding := range findings {
+		testCase := junit.TestCase{
+			ClassName: "lint-" + opts.ImageName,
+			File:      relativeFilename,
+			Name:      fmt.Sprintf("%s: %s", finding.Rule, finding.Message),
+			Time:      "0.000",
+		}
+
+		output := fmt.Sprintf("%s:%d %s", relativeFilename, finding.Line, finding.Severity)
+		if finding.Severity >= threshold {
+			testCase.Failure = output
+			failures++
+		} else {
+			testCase.SystemOut = output
+		}
+
+		testSuite.TestCases = append(testSuite.TestCases, testCase)
+	}
+
+	if len(findings) == 0 {
+		testSuite.TestCases = append(testSuite.TestCases, junit.TestCase{
+			ClassName: "lint-" + opts.ImageName,
+			File:      relativeFilename,
+			Name:      "Dockerfile lint",
+			Time:      "0.000",
+			SystemOut: "No issues found",
+		})
+	}
+
+	testSuite.Tests = strconv.Itoa(len(testSuite.TestCases))
+	testSuite.Failures = strconv.Itoa(failures)
+
+	data, err := xml.MarshalIndent(testSuite, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	junitFilename := JunitReportPath(opts.ReportJunitDir, opts.ImageName)
+
+	err = os.WriteFile(junitFilename, append([]byte(xml.Header), data...), 0o644)
+	if err != nil {
+		return fmt.Errorf("could not write junit report to file %s: %w", junitFilename, err)
+	}
+
+	return nil
+}
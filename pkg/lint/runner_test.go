@@ -0,0 +1,118 @@
+package lint_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/junit"
+	"github.com/radiofrance/dib/pkg/lint"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestRunner_RunTest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		content          string
+		config           lint.Config
+		expectedError    string
+		expectedFailures string
+		expectedCases    []string
+	}{
+		{
+			name:             "no findings",
+			content:          "FROM debian:bookworm\n",
+			expectedFailures: "0",
+			expectedCases:    []string{"Dockerfile lint"},
+		},
+		{
+			name:             "findings below the threshold",
+			content:          "FROM debian\n",
+			expectedFailures: "0",
+			expectedCases:    []string{"DL3006: Always tag the version of an image explicitly"},
+		},
+		{
+			name:    "findings reaching the threshold",
+			content: "FROM debian\nMAINTAINER me\n",
+			config:  lint.Config{FailureThreshold: "warning"},
+			expectedError: "dockerfile lint failed: 1 DL3006 warning: " +
+				"Always tag the version of an image explicitly; 2 DL4000 error: MAINTAINER is deprecated",
+			expectedFailures: "2",
+			expectedCases: []string{
+				"DL3006: Always tag the version of an image explicitly",
+				"DL4000: MAINTAINER is deprecated",
+			},
+		},
+		{
+			name: "threshold and ignores from labels",
+			content: "FROM debian\n" +
+				"LABEL dib.lint.failure-threshold=\"none\"\n" +
+				"LABEL dib.lint.ignore=\"DL3006\"\n" +
+				"MAINTAINER me\n",
+			expectedFailures: "0",
+			expectedCases:    []string{"DL4000: MAINTAINER is deprecated"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			workingDir := t.TempDir()
+			contextPath := path.Join(workingDir, "docker", "image")
+			require.NoError(t, os.MkdirAll(contextPath, 0o750))
+			require.NoError(t, os.WriteFile(path.Join(contextPath, "Dockerfile"), []byte(test.content), 0o600))
+
+			runner := lint.NewTestRunner(test.config, "registry.org", workingDir)
+			opts := types.RunTestOptions{
+				ImageName:         "image",
+				DockerContextPath: contextPath,
+				ReportJunitDir:    path.Join(workingDir, "junit"),
+			}
+
+			assert.Equal(t, types.TestRunnerLint, runner.Name())
+			assert.True(t, runner.RunBeforeBuild())
+			assert.True(t, runner.IsConfigured(opts))
+
+			err := runner.RunTest(t.Context(), opts)
+			if test.expectedError != "" {
+				require.ErrorIs(t, err, lint.ErrLintFailed)
+				require.EqualError(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			rawJunit, err := os.ReadFile(lint.JunitReportPath(opts.ReportJunitDir, "image"))
+			require.NoError(t, err)
+
+			suite, err := junit.ParseRawLogs(rawJunit)
+			require.NoError(t, err)
+			assert.Equal(t, "lint", suite.Name)
+			assert.Equal(t, test.expectedFailures, suite.Failures)
+
+			names := []string{}
+			for _, testCase := range suite.TestCases {
+				names = append(names, testCase.Name)
+				assert.Equal(t, "docker/image/Dockerfile", testCase.File)
+			}
+
+			assert.Equal(t, test.expectedCases, names)
+		})
+	}
+}
+
+func TestTestRunner_IsConfigured(t *testing.T) {
+	t.Parallel()
+
+	runner := lint.NewTestRunner(lint.Config{}, "", "")
+
+	assert.False(t, runner.IsConfigured(types.RunTestOptions{DockerContextPath: t.TempDir()}))
+	assert.True(t, runner.IsConfigured(types.RunTestOptions{
+		DockerContextPath: t.TempDir(),
+		DockerfilePath:    "../../test/fixtures/dockerfile/simple.dockerfile",
+	}))
+}
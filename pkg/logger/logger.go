@@ -1,8 +1,11 @@
 package logger
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
@@ -14,9 +17,13 @@ import (
 
 type LogLevel int
 
+// LogFormat is the format of the log lines.
+type LogFormat string
+
 type Logger struct {
 	Writer io.Writer
 	Level  LogLevel
+	Format LogFormat
 }
 
 const (
@@ -27,12 +34,23 @@ const (
 	LogLevelFatal
 )
 
+const (
+	// LogFormatText prints human-readable, coloured log lines.
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON prints each log line as a JSON object, for log pipelines.
+	LogFormatJSON LogFormat = "json"
+)
+
+// slogLevelFatal is the slog level used for fatal logs, which slog does not define.
+const slogLevelFatal = slog.LevelError + 4
+
 var (
 	logger        atomic.Value
 	defaultLevel  = "info"
 	defaultLogger = Logger{
 		Writer: os.Stderr,
 		Level:  LogLevelInfo,
+		Format: LogFormatText,
 	}
 
 	// loggerMutex syncs all loggers, so that they don't print at the exact same time.
@@ -73,6 +91,29 @@ func SetLevel(level *string) {
 	logger.Store(log)
 }
 
+// SetFormat changes the format of the log lines. Supported formats are "text" and "json".
+func SetFormat(format *string) {
+	if format == nil || *format == "" {
+		return
+	}
+
+	log, ok := logger.Load().(Logger)
+	if !ok {
+		panic("invalid logger")
+	}
+
+	switch LogFormat(strings.ToLower(*format)) {
+	case LogFormatText:
+		log.Format = LogFormatText
+	case LogFormatJSON:
+		log.Format = LogFormatJSON
+	default:
+		Fatalf("%q is not a valid log format", *format)
+	}
+
+	logger.Store(log)
+}
+
 // LogLevelStyle returns the style of the prefix for each log level.
 func (l LogLevel) LogLevelStyle() pterm.Style {
 	switch l {
@@ -126,24 +167,86 @@ func (l LogLevel) String() string {
 	return "Unknown"
 }
 
+// slogLevel returns the slog level matching the log level.
+func (l LogLevel) slogLevel() slog.Level {
+	switch l {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	default:
+		return slogLevelFatal
+	}
+}
+
 // CanPrint checks if the logger can print a specific log level.
 func (l Logger) CanPrint(level LogLevel) bool {
 	return l.Level <= level
 }
 
-func (l Logger) log(level LogLevel, msg string) {
+func (l Logger) log(level LogLevel, msg string, attrs []slog.Attr) {
 	if !l.CanPrint(level) {
 		return
 	}
 
+	var line []byte
+	if l.Format == LogFormatJSON {
+		line = jsonLine(level, msg, attrs)
+	} else {
+		line = textLine(level, msg, attrs)
+	}
+
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+
+	_, _ = l.Writer.Write(line)
+}
+
+// textLine formats a log line as coloured text, with the fields appended as key=value pairs.
+func textLine(level LogLevel, msg string, attrs []slog.Attr) []byte {
 	line := pterm.Gray(time.Now().Format("15:04:05")) + " "
 	line += level.LogLevelStyle().Sprintf(" %-5s ", level.String()) + " "
 	line += level.MessageStyle().Sprint(msg)
 
-	loggerMutex.Lock()
-	defer loggerMutex.Unlock()
+	for _, attr := range attrs {
+		line += " " + pterm.Gray(attr.String())
+	}
+
+	return []byte(line + "\n")
+}
+
+// jsonLine formats a log line as a JSON object, holding the time, level, message and fields.
+func jsonLine(level LogLevel, msg string, attrs []slog.Attr) []byte {
+	var buf bytes.Buffer
+
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return attr
+			}
+
+			switch attr.Key {
+			case slog.MessageKey:
+				attr.Key = "message"
+			case slog.LevelKey:
+				attr.Value = slog.StringValue(level.String())
+			}
+
+			return attr
+		},
+	})
 
-	_, _ = l.Writer.Write([]byte(line + "\n"))
+	record := slog.NewRecord(time.Now(), level.slogLevel(), msg, 0)
+	record.AddAttrs(attrs...)
+
+	_ = handler.Handle(context.Background(), record)
+
+	return buf.Bytes()
 }
 
 func Get() Logger {
@@ -161,7 +264,7 @@ func Debugf(msg string, args ...any) {
 		panic("invalid logger")
 	}
 
-	l.log(LogLevelDebug, fmt.Sprintf(msg, args...))
+	l.log(LogLevelDebug, fmt.Sprintf(msg, args...), nil)
 }
 
 func Infof(msg string, args ...any) {
@@ -170,7 +273,7 @@ func Infof(msg string, args ...any) {
 		panic("invalid logger")
 	}
 
-	l.log(LogLevelInfo, fmt.Sprintf(msg, args...))
+	l.log(LogLevelInfo, fmt.Sprintf(msg, args...), nil)
 }
 
 func Warnf(msg string, args ...any) {
@@ -179,7 +282,7 @@ func Warnf(msg string, args ...any) {
 		panic("invalid logger")
 	}
 
-	l.log(LogLevelWarn, fmt.Sprintf(msg, args...))
+	l.log(LogLevelWarn, fmt.Sprintf(msg, args...), nil)
 }
 
 func Errorf(msg string, args ...any) {
@@ -188,7 +291,7 @@ func Errorf(msg string, args ...any) {
 		panic("invalid logger")
 	}
 
-	l.log(LogLevelError, fmt.Sprintf(msg, args...))
+	l.log(LogLevelError, fmt.Sprintf(msg, args...), nil)
 }
 
 func Fatalf(msg string, args ...any) {
@@ -197,7 +300,61 @@ func Fatalf(msg string, args ...any) {
 		panic("invalid logger")
 	}
 
-	l.log(LogLevelFatal, fmt.Sprintf(msg, args...))
+	l.log(LogLevelFatal, fmt.Sprintf(msg, args...), nil)
+
+	if l.CanPrint(LogLevelFatal) {
+		os.Exit(1)
+	}
+}
+
+// Entry is a logger carrying contextual fields, such as the image name or hash,
+// which are printed along with every log message.
+type Entry struct {
+	attrs []slog.Attr
+}
+
+// With returns an Entry with the given fields. Arguments are key-value pairs or slog.Attr,
+// as accepted by slog.Logger.With.
+func With(args ...any) *Entry {
+	return (&Entry{}).With(args...)
+}
+
+// With returns a new Entry with the given fields added to the existing ones.
+func (e *Entry) With(args ...any) *Entry {
+	record := slog.Record{}
+	record.Add(args...)
+
+	attrs := make([]slog.Attr, 0, len(e.attrs)+record.NumAttrs())
+	attrs = append(attrs, e.attrs...)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	return &Entry{attrs: attrs}
+}
+
+func (e *Entry) Debugf(msg string, args ...any) {
+	Get().log(LogLevelDebug, fmt.Sprintf(msg, args...), e.attrs)
+}
+
+func (e *Entry) Infof(msg string, args ...any) {
+	Get().log(LogLevelInfo, fmt.Sprintf(msg, args...), e.attrs)
+}
+
+func (e *Entry) Warnf(msg string, args ...any) {
+	Get().log(LogLevelWarn, fmt.Sprintf(msg, args...), e.attrs)
+}
+
+func (e *Entry) Errorf(msg string, args ...any) {
+	Get().log(LogLevelError, fmt.Sprintf(msg, args...), e.attrs)
+}
+
+func (e *Entry) Fatalf(msg string, args ...any) {
+	l := Get()
+
+	l.log(LogLevelFatal, fmt.Sprintf(msg, args...), e.attrs)
 
 	if l.CanPrint(LogLevelFatal) {
 		os.Exit(1)
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_LogJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	log := Logger{Writer: &buf, Level: LogLevelInfo, Format: LogFormatJSON}
+	log.log(LogLevelDebug, "should not be displayed", nil)
+	log.log(LogLevelWarn, "building image", []slog.Attr{
+		slog.String("image", "bullseye"),
+		slog.String("hash", "golf-hotel-india-juliet"),
+	})
+	log.log(LogLevelFatal, "fatal error", nil)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "building image", entry["message"])
+	assert.Equal(t, "bullseye", entry["image"])
+	assert.Equal(t, "golf-hotel-india-juliet", entry["hash"])
+	assert.Contains(t, entry, "time")
+
+	require.NoError(t, json.Unmarshal(lines[1], &entry))
+	assert.Equal(t, "FATAL", entry["level"])
+}
+
+func TestLogger_LogText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	log := Logger{Writer: &buf, Level: LogLevelDebug, Format: LogFormatText}
+	log.log(LogLevelInfo, "building image", []slog.Attr{slog.String("image", "bullseye")})
+
+	assert.Contains(t, buf.String(), "building image")
+	assert.Contains(t, buf.String(), "image=bullseye")
+}
+
+func TestEntry_With(t *testing.T) {
+	t.Parallel()
+
+	entry := With("image", "bullseye").With(slog.Int("attempt", 2))
+
+	assert.Equal(t, []slog.Attr{
+		slog.String("image", "bullseye"),
+		slog.Int("attempt", 2),
+	}, entry.attrs)
+}
@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/report"
+)
+
+const (
+	namespace  = "dib"
+	defaultJob = "dib"
+)
+
+// Config holds the configuration for exporting the metrics of a dib run.
+type Config struct {
+	// PushgatewayURL is the URL of a Pushgateway-compatible endpoint where metrics are pushed.
+	PushgatewayURL string `mapstructure:"pushgateway_url"`
+	// Job is the job name used when pushing metrics. Defaults to "dib".
+	Job string `mapstructure:"job"`
+	// Grouping holds additional grouping labels used when pushing metrics (e.g. the pipeline name).
+	Grouping map[string]string `mapstructure:"grouping"`
+	// TextfilePath is the path of a file where metrics are written, for the node exporter textfile collector.
+	TextfilePath string `mapstructure:"textfile_path"`
+}
+
+// Enabled returns true if metrics have to be exported somewhere.
+func (c Config) Enabled() bool {
+	return c.PushgatewayURL != "" || c.TextfilePath != ""
+}
+
+// Metrics collects metrics about a dib build run.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	imagesRebuilt    prometheus.Counter
+	imagesRetagged   prometheus.Counter
+	imagesSkipped    prometheus.Counter
+	imagesFailed     prometheus.Counter
+	testFailures     prometheus.Counter
+	buildDuration    prometheus.Histogram
+	runDuration      prometheus.Gauge
+	lastRunTimestamp prometheus.Gauge
+	registryRequests *prometheus.CounterVec
+
+	startTime time.Time
+}
+
+// New creates a new instance of Metrics, with all metrics registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		imagesRebuilt: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "images_rebuilt_total",
+			Help:      "Number of images successfully rebuilt.",
+		}),
+		imagesRetagged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "images_retagged_total",
+			Help:      "Number of images retagged.",
+		}),
+		imagesSkipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "images_skipped_total",
+			Help:      "Number of images not rebuilt, because they are up-to-date or a parent image failed to build.",
+		}),
+		imagesFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "images_failed_total",
+			Help:      "Number of images that failed to build.",
+		}),
+		testFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "test_failures_total",
+			Help:      "Number of images whose tests failed.",
+		}),
+		buildDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "image_build_duration_seconds",
+			Help:      "Time spent building each image, excluding the time spent queued.",
+			Buckets:   []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+		}),
+		runDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "run_duration_seconds",
+			Help:      "Total duration of the dib run.",
+		}),
+		lastRunTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_run_timestamp_seconds",
+			Help:      "Unix timestamp of the end of the dib run.",
+		}),
+		registryRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registry_requests_total",
+			Help:      "Number of calls made to the registry, by operation and result.",
+		}, []string{"operation", "result"}),
+		startTime: time.Now(),
+	}
+
+	m.registry.MustRegister(
+		m.imagesRebuilt,
+		m.imagesRetagged,
+		m.imagesSkipped,
+		m.imagesFailed,
+		m.testFailures,
+		m.buildDuration,
+		m.runDuration,
+		m.lastRunTimestamp,
+		m.registryRequests,
+	)
+
+	return m
+}
+
+// ObserveBuild records the outcome of the builds from the report.
+// Images of the graph that are not part of the report were up-to-date, and are counted as skipped.
+func (m *Metrics) ObserveBuild(graph *dag.DAG, dibReport *report.Report) {
+	buildReports := make(map[string]report.BuildReport, len(dibReport.BuildReports))
+	for _, buildReport := range dibReport.BuildReports {
+		buildReports[buildReport.Image.Name] = buildReport
+	}
+
+	graph.Walk(func(node *dag.Node) {
+		buildReport, processed := buildReports[node.Image.Name]
+		if !processed {
+			m.imagesSkipped.Inc()
+			return
+		}
+
+		switch buildReport.BuildStatus {
+		case report.BuildStatusSuccess:
+			m.imagesRebuilt.Inc()
+			m.buildDuration.Observe(buildReport.Timings.BuildDuration.Seconds())
+		case report.BuildStatusError:
+			m.imagesFailed.Inc()
+		case report.BuildStatusSkipped:
+			m.imagesSkipped.Inc()
+		}
+
+		if buildReport.TestsStatus == report.TestsStatusFailed {
+			m.testFailures.Inc()
+		}
+	})
+}
+
+// ObserveRetag records the number of images retagged.
+func (m *Metrics) ObserveRetag(graph *dag.DAG) {
+	graph.Walk(func(node *dag.Node) {
+		if node.Image.RetagDone {
+			m.imagesRetagged.Inc()
+		}
+	})
+}
+
+// Export writes the metrics to the textfile and pushes them to the Pushgateway, as configured.
+func (m *Metrics) Export(cfg Config) error {
+	end := time.Now()
+	m.runDuration.Set(end.Sub(m.startTime).Seconds())
+	m.lastRunTimestamp.Set(float64(end.Unix()))
+
+	var errs []error
+
+	if cfg.TextfilePath != "" {
+		err := prometheus.WriteToTextfile(cfg.TextfilePath, m.registry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot write metrics to %s: %w", cfg.TextfilePath, err))
+		} else {
+			logger.Infof("Metrics written to \"%s\"", cfg.TextfilePath)
+		}
+	}
+
+	if cfg.PushgatewayURL != "" {
+		job := cfg.Job
+		if job == "" {
+			job = defaultJob
+		}
+
+		pusher := push.New(cfg.PushgatewayURL, job).Gatherer(m.registry)
+		for name, value := range cfg.Grouping {
+			pusher = pusher.Grouping(name, value)
+		}
+
+		err := pusher.Push()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot push metrics to %s: %w", cfg.PushgatewayURL, err))
+		} else {
+			logger.Infof("Metrics pushed to \"%s\"", cfg.PushgatewayURL)
+		}
+	}
+
+	return errors.Join(errs...)
+}
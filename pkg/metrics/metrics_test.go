@@ -0,0 +1,170 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/metrics"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGraph() (*dag.DAG, *report.Report) {
+	root := dag.NewNode(&dag.Image{Name: "registry/root", ShortName: "root", RetagDone: true})
+	built := dag.NewNode(&dag.Image{Name: "registry/built", ShortName: "built", RetagDone: true})
+	failed := dag.NewNode(&dag.Image{Name: "registry/failed", ShortName: "failed"})
+	skipped := dag.NewNode(&dag.Image{Name: "registry/skipped", ShortName: "skipped"})
+
+	root.AddChild(built)
+	root.AddChild(failed)
+	failed.AddChild(skipped)
+
+	graph := &dag.DAG{}
+	graph.AddNode(root)
+
+	dibReport := &report.Report{
+		BuildReports: []report.BuildReport{
+			{
+				Image:       *built.Image,
+				BuildStatus: report.BuildStatusSuccess,
+				TestsStatus: report.TestsStatusFailed,
+				Timings:     report.Timings{BuildDuration: 45 * time.Second},
+			},
+			{
+				Image:       *failed.Image,
+				BuildStatus: report.BuildStatusError,
+			},
+			{
+				Image:       *skipped.Image,
+				BuildStatus: report.BuildStatusSkipped,
+			},
+		},
+	}
+
+	return graph, dibReport
+}
+
+type registryTagger struct {
+	*mock.TagListingRegistry
+	*mock.Tagger
+	*mock.ImageCopier
+}
+
+func TestMetrics_ExportTextfile(t *testing.T) {
+	t.Parallel()
+
+	graph, dibReport := newTestGraph()
+
+	buildMetrics := metrics.New()
+	buildMetrics.ObserveBuild(graph, dibReport)
+	buildMetrics.ObserveRetag(graph)
+
+	registry := buildMetrics.InstrumentRegistry(registryTagger{
+		TagListingRegistry: &mock.TagListingRegistry{Registry: mock.Registry{Lock: &sync.Mutex{}}},
+		Tagger:             &mock.Tagger{},
+		ImageCopier:        &mock.ImageCopier{},
+	})
+	_, err := registry.RefExists("registry/root:hash")
+	require.NoError(t, err)
+	_, err = registry.Tags("registry/root")
+	require.NoError(t, err)
+	require.NoError(t, registry.Tag("registry/root:dev-hash", "registry/root:hash"))
+
+	failingRegistry := buildMetrics.InstrumentRegistry(registryTagger{
+		TagListingRegistry: &mock.TagListingRegistry{
+			Registry: mock.Registry{Lock: &sync.Mutex{}, Error: errors.New("unauthorized")},
+		},
+		Tagger:      &mock.Tagger{},
+		ImageCopier: &mock.ImageCopier{},
+	})
+	_, err = failingRegistry.RefExists("registry/root:hash")
+	require.Error(t, err)
+
+	textfile := path.Join(t.TempDir(), "dib.prom")
+	require.NoError(t, buildMetrics.Export(metrics.Config{TextfilePath: textfile}))
+
+	content, err := os.ReadFile(textfile)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(content), "dib_images_rebuilt_total 1\n")
+	assert.Contains(t, string(content), "dib_images_failed_total 1\n")
+	assert.Contains(t, string(content), "dib_images_skipped_total 2\n")
+	assert.Contains(t, string(content), "dib_images_retagged_total 2\n")
+	assert.Contains(t, string(content), "dib_test_failures_total 1\n")
+	assert.Contains(t, string(content), "dib_image_build_duration_seconds_sum 45\n")
+	assert.Contains(t, string(content), "dib_image_build_duration_seconds_bucket{le=\"60\"} 1\n")
+	assert.Contains(t, string(content), "dib_registry_requests_total{operation=\"ref_exists\",result=\"success\"} 1\n")
+	assert.Contains(t, string(content), "dib_registry_requests_total{operation=\"ref_exists\",result=\"error\"} 1\n")
+	assert.Contains(t, string(content), "dib_registry_requests_total{operation=\"list_tags\",result=\"success\"} 1\n")
+	assert.Contains(t, string(content), "dib_registry_requests_total{operation=\"tag\",result=\"success\"} 1\n")
+	assert.Contains(t, string(content), "dib_run_duration_seconds ")
+}
+
+func TestMetrics_ExportPushgateway(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		requestPath string
+		body        []byte
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requestPath = r.URL.Path
+		body, _ = io.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	graph, dibReport := newTestGraph()
+
+	buildMetrics := metrics.New()
+	buildMetrics.ObserveBuild(graph, dibReport)
+
+	err := buildMetrics.Export(metrics.Config{
+		PushgatewayURL: server.URL,
+		Grouping:       map[string]string{"pipeline": "images"},
+	})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, "/metrics/job/dib/pipeline/images", requestPath)
+	assert.Contains(t, string(body), "dib_images_rebuilt_total")
+}
+
+func TestMetrics_ExportPushgatewayError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	err := metrics.New().Export(metrics.Config{PushgatewayURL: server.URL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot push metrics")
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, metrics.Config{Job: "dib"}.Enabled())
+	assert.True(t, metrics.Config{TextfilePath: "dib.prom"}.Enabled())
+	assert.True(t, metrics.Config{PushgatewayURL: "http://pushgateway:9091"}.Enabled())
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+// Registry is a docker registry that is also able to tag and copy images.
+type Registry interface {
+	types.DockerRegistry
+	types.TagLister
+	types.ImageTagger
+	types.ImageCopier
+}
+
+// InstrumentedRegistry wraps a Registry to count the calls made to it.
+type InstrumentedRegistry struct {
+	registry Registry
+	metrics  *Metrics
+}
+
+// InstrumentRegistry returns a Registry counting calls made to the given registry.
+func (m *Metrics) InstrumentRegistry(registry Registry) *InstrumentedRegistry {
+	return &InstrumentedRegistry{registry, m}
+}
+
+// RefExists checks if the image ref exists in the registry.
+func (r *InstrumentedRegistry) RefExists(imageRef string) (bool, error) {
+	exists, err := r.registry.RefExists(imageRef)
+	r.metrics.observeRegistryRequest("ref_exists", err)
+
+	return exists, err
+}
+
+// Tags returns all the tags of the repository.
+func (r *InstrumentedRegistry) Tags(repository string) ([]string, error) {
+	tags, err := r.registry.Tags(repository)
+	r.metrics.observeRegistryRequest("list_tags", err)
+
+	return tags, err
+}
+
+// Tag creates a new tag from an existing one.
+func (r *InstrumentedRegistry) Tag(existingRef, toCreateRef string) error {
+	err := r.registry.Tag(existingRef, toCreateRef)
+	r.metrics.observeRegistryRequest("tag", err)
+
+	return err
+}
+
+// Copy copies the image the source ref points to, to the destination ref.
+func (r *InstrumentedRegistry) Copy(srcRef, destRef string) error {
+	err := r.registry.Copy(srcRef, destRef)
+	r.metrics.observeRegistryRequest("copy", err)
+
+	return err
+}
+
+func (m *Metrics) observeRegistryRequest(operation string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	m.registryRequests.WithLabelValues(operation, result).Inc()
+}
@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"fmt"
+
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+type pushArgs struct {
+	Image   types.ArchivedImage
+	DestRef string
+}
+
+type ImageArchive struct {
+	// Digests maps the refs of the images which can be added to the archive to their digest.
+	Digests map[string]string
+
+	Archived          []types.ArchivedImage
+	RecordedCallsArgs []pushArgs
+}
+
+func (a *ImageArchive) Add(imageRef string, image types.ArchivedImage) (types.ArchivedImage, error) {
+	digest, ok := a.Digests[imageRef]
+	if !ok {
+		return image, fmt.Errorf("%s: manifest unknown", imageRef)
+	}
+
+	image.Digest = digest
+	a.Archived = append(a.Archived, image)
+
+	return image, nil
+}
+
+func (a *ImageArchive) Images() ([]types.ArchivedImage, error) {
+	return a.Archived, nil
+}
+
+func (a *ImageArchive) Push(image types.ArchivedImage, destRef string) error {
+	a.RecordedCallsArgs = append(a.RecordedCallsArgs, pushArgs{Image: image, DestRef: destRef})
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package mock
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CleanableRegistry holds the tags of the repositories, and the creation dates of the manifests.
+type CleanableRegistry struct {
+	// Repositories maps the repositories to their tags, and the tags to the digest they point to.
+	Repositories map[string]map[string]string
+	CreatedAt    map[string]time.Time
+	Deleted      []string
+}
+
+func (r *CleanableRegistry) Digest(imageRef string) (string, error) {
+	repository, tag, _ := strings.Cut(imageRef, ":")
+
+	digest, ok := r.Repositories[repository][tag]
+	if !ok {
+		return "", fmt.Errorf("%s: manifest unknown", imageRef)
+	}
+
+	return digest, nil
+}
+
+func (r *CleanableRegistry) Tags(repository string) ([]string, error) {
+	tags, ok := r.Repositories[repository]
+	if !ok {
+		return nil, fmt.Errorf("%s: repository unknown", repository)
+	}
+
+	return slices.Sorted(maps.Keys(tags)), nil
+}
+
+func (r *CleanableRegistry) Created(imageRef string) (time.Time, error) {
+	digest, err := r.Digest(imageRef)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return r.CreatedAt[digest], nil
+}
+
+func (r *CleanableRegistry) Delete(imageRef string) error {
+	repository, digest, _ := strings.Cut(imageRef, "@")
+
+	r.Deleted = append(r.Deleted, imageRef)
+
+	maps.DeleteFunc(r.Repositories[repository], func(_, tagDigest string) bool {
+		return tagDigest == digest
+	})
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package mock
+
+import (
+	"fmt"
+	"sync"
+)
+
+type copyArgs struct {
+	Src  string
+	Dest string
+}
+
+type ImageCopier struct {
+	// Missing lists the source refs which cannot be copied.
+	Missing []string
+
+	mu                sync.Mutex
+	RecordedCallsArgs []copyArgs
+}
+
+func (c *ImageCopier) Copy(srcRef, destRef string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, missing := range c.Missing {
+		if missing == srcRef {
+			return fmt.Errorf("%s: manifest unknown", srcRef)
+		}
+	}
+
+	c.RecordedCallsArgs = append(c.RecordedCallsArgs, copyArgs{Src: srcRef, Dest: destRef})
+
+	return nil
+}
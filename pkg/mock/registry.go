@@ -1,7 +1,9 @@
 package mock
 
 import (
+	"errors"
 	"slices"
+	"strings"
 	"sync"
 )
 
@@ -9,7 +11,9 @@ type Registry struct {
 	RefExistsCallCount int
 	ExistingRefs       []string
 	Error              error
-	Lock               sync.Locker
+	// RateLimited is the number of calls rejected with a rate limit error before the registry answers.
+	RateLimited int
+	Lock        sync.Locker
 }
 
 func (r *Registry) RefExists(ref string) (bool, error) {
@@ -18,9 +22,44 @@ func (r *Registry) RefExists(ref string) (bool, error) {
 
 	r.RefExistsCallCount++
 
+	if r.RateLimited > 0 {
+		r.RateLimited--
+		return false, errors.New("429 Too Many Requests")
+	}
+
 	if slices.Contains(r.ExistingRefs, ref) {
 		return true, r.Error
 	}
 
 	return false, r.Error
 }
+
+// TagListingRegistry is a Registry able to list the tags of the repositories.
+type TagListingRegistry struct {
+	Registry
+
+	TagsCallCount int
+}
+
+func (r *TagListingRegistry) Tags(repository string) ([]string, error) {
+	r.Lock.Lock()
+	defer r.Lock.Unlock()
+
+	r.TagsCallCount++
+
+	if r.RateLimited > 0 {
+		r.RateLimited--
+		return nil, errors.New("429 Too Many Requests")
+	}
+
+	var tags []string
+
+	for _, ref := range r.ExistingRefs {
+		tag, ok := strings.CutPrefix(ref, repository+":")
+		if ok {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, r.Error
+}
@@ -0,0 +1,41 @@
+package mock
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+)
+
+// NewRegistryServer starts an in-memory OCI registry, stopped at the end of the test, and returns its host.
+func NewRegistryServer(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(ggcrregistry.New())
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// PushRandomImage pushes a random single-layer image to the given ref, and returns its digest.
+func PushRandomImage(t *testing.T, imageRef string) v1.Hash {
+	t.Helper()
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	ref, err := name.ParseReference(imageRef)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	return digest
+}
@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"context"
+	"sync"
+)
+
+type SBOMProvider struct {
+	ReturnedError error
+
+	mu       sync.Mutex
+	Attached map[string]string
+	Ensured  []string
+}
+
+func (s *SBOMProvider) Attach(_ context.Context, imageRef, filename string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attached == nil {
+		s.Attached = map[string]string{}
+	}
+
+	s.Attached[imageRef] = filename
+
+	return s.ReturnedError
+}
+
+func (s *SBOMProvider) Ensure(_ context.Context, imageRef string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Ensured = append(s.Ensured, imageRef)
+
+	return s.ReturnedError
+}
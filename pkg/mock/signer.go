@@ -0,0 +1,26 @@
+package mock
+
+import (
+	"context"
+	"sync"
+)
+
+type ImageSigner struct {
+	ReturnedError error
+
+	mu     sync.Mutex
+	Signed []string
+}
+
+func (s *ImageSigner) Sign(_ context.Context, imageRef string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ReturnedError != nil {
+		return "", s.ReturnedError
+	}
+
+	s.Signed = append(s.Signed, imageRef)
+
+	return imageRef + ".sig", nil
+}
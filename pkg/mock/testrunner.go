@@ -21,3 +21,12 @@ func (t *TestRunner) IsConfigured(_ types.RunTestOptions) bool {
 func (t *TestRunner) RunTest(_ context.Context, _ types.RunTestOptions) error {
 	return t.ReturnedError
 }
+
+// PreBuildTestRunner is a TestRunner running before the image is built.
+type PreBuildTestRunner struct {
+	TestRunner
+}
+
+func (t *PreBuildTestRunner) RunBeforeBuild() bool {
+	return true
+}
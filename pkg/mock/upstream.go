@@ -0,0 +1,26 @@
+package mock
+
+import "fmt"
+
+type UpstreamRegistry struct {
+	Digests map[string]string
+	TagList map[string][]string
+}
+
+func (r *UpstreamRegistry) Digest(imageRef string) (string, error) {
+	digest, ok := r.Digests[imageRef]
+	if !ok {
+		return "", fmt.Errorf("%s: manifest unknown", imageRef)
+	}
+
+	return digest, nil
+}
+
+func (r *UpstreamRegistry) Tags(repository string) ([]string, error) {
+	tags, ok := r.TagList[repository]
+	if !ok {
+		return nil, fmt.Errorf("%s: repository unknown", repository)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type SignatureVerifier struct {
+	// Unsigned lists the refs of the images without a valid signature.
+	Unsigned []string
+
+	mu       sync.Mutex
+	Verified []string
+}
+
+func (v *SignatureVerifier) Verify(_ context.Context, imageRef string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.Verified = append(v.Verified, imageRef)
+
+	for _, unsigned := range v.Unsigned {
+		if unsigned == imageRef {
+			return fmt.Errorf("no valid signature found for %s", imageRef)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package provenance
+
+import "fmt"
+
+const (
+	// ModeMin records the minimal provenance: the build materials and the VCS information.
+	ModeMin = "min"
+	// ModeMax also records the build parameters, including the metadata dib adds to the build.
+	ModeMax = "max"
+)
+
+// Config holds the configuration for the SLSA provenance attestations.
+type Config struct {
+	// Enabled asks BuildKit to attach a SLSA provenance attestation to every built image.
+	Enabled bool `mapstructure:"enabled"`
+	// Mode is either "max" (default) or "min".
+	Mode string `mapstructure:"mode"`
+}
+
+// Validate returns an error if the mode is unknown.
+func (c Config) Validate() error {
+	switch c.Mode {
+	case "", ModeMin, ModeMax:
+		return nil
+	default:
+		return fmt.Errorf("invalid provenance mode %q (available: %s, %s)", c.Mode, ModeMax, ModeMin)
+	}
+}
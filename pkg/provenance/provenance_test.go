@@ -0,0 +1,37 @@
+package provenance_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/provenance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mode    string
+		wantErr bool
+	}{
+		{name: "default", mode: ""},
+		{name: "max", mode: provenance.ModeMax},
+		{name: "min", mode: provenance.ModeMin},
+		{name: "unknown", mode: "full", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := provenance.Config{Enabled: true, Mode: test.mode}.Validate()
+			if test.wantErr {
+				assert.ErrorContains(t, err, `invalid provenance mode "full"`)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
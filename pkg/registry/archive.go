@@ -0,0 +1,343 @@
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+const (
+	// annotationRefName is the OCI annotation holding the tag of an image in an image layout.
+	annotationRefName = "org.opencontainers.image.ref.name"
+	// annotationImageName holds the full ref of an image, as expected by containerd when importing an image layout.
+	annotationImageName = "io.containerd.image.name"
+	// annotationRepository holds the repository path of an image, so it can be pushed to any registry.
+	annotationRepository = "dib.repository"
+
+	tarExtension = ".tar"
+)
+
+// Archive implements types.ImageArchive, storing the images in an OCI image layout. The layout is either a
+// directory, or a tarball when its path has the ".tar" extension.
+type Archive struct {
+	layout layout.Path
+	opts   []remote.Option
+	// tarball is the path of the tarball the layout is written to by Flush.
+	tarball string
+	// tmpDir is the temporary directory holding the layout of a tarball, removed by Close.
+	tmpDir string
+}
+
+// CreateArchive creates an empty archive at the given path, using the credentials from the docker config file to
+// pull the images. Archives written to a tarball are only complete once flushed.
+func CreateArchive(ctx context.Context, archivePath string, opts ...remote.Option) (*Archive, error) {
+	archive := newArchive(ctx, opts)
+
+	dir := archivePath
+	if strings.HasSuffix(archivePath, tarExtension) {
+		tmpDir, err := os.MkdirTemp("", "dib-archive")
+		if err != nil {
+			return nil, err
+		}
+
+		dir = tmpDir
+		archive.tarball = archivePath
+		archive.tmpDir = tmpDir
+	}
+
+	path, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		_ = archive.Close()
+		return nil, fmt.Errorf("cannot create image layout in %s: %w", dir, err)
+	}
+
+	archive.layout = path
+
+	return archive, nil
+}
+
+// OpenArchive opens an existing archive, either a directory or a tarball, using the credentials from the docker
+// config file to push the images.
+func OpenArchive(ctx context.Context, archivePath string, opts ...remote.Option) (*Archive, error) {
+	archive := newArchive(ctx, opts)
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := archivePath
+	if !info.IsDir() {
+		dir, err = os.MkdirTemp("", "dib-archive")
+		if err != nil {
+			return nil, err
+		}
+
+		archive.tmpDir = dir
+
+		err = extractTar(archivePath, dir)
+		if err != nil {
+			_ = archive.Close()
+			return nil, fmt.Errorf("cannot extract %s: %w", archivePath, err)
+		}
+	}
+
+	path, err := layout.FromPath(dir)
+	if err != nil {
+		_ = archive.Close()
+		return nil, fmt.Errorf("cannot open image layout in %s: %w", archivePath, err)
+	}
+
+	archive.layout = path
+
+	return archive, nil
+}
+
+func newArchive(ctx context.Context, opts []remote.Option) *Archive {
+	return &Archive{
+		opts: append([]remote.Option{
+			remote.WithContext(ctx),
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		}, opts...),
+	}
+}
+
+// Flush writes the tarball of an archive created with the ".tar" extension. It does nothing for directories.
+func (a *Archive) Flush() error {
+	if a.tarball == "" {
+		return nil
+	}
+
+	err := writeTar(string(a.layout), a.tarball)
+	if err != nil {
+		return fmt.Errorf("cannot write %s: %w", a.tarball, err)
+	}
+
+	return nil
+}
+
+// Close removes the temporary files of the archive.
+func (a *Archive) Close() error {
+	if a.tmpDir == "" {
+		return nil
+	}
+
+	return os.RemoveAll(a.tmpDir)
+}
+
+// Add copies the image the ref points to from its registry into the archive. Image indexes are stored with all
+// their images, so multi-platform images are preserved.
+func (a *Archive) Add(imageRef string, image types.ArchivedImage) (types.ArchivedImage, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return image, fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	desc, err := remote.Get(ref, a.opts...)
+	if err != nil {
+		return image, fmt.Errorf("cannot get manifest of %q: %w", imageRef, err)
+	}
+
+	image.Digest = desc.Digest.String()
+
+	annotations := map[string]string{
+		annotationImageName:  imageRef,
+		annotationRepository: image.Repository,
+	}
+	if image.Tag != "" {
+		annotations[annotationRefName] = image.Tag
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return image, err
+		}
+
+		err = a.layout.AppendIndex(index, layout.WithAnnotations(annotations))
+		if err != nil {
+			return image, fmt.Errorf("cannot add %q to the archive: %w", imageRef, err)
+		}
+
+		return image, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return image, err
+	}
+
+	err = a.layout.AppendImage(img, layout.WithAnnotations(annotations))
+	if err != nil {
+		return image, fmt.Errorf("cannot add %q to the archive: %w", imageRef, err)
+	}
+
+	return image, nil
+}
+
+// Images returns all the images stored in the archive by dib.
+func (a *Archive) Images() ([]types.ArchivedImage, error) {
+	index, err := a.layout.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]types.ArchivedImage, 0, len(manifest.Manifests))
+
+	for _, desc := range manifest.Manifests {
+		repository, ok := desc.Annotations[annotationRepository]
+		if !ok {
+			continue
+		}
+
+		images = append(images, types.ArchivedImage{
+			Repository: repository,
+			Tag:        desc.Annotations[annotationRefName],
+			Digest:     desc.Digest.String(),
+		})
+	}
+
+	return images, nil
+}
+
+// Push pushes an image of the archive to the destination ref, along with its blobs.
+func (a *Archive) Push(image types.ArchivedImage, destRef string) error {
+	ref, err := name.ParseReference(destRef)
+	if err != nil {
+		return fmt.Errorf("invalid image ref %q: %w", destRef, err)
+	}
+
+	digest, err := v1.NewHash(image.Digest)
+	if err != nil {
+		return fmt.Errorf("invalid digest %q: %w", image.Digest, err)
+	}
+
+	index, err := a.layout.ImageIndex()
+	if err != nil {
+		return err
+	}
+
+	// The manifest is pushed as is, so the image keeps its digest.
+	childIndex, err := index.ImageIndex(digest)
+	if err == nil {
+		err = remote.WriteIndex(ref, childIndex, a.opts...)
+		if err != nil {
+			return fmt.Errorf("cannot push %q: %w", destRef, err)
+		}
+
+		return nil
+	}
+
+	img, err := index.Image(digest)
+	if err != nil {
+		return fmt.Errorf("cannot read image %s from the archive: %w", image.Digest, err)
+	}
+
+	err = remote.Write(ref, img, a.opts...)
+	if err != nil {
+		return fmt.Errorf("cannot push %q: %w", destRef, err)
+	}
+
+	return nil
+}
+
+// writeTar writes the content of the directory to a tarball.
+func writeTar(dir, filename string) (err error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	defer func() { err = errors.Join(err, file.Close()) }()
+
+	writer := tar.NewWriter(file)
+
+	err = writer.AddFS(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// extractTar extracts the regular files and directories of the tarball into the directory.
+func extractTar(filename, dir string) (err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	defer func() { err = errors.Join(err, file.Close()) }()
+
+	reader := tar.NewReader(file)
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !filepath.IsLocal(header.Name) {
+			return fmt.Errorf("invalid path in tarball: %s", header.Name)
+		}
+
+		target := filepath.Join(dir, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			err = extractFile(reader, target, header.FileInfo().Mode())
+		default:
+			return fmt.Errorf("unsupported file type in tarball: %s", header.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(reader io.Reader, target string, mode fs.FileMode) (err error) {
+	err = os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	defer func() { err = errors.Join(err, file.Close()) }()
+
+	_, err = io.Copy(file, reader) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
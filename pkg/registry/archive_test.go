@@ -0,0 +1,92 @@
+package registry_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		archive string
+	}{
+		{name: "directory", archive: "images"},
+		{name: "tarball", archive: "images.tar"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srcHost := mock.NewRegistryServer(t)
+			destHost := mock.NewRegistryServer(t)
+
+			img, err := random.Image(64, 2)
+			require.NoError(t, err)
+
+			imageRef, err := name.ParseReference(srcHost + "/team/app:hash")
+			require.NoError(t, err)
+			require.NoError(t, remote.Write(imageRef, img))
+
+			index, err := random.Index(64, 1, 2)
+			require.NoError(t, err)
+
+			indexRef, err := name.ParseReference(srcHost + "/library/debian:bookworm")
+			require.NoError(t, err)
+			require.NoError(t, remote.WriteIndex(indexRef, index))
+
+			archivePath := path.Join(t.TempDir(), test.archive)
+
+			archive, err := registry.CreateArchive(t.Context(), archivePath)
+			require.NoError(t, err)
+
+			archivedImage, err := archive.Add(srcHost+"/team/app:hash", types.ArchivedImage{Repository: "app", Tag: "hash"})
+			require.NoError(t, err)
+
+			archivedIndex, err := archive.Add(srcHost+"/library/debian:bookworm",
+				types.ArchivedImage{Repository: "library/debian"})
+			require.NoError(t, err)
+
+			_, err = archive.Add(srcHost+"/team/app:missing", types.ArchivedImage{Repository: "app", Tag: "missing"})
+			require.ErrorContains(t, err, "cannot get manifest of")
+
+			require.NoError(t, archive.Flush())
+			require.NoError(t, archive.Close())
+
+			archive, err = registry.OpenArchive(t.Context(), archivePath)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, archive.Close()) })
+
+			images, err := archive.Images()
+			require.NoError(t, err)
+			assert.Equal(t, []types.ArchivedImage{archivedImage, archivedIndex}, images)
+
+			require.NoError(t, archive.Push(archivedImage, destHost+"/site/app:hash"))
+			require.NoError(t, archive.Push(archivedIndex, destHost+"/site/library/debian@"+archivedIndex.Digest))
+
+			upstream := registry.NewUpstream(t.Context())
+
+			digest, err := upstream.Digest(destHost + "/site/app:hash")
+			require.NoError(t, err)
+			assert.Equal(t, archivedImage.Digest, digest)
+
+			expectedDigest, err := index.Digest()
+			require.NoError(t, err)
+
+			digest, err = upstream.Digest(destHost + "/site/library/debian@" + archivedIndex.Digest)
+			require.NoError(t, err)
+			assert.Equal(t, expectedDigest.String(), digest)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RefCache stores the refs known to exist in the registry, along with the date they were last seen.
+// Refs are only trusted for a limited time, as tags may be removed from the registry (e.g. by "dib gc").
+type RefCache struct {
+	filename string
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	refs map[string]time.Time
+}
+
+// LoadRefCache reads the cache from the file. A missing file gives an empty cache.
+func LoadRefCache(filename string, ttl time.Duration) (*RefCache, error) {
+	cache := &RefCache{
+		filename: filename,
+		ttl:      ttl,
+		now:      time.Now,
+		refs:     map[string]time.Time{},
+	}
+
+	content, err := os.ReadFile(filename) //nolint:gosec
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read registry cache: %w", err)
+	}
+
+	err = json.Unmarshal(content, &cache.refs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry cache %s: %w", filename, err)
+	}
+
+	return cache, nil
+}
+
+// Exists returns true if the ref was seen in the registry less than the TTL ago.
+func (c *RefCache) Exists(ref string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen, ok := c.refs[ref]
+
+	return ok && c.now().Sub(seen) < c.ttl
+}
+
+// Add records the ref was just seen in the registry.
+func (c *RefCache) Add(ref string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refs[ref] = c.now()
+}
+
+// Save writes the cache to the file, without the expired refs.
+func (c *RefCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ref, seen := range c.refs {
+		if c.now().Sub(seen) >= c.ttl {
+			delete(c.refs, ref)
+		}
+	}
+
+	content, err := json.MarshalIndent(c.refs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.filename), 0o755)
+	if err != nil {
+		return fmt.Errorf("cannot write registry cache: %w", err)
+	}
+
+	err = os.WriteFile(c.filename, content, 0o644) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("cannot write registry cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package registry_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefCache(t *testing.T) {
+	t.Parallel()
+
+	filename := path.Join(t.TempDir(), "cache", "registry.json")
+
+	cache, err := registry.LoadRefCache(filename, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, cache.Exists("registry.example.org/app:hash"))
+
+	cache.Add("registry.example.org/app:hash")
+	assert.True(t, cache.Exists("registry.example.org/app:hash"))
+	require.NoError(t, cache.Save())
+
+	cache, err = registry.LoadRefCache(filename, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, cache.Exists("registry.example.org/app:hash"))
+	assert.False(t, cache.Exists("registry.example.org/app:other"))
+
+	// Expired refs are not trusted, and not saved.
+	cache, err = registry.LoadRefCache(filename, 0)
+	require.NoError(t, err)
+	assert.False(t, cache.Exists("registry.example.org/app:hash"))
+	require.NoError(t, cache.Save())
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, "{}", string(content))
+}
+
+func TestLoadRefCache_Invalid(t *testing.T) {
+	t.Parallel()
+
+	filename := path.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(filename, []byte("invalid"), 0o600))
+
+	_, err := registry.LoadRefCache(filename, time.Hour)
+	require.ErrorContains(t, err, "invalid registry cache")
+}
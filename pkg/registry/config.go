@@ -0,0 +1,15 @@
+package registry
+
+// Config holds the configuration of the requests made to the registry to find out which images already exist.
+type Config struct {
+	// Concurrency is the maximum number of concurrent requests made to the registry. 0 means no limit.
+	Concurrency int `mapstructure:"concurrency"`
+	// MaxRetries is the number of times a request rate limited by the registry (HTTP 429) is retried, waiting
+	// twice as long before each retry.
+	MaxRetries int `mapstructure:"max_retries"`
+	// CacheFile is the path of a file where the refs known to exist are stored, so they are not checked again by
+	// the next runs. The cache is disabled when empty.
+	CacheFile string `mapstructure:"cache_file"`
+	// CacheTTL is the number of seconds a ref is kept in the cache.
+	CacheTTL int `mapstructure:"cache_ttl"`
+}
@@ -1,6 +1,16 @@
 package registry
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/radiofrance/dib/pkg/logger"
 	registry "github.com/radiofrance/go-containerregistry"
 )
@@ -26,6 +36,27 @@ func (r Registry) RefExists(imageRef string) (bool, error) {
 	return r.gcr.RefExists(imageRef)
 }
 
+// Tags returns all the tags of the repository, using the credentials from the docker config file.
+// A repository which does not exist yet has no tags.
+func (r Registry) Tags(repository string) ([]string, error) {
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository %q: %w", repository, err)
+	}
+
+	tags, err := remote.List(repo, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("cannot list tags of %q: %w", repository, err)
+	}
+
+	return tags, nil
+}
+
 // Tag creates a new tag from an existing one.
 func (r Registry) Tag(existingRef, toCreateRef string) error {
 	if r.dryRun {
@@ -37,3 +68,31 @@ func (r Registry) Tag(existingRef, toCreateRef string) error {
 
 	return r.gcr.Retag(existingRef, toCreateRef)
 }
+
+// Copy copies the image the source ref points to, to the destination ref, which may be in another registry.
+func (r Registry) Copy(srcRef, destRef string) error {
+	if r.dryRun {
+		logger.Infof("[DRY-RUN] Copying image from \"%s\" to \"%s\"", srcRef, destRef)
+		return nil
+	}
+
+	logger.Debugf("Copying image, source %s, dest %s", srcRef, destRef)
+
+	return NewUpstream(context.Background()).Copy(srcRef, destRef)
+}
+
+// IsRateLimited returns true if the error is caused by the registry rate limiting the requests (HTTP 429).
+func IsRateLimited(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		return terr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// Some registry clients do not expose the status code, but keep the error returned by the registry.
+	return strings.Contains(err.Error(), "TOOMANYREQUESTS") ||
+		strings.Contains(err.Error(), http.StatusText(http.StatusTooManyRequests))
+}
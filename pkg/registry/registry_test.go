@@ -0,0 +1,62 @@
+package registry_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRateLimited(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "no error"},
+		{
+			name:     "transport error",
+			err:      fmt.Errorf("cannot list tags: %w", &transport.Error{StatusCode: http.StatusTooManyRequests}),
+			expected: true,
+		},
+		{name: "other transport error", err: &transport.Error{StatusCode: http.StatusUnauthorized}},
+		{name: "docker hub error", err: errors.New("TOOMANYREQUESTS: You have reached your pull rate limit"), expected: true},
+		{name: "status text", err: errors.New("unexpected status: 429 Too Many Requests"), expected: true},
+		{name: "other error", err: errors.New("manifest unknown")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, registry.IsRateLimited(test.err))
+		})
+	}
+}
+
+func TestRegistry_Tags(t *testing.T) {
+	t.Parallel()
+
+	host := mock.NewRegistryServer(t)
+	mock.PushRandomImage(t, host+"/app:hash")
+
+	dockerRegistry, err := registry.NewRegistry(host, false)
+	require.NoError(t, err)
+
+	tags, err := dockerRegistry.Tags(host + "/app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hash"}, tags)
+
+	// A repository which does not exist yet has no tags.
+	tags, err = dockerRegistry.Tags(host + "/missing")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Upstream queries any registry, using the credentials from the docker config file if available.
+type Upstream struct {
+	opts []remote.Option
+}
+
+// NewUpstream creates a new instance of Upstream.
+func NewUpstream(ctx context.Context, opts ...remote.Option) *Upstream {
+	return &Upstream{
+		opts: append([]remote.Option{
+			remote.WithContext(ctx),
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		}, opts...),
+	}
+}
+
+// Digest returns the digest of the manifest the image ref points to.
+func (u Upstream) Digest(imageRef string) (string, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	desc, err := remote.Head(ref, u.opts...)
+	if err != nil {
+		return "", fmt.Errorf("cannot get digest of %q: %w", imageRef, err)
+	}
+
+	return desc.Digest.String(), nil
+}
+
+// Tags returns all the tags of the repository.
+func (u Upstream) Tags(repository string) ([]string, error) {
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository %q: %w", repository, err)
+	}
+
+	tags, err := remote.List(repo, u.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list tags of %q: %w", repository, err)
+	}
+
+	return tags, nil
+}
+
+// Created returns the creation date of the image the ref points to. For an image index, the creation date of
+// its first image is returned.
+func (u Upstream) Created(imageRef string) (time.Time, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	desc, err := remote.Get(ref, u.opts...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot get manifest of %q: %w", imageRef, err)
+	}
+
+	var img v1.Image
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if len(manifest.Manifests) == 0 {
+			return time.Time{}, fmt.Errorf("image index %q is empty", imageRef)
+		}
+
+		img, err = index.Image(manifest.Manifests[0].Digest)
+		if err != nil {
+			return time.Time{}, err
+		}
+	} else {
+		img, err = desc.Image()
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot get config of %q: %w", imageRef, err)
+	}
+
+	return config.Created.Time, nil
+}
+
+// Copy copies the manifest the source ref points to, along with its blobs, to the destination ref.
+// Image indexes are copied with all their images, so multi-platform images are preserved.
+func (u Upstream) Copy(srcRef, destRef string) error {
+	src, err := name.ParseReference(srcRef)
+	if err != nil {
+		return fmt.Errorf("invalid image ref %q: %w", srcRef, err)
+	}
+
+	dest, err := name.ParseReference(destRef)
+	if err != nil {
+		return fmt.Errorf("invalid image ref %q: %w", destRef, err)
+	}
+
+	desc, err := remote.Get(src, u.opts...)
+	if err != nil {
+		return fmt.Errorf("cannot get manifest of %q: %w", srcRef, err)
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+
+		err = remote.WriteIndex(dest, index, u.opts...)
+		if err != nil {
+			return fmt.Errorf("cannot copy %q to %q: %w", srcRef, destRef, err)
+		}
+
+		return nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return err
+	}
+
+	err = remote.Write(dest, img, u.opts...)
+	if err != nil {
+		return fmt.Errorf("cannot copy %q to %q: %w", srcRef, destRef, err)
+	}
+
+	return nil
+}
+
+// Delete deletes the manifest the ref points to. When the ref is a digest, all the tags pointing to the
+// manifest are deleted along with it.
+func (u Upstream) Delete(imageRef string) error {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	err = remote.Delete(ref, u.opts...)
+	if err != nil {
+		return fmt.Errorf("cannot delete %q: %w", imageRef, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstream(t *testing.T) {
+	t.Parallel()
+
+	host := mock.NewRegistryServer(t)
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	for _, tag := range []string{"3.17", "3.18"} {
+		ref, err := name.ParseReference(host + "/alpine:" + tag)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, img))
+	}
+
+	expectedDigest, err := img.Digest()
+	require.NoError(t, err)
+
+	upstream := registry.NewUpstream(t.Context())
+
+	digest, err := upstream.Digest(host + "/alpine:3.17")
+	require.NoError(t, err)
+	assert.Equal(t, expectedDigest.String(), digest)
+
+	tags, err := upstream.Tags(host + "/alpine")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"3.17", "3.18"}, tags)
+
+	_, err = upstream.Digest(host + "/alpine:3.19")
+	require.ErrorContains(t, err, "cannot get digest of")
+
+	_, err = upstream.Tags("INVALID")
+	require.ErrorContains(t, err, "invalid repository")
+
+	configFile, err := img.ConfigFile()
+	require.NoError(t, err)
+
+	created, err := upstream.Created(host + "/alpine:3.17")
+	require.NoError(t, err)
+	assert.True(t, configFile.Created.Time.Equal(created))
+
+	require.NoError(t, upstream.Delete(host+"/alpine@"+expectedDigest.String()))
+
+	_, err = upstream.Digest(host + "/alpine@" + expectedDigest.String())
+	require.ErrorContains(t, err, "cannot get digest of")
+}
+
+func TestUpstream_Copy(t *testing.T) {
+	t.Parallel()
+
+	srcHost := mock.NewRegistryServer(t)
+	destHost := mock.NewRegistryServer(t)
+
+	img, err := random.Image(64, 2)
+	require.NoError(t, err)
+
+	index, err := random.Index(64, 1, 2)
+	require.NoError(t, err)
+
+	imageRef, err := name.ParseReference(srcHost + "/app:image")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(imageRef, img))
+
+	indexRef, err := name.ParseReference(srcHost + "/app:index")
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(indexRef, index))
+
+	imageDigest, err := img.Digest()
+	require.NoError(t, err)
+
+	indexDigest, err := index.Digest()
+	require.NoError(t, err)
+
+	upstream := registry.NewUpstream(t.Context())
+
+	for tag, expectedDigest := range map[string]string{"image": imageDigest.String(), "index": indexDigest.String()} {
+		require.NoError(t, upstream.Copy(srcHost+"/app:"+tag, destHost+"/app:"+tag))
+
+		digest, err := upstream.Digest(destHost + "/app:" + tag)
+		require.NoError(t, err)
+		assert.Equal(t, expectedDigest, digest)
+	}
+
+	err = upstream.Copy(srcHost+"/app:missing", destHost+"/app:missing")
+	require.ErrorContains(t, err, "cannot get manifest of")
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/logger"
+)
+
+// jsonReportFile is the name of the machine-readable report file, written in the report root directory.
+const jsonReportFile = "report.json"
+
+type jsonReport struct {
+	Version        string            `json:"version"`
+	GenerationDate time.Time         `json:"generation_date"`
+	Images         []jsonBuildReport `json:"images"`
+	Signatures     []jsonSignature   `json:"signatures,omitempty"`
+}
+
+type jsonSignature struct {
+	Image     string `json:"image"`
+	Signature string `json:"signature"`
+}
+
+type jsonBuildReport struct {
+	Name           string    `json:"name"`
+	ShortName      string    `json:"short_name"`
+	Hash           string    `json:"hash"`
+	BuildStatus    string    `json:"build_status"`
+	TestsStatus    string    `json:"tests_status"`
+	FailureMessage string    `json:"failure_message,omitempty"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	QueueSeconds   float64   `json:"queue_seconds"`
+	BuildSeconds   float64   `json:"build_seconds"`
+	PushSeconds    *float64  `json:"push_seconds,omitempty"`
+	TestSeconds    float64   `json:"test_seconds"`
+	TotalSeconds   float64   `json:"total_seconds"`
+}
+
+// RecordSignatures adds the refs of the image signatures to the report, and updates the JSON report.
+// Signatures are made after the report is generated, once the images have their final tags. When no image was built,
+// e.g. on a release only retagging the images, the JSON report is written with the signatures only.
+func RecordSignatures(dibReport *Report, signatures []Signature) error {
+	dibReport.Signatures = signatures
+
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	for _, signature := range signatures {
+		logger.Infof("Signed \"%s\": \"%s\"", signature.Image, signature.Signature)
+	}
+
+	err := os.MkdirAll(dibReport.GetRootDir(), 0o750)
+	if err != nil {
+		return fmt.Errorf("unable to create report folder: %w", err)
+	}
+
+	err = writeJSONReport(dibReport)
+	if err != nil {
+		return fmt.Errorf("unable to write JSON report: %w", err)
+	}
+
+	return nil
+}
+
+// writeJSONReport writes the build reports, including timings, as a JSON document in the report folder.
+func writeJSONReport(dibReport *Report) error {
+	data := jsonReport{
+		Version:        dibReport.Options.Version,
+		GenerationDate: dibReport.Options.GenerationDate,
+		Images:         make([]jsonBuildReport, 0, len(dibReport.BuildReports)),
+	}
+
+	for _, buildReport := range dibReport.BuildReports {
+		timings := buildReport.Timings
+
+		data.Images = append(data.Images, jsonBuildReport{
+			Name:           buildReport.Image.Name,
+			ShortName:      buildReport.Image.ShortName,
+			Hash:           buildReport.Image.Hash,
+			BuildStatus:    buildReport.BuildStatus.String(),
+			TestsStatus:    buildReport.TestsStatus.String(),
+			FailureMessage: buildReport.FailureMessage,
+			StartTime:      timings.StartTime,
+			EndTime:        timings.EndTime,
+			QueueSeconds:   timings.QueueDuration.Seconds(),
+			BuildSeconds:   timings.BuildDuration.Seconds(),
+			PushSeconds:    pushSeconds(timings),
+			TestSeconds:    timings.TestDuration.Seconds(),
+			TotalSeconds:   timings.Duration().Seconds(),
+		})
+	}
+
+	for _, signature := range dibReport.Signatures {
+		data.Signatures = append(data.Signatures, jsonSignature(signature))
+	}
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path.Join(dibReport.GetRootDir(), jsonReportFile), content, 0o644)
+}
+
+// pushSeconds returns the time spent pushing the image in seconds, or nil when it is not measured.
+func pushSeconds(timings Timings) *float64 {
+	if timings.PushDuration == nil {
+		return nil
+	}
+
+	seconds := timings.PushDuration.Seconds()
+
+	return &seconds
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeJSONReport_Timings(t *testing.T) {
+	t.Parallel()
+
+	startTime := time.Date(2022, 8, 23, 18, 30, 0, 0, time.UTC)
+	pushDuration := 2 * time.Second
+	dibReport := &Report{
+		Options: Options{
+			RootDir: t.TempDir(),
+			Name:    "report",
+		},
+		BuildReports: []BuildReport{
+			{
+				Image: dag.Image{Name: "registry.example.org/measured", ShortName: "measured"},
+				Timings: Timings{
+					StartTime:     startTime,
+					EndTime:       startTime.Add(40 * time.Second),
+					QueueDuration: 10 * time.Second,
+					BuildDuration: 20 * time.Second,
+					PushDuration:  &pushDuration,
+					TestDuration:  8 * time.Second,
+				},
+			},
+			{
+				// Builders pushing during the build do not measure the push.
+				Image: dag.Image{Name: "registry.example.org/unmeasured", ShortName: "unmeasured"},
+			},
+		},
+	}
+
+	require.NoError(t, os.MkdirAll(dibReport.GetRootDir(), 0o755))
+	require.NoError(t, writeJSONReport(dibReport))
+
+	content, err := os.ReadFile(path.Join(dibReport.GetRootDir(), jsonReportFile))
+	require.NoError(t, err)
+
+	var data struct {
+		Images []map[string]any `json:"images"`
+	}
+	require.NoError(t, json.Unmarshal(content, &data))
+	require.Len(t, data.Images, 2)
+
+	measured := data.Images[0]
+	assert.InDelta(t, 10.0, measured["queue_seconds"], 0)
+	assert.InDelta(t, 20.0, measured["build_seconds"], 0)
+	assert.InDelta(t, 2.0, measured["push_seconds"], 0)
+	assert.InDelta(t, 8.0, measured["test_seconds"], 0)
+	assert.InDelta(t, 40.0, measured["total_seconds"], 0)
+	assert.NotContains(t, data.Images[1], "push_seconds")
+}
@@ -0,0 +1,193 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/graphviz"
+	"github.com/radiofrance/dib/pkg/lint"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/trivy"
+)
+
+// githubStepSummaryEnv is the environment variable set by GitHub Actions, pointing to the job summary file.
+const githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+// junitReports lists the JUnit reports the test runners may write for an image, in the order they are linked.
+var junitReports = []struct {
+	name string
+	path func(junitDir, imageName string) string
+}{
+	{name: "goss", path: gossJunitReportPath},
+	{name: "trivy", path: trivy.JunitReportPath},
+	{name: "structure-test", path: structuretest.JunitReportPath},
+	{name: "lint", path: lint.JunitReportPath},
+}
+
+// GenerateMarkdownSummary writes a compact Markdown summary of the Report to the given file.
+// When no file is given, the summary is appended to the GitHub Actions job summary if available,
+// otherwise nothing is written.
+func GenerateMarkdownSummary(dibReport *Report, graph *dag.DAG, filePath string) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+	if filePath == "" {
+		filePath = os.Getenv(githubStepSummaryEnv)
+		if filePath == "" {
+			return nil
+		}
+
+		// The job summary may already contain content from previous steps.
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(filePath, flags, 0o644) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("unable to open markdown summary file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, err = file.WriteString(RenderMarkdown(dibReport, graph))
+	if err != nil {
+		return fmt.Errorf("unable to write markdown summary: %w", err)
+	}
+
+	logger.Infof("Generated markdown summary: \"%s\"", filePath)
+
+	return nil
+}
+
+// RenderMarkdown renders the Report as a Markdown document, suitable for merge-request comments
+// or CI job summaries. It contains the graph of the images processed during the build,
+// a table with the status of each image, and links to the HTML report.
+func RenderMarkdown(dibReport *Report, graph *dag.DAG) string {
+	var md strings.Builder
+
+	md.WriteString("## dib build report\n\n")
+
+	buildReports := sortBuildReport(dibReport.BuildReports)
+	if len(buildReports) == 0 {
+		md.WriteString("All images are up-to-date, nothing to build.\n")
+		return md.String()
+	}
+
+	fmt.Fprintf(&md, "%s\n\n", summaryLine(buildReports))
+
+	if graph != nil {
+		md.WriteString("```mermaid\n")
+		md.WriteString(graphviz.RenderMermaid(graph, graphStatuses(buildReports)))
+		md.WriteString("```\n\n")
+	}
+
+	md.WriteString("| Image | Hash | Build | Tests | Duration | Logs |\n")
+	md.WriteString("|-------|------|-------|-------|----------|------|\n")
+
+	for _, buildReport := range buildReports {
+		shortName := buildReport.Image.ShortName
+
+		links := []string{
+			fmt.Sprintf("[build](%s#heading-image-%s)", dibReport.getFileURL("build.html"), sanitize(shortName)),
+		}
+		links = append(links, junitLinks(dibReport, shortName)...)
+
+		fmt.Fprintf(&md, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+			shortName,
+			buildReport.Image.Hash,
+			markdownBuildStatus(buildReport.BuildStatus),
+			markdownTestsStatus(buildReport.TestsStatus),
+			buildReport.Timings.Duration().Round(time.Second),
+			strings.Join(links, " · "),
+		)
+	}
+
+	failures := []BuildReport{}
+
+	for _, buildReport := range buildReports {
+		if buildReport.FailureMessage != "" {
+			failures = append(failures, buildReport)
+		}
+	}
+
+	if len(failures) > 0 {
+		md.WriteString("\n<details><summary>Failures</summary>\n\n")
+
+		for _, buildReport := range failures {
+			fmt.Fprintf(&md, "- `%s`: %s\n", buildReport.Image.ShortName,
+				strings.ReplaceAll(buildReport.FailureMessage, "\n", " "))
+		}
+
+		md.WriteString("\n</details>\n")
+	}
+
+	fmt.Fprintf(&md, "\n[Full HTML report](%s)\n", dibReport.GetURL())
+
+	return md.String()
+}
+
+// junitLinks returns the links to the JUnit reports written for the image by the test runners.
+func junitLinks(dibReport *Report, shortName string) []string {
+	var links []string
+
+	for _, junitReport := range junitReports {
+		_, err := os.Stat(junitReport.path(dibReport.GetJunitReportDir(), shortName))
+		if err != nil {
+			continue
+		}
+
+		links = append(links, fmt.Sprintf("[%s](%s)",
+			junitReport.name, dibReport.getFileURL(junitReport.path(JunitReportDir, shortName))))
+	}
+
+	return links
+}
+
+// summaryLine counts images by status and returns a single line describing the build outcome.
+func summaryLine(buildReports []BuildReport) string {
+	var built, failed, skipped, testsFailed int
+
+	for _, buildReport := range buildReports {
+		switch buildReport.BuildStatus {
+		case BuildStatusSuccess:
+			built++
+		case BuildStatusError:
+			failed++
+		case BuildStatusSkipped:
+			skipped++
+		}
+
+		if buildReport.TestsStatus == TestsStatusFailed {
+			testsFailed++
+		}
+	}
+
+	return fmt.Sprintf("**%d** image(s) processed: %d built, %d failed, %d skipped, %d with failing tests.",
+		len(buildReports), built, failed, skipped, testsFailed)
+}
+
+func markdownBuildStatus(status BuildStatus) string {
+	switch status {
+	case BuildStatusSuccess:
+		return ":white_check_mark: Success"
+	case BuildStatusError:
+		return ":x: Error"
+	default:
+		return ":heavy_minus_sign: Skipped"
+	}
+}
+
+func markdownTestsStatus(status TestsStatus) string {
+	switch status {
+	case TestsStatusPassed:
+		return ":white_check_mark: Passed"
+	case TestsStatusFailed:
+		return ":x: Failed"
+	default:
+		return ":heavy_minus_sign: Skipped"
+	}
+}
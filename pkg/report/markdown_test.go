@@ -0,0 +1,129 @@
+package report_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/lint"
+	"github.com/radiofrance/dib/pkg/report"
+	"github.com/radiofrance/dib/pkg/trivy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMarkdownTestData() (*report.Report, *dag.DAG) {
+	parent := dag.NewNode(&dag.Image{Name: "registry/parent", ShortName: "parent", Hash: "parent-hash"})
+	child := dag.NewNode(&dag.Image{Name: "registry/child", ShortName: "child", Hash: "child-hash"})
+	unchanged := dag.NewNode(&dag.Image{Name: "registry/unchanged", ShortName: "unchanged", Hash: "same-hash"})
+	parent.AddChild(child)
+	parent.AddChild(unchanged)
+
+	graph := &dag.DAG{}
+	graph.AddNode(parent)
+
+	dibReport := &report.Report{
+		Options: report.Options{
+			RootDir: reportsDir,
+			Name:    "20220823183000",
+		},
+		BuildReports: []report.BuildReport{
+			{
+				Image:          *child.Image,
+				BuildStatus:    report.BuildStatusError,
+				TestsStatus:    report.TestsStatusSkipped,
+				FailureMessage: "building image child failed:\nexit status 1",
+			},
+			{
+				Image:       *parent.Image,
+				BuildStatus: report.BuildStatusSuccess,
+				TestsStatus: report.TestsStatusPassed,
+				Timings: report.Timings{
+					StartTime: time.Date(2022, 8, 23, 18, 30, 0, 0, time.UTC),
+					EndTime:   time.Date(2022, 8, 23, 18, 31, 5, 400, time.UTC),
+				},
+			},
+		},
+	}
+
+	return dibReport, graph
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Setenv("CI_JOB_URL", "https://gitlab.com/example-repository/-/jobs/123456")
+
+	dibReport, graph := newMarkdownTestData()
+	dibReport.Options.RootDir = t.TempDir()
+
+	// Only the JUnit reports written by the test runners are linked.
+	junitDir := dibReport.GetJunitReportDir()
+	require.NoError(t, os.MkdirAll(junitDir, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(junitDir, "junit-parent.xml"), []byte("<testsuite/>"), 0o600))
+	require.NoError(t, os.WriteFile(trivy.JunitReportPath(junitDir, "parent"), []byte("<testsuite/>"), 0o600))
+	require.NoError(t, os.WriteFile(lint.JunitReportPath(junitDir, "parent"), []byte("<testsuite/>"), 0o600))
+
+	actual := report.RenderMarkdown(dibReport, graph)
+
+	baseURL := "https://gitlab.com/example-repository/-/jobs/123456/artifacts/file/" +
+		path.Join(dibReport.Options.RootDir, "20220823183000")
+
+	assert.Contains(t, actual, "**2** image(s) processed: 1 built, 1 failed, 0 skipped, 0 with failing tests.")
+	assert.Contains(t, actual, "  classDef built fill:#d4edda,stroke:#28a745\n")
+	assert.Contains(t, actual, "  n0[\"child\"]:::build_failed\n")
+	assert.Contains(t, actual, "  n1[\"parent\"]:::built\n")
+	assert.Contains(t, actual, "  n1 --> n0\n")
+	assert.NotContains(t, actual, "unchanged")
+	assert.Contains(t, actual,
+		"| `child` | `child-hash` | :x: Error | :heavy_minus_sign: Skipped | 0s | ["+
+			"build]("+baseURL+"/build.html#heading-image-child) |\n")
+	assert.Contains(t, actual,
+		"| `parent` | `parent-hash` | :white_check_mark: Success | :white_check_mark: Passed | 1m5s | "+
+			"[build]("+baseURL+"/build.html#heading-image-parent) · [goss]("+baseURL+"/junit/junit-parent.xml) · "+
+			"[trivy]("+baseURL+"/junit/junit-trivy-parent.xml) · [lint]("+baseURL+"/junit/junit-lint-parent.xml) |\n")
+	assert.Contains(t, actual, "- `child`: building image child failed: exit status 1\n")
+	assert.Contains(t, actual, "[Full HTML report]("+baseURL+"/index.html)")
+}
+
+func TestRenderMarkdown_NothingToBuild(t *testing.T) {
+	t.Parallel()
+
+	actual := report.RenderMarkdown(&report.Report{}, &dag.DAG{})
+
+	assert.Equal(t, "## dib build report\n\nAll images are up-to-date, nothing to build.\n", actual)
+}
+
+func TestGenerateMarkdownSummary_File(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+
+	dibReport, graph := newMarkdownTestData()
+	summaryFile := path.Join(t.TempDir(), "summary.md")
+	require.NoError(t, os.WriteFile(summaryFile, []byte("previous content"), 0o600))
+
+	require.NoError(t, report.GenerateMarkdownSummary(dibReport, graph, summaryFile))
+
+	content, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	assert.Equal(t, report.RenderMarkdown(dibReport, graph), string(content))
+}
+
+func TestGenerateMarkdownSummary_GitHubStepSummary(t *testing.T) {
+	summaryFile := path.Join(t.TempDir(), "step_summary.md")
+	require.NoError(t, os.WriteFile(summaryFile, []byte("previous step\n"), 0o600))
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+
+	dibReport, graph := newMarkdownTestData()
+
+	require.NoError(t, report.GenerateMarkdownSummary(dibReport, graph, ""))
+
+	content, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	assert.Equal(t, "previous step\n"+report.RenderMarkdown(dibReport, graph), string(content))
+}
+
+func TestGenerateMarkdownSummary_Disabled(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+
+	require.NoError(t, report.GenerateMarkdownSummary(&report.Report{}, &dag.DAG{}, ""))
+}
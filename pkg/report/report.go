@@ -31,16 +31,25 @@ type (
 type Report struct {
 	Options      Options
 	BuildReports []BuildReport
+	Signatures   []Signature
 }
 
 type Options struct {
-	RootDir        string
-	Name           string
-	GenerationDate time.Time
-	Version        string
-	BuildOpts      string
-	WithGraph      bool
-	WithGoss       bool
+	RootDir           string
+	Name              string
+	GenerationDate    time.Time
+	Version           string
+	BuildOpts         string
+	WithGraph         bool
+	WithGoss          bool
+	WithTrivy         bool
+	WithStructureTest bool
+	WithLint          bool
+}
+
+// WithTests returns true if any test runner is enabled, so the report has a test page.
+func (o Options) WithTests() bool {
+	return o.WithGoss || o.WithTrivy || o.WithStructureTest || o.WithLint
 }
 
 // BuildReport holds the status of the build/tests.
@@ -49,6 +58,35 @@ type BuildReport struct {
 	BuildStatus    BuildStatus
 	TestsStatus    TestsStatus
 	FailureMessage string
+	Timings        Timings
+}
+
+// Signature holds the ref of the signature of an image, pushed to the registry.
+type Signature struct {
+	Image     string
+	Signature string
+}
+
+// Timings holds the start/end times of an image processing, and the time spent in each step.
+type Timings struct {
+	StartTime time.Time
+	EndTime   time.Time
+	// Time spent waiting for the rate limiter before the build could start.
+	QueueDuration time.Duration
+	// Time spent building the image, excluding the push when the builder reports it separately.
+	BuildDuration time.Duration
+	// Time spent pushing the image, nil when it is not measured, e.g. for builders pushing during the build.
+	PushDuration *time.Duration
+	TestDuration time.Duration
+}
+
+// Duration returns the total time spent processing the image.
+func (t Timings) Duration() time.Duration {
+	if t.StartTime.IsZero() || t.EndTime.Before(t.StartTime) {
+		return 0
+	}
+
+	return t.EndTime.Sub(t.StartTime)
 }
 
 // GetRootDir return the path of the Report "root" directory.
@@ -61,6 +99,11 @@ func (r Report) GetBuildReportDir() string {
 	return path.Join(r.GetRootDir(), BuildReportDir)
 }
 
+// GetSBOMReportDir return the path of the Report "SBOM" directory.
+func (r Report) GetSBOMReportDir() string {
+	return path.Join(r.GetRootDir(), SBOMReportDir)
+}
+
 // GetJunitReportDir return the path of the Report "Junit reports" directory.
 func (r Report) GetJunitReportDir() string {
 	return path.Join(r.GetRootDir(), JunitReportDir)
@@ -68,19 +111,25 @@ func (r Report) GetJunitReportDir() string {
 
 // GetURL return a string representing the path from which we can browse Report.
 func (r Report) GetURL() string {
+	return r.getFileURL("index.html")
+}
+
+// getFileURL return a string representing the path from which we can browse a file of the Report,
+// relative to the Report "root" directory.
+func (r Report) getFileURL(name string) string {
 	// GitLab context
 	gitlabJobURL := os.Getenv("CI_JOB_URL")
 	if gitlabJobURL != "" {
-		return fmt.Sprintf("%s/artifacts/file/%s/index.html", gitlabJobURL, r.GetRootDir())
+		return fmt.Sprintf("%s/artifacts/file/%s/%s", gitlabJobURL, r.GetRootDir(), name)
 	}
 
 	// Local context
 	finalReportURL, err := filepath.Abs(r.GetRootDir())
 	if err != nil {
-		return r.GetRootDir()
+		return path.Join(r.GetRootDir(), name)
 	}
 
-	return fmt.Sprintf("file://%s/index.html", finalReportURL)
+	return fmt.Sprintf("file://%s/%s", finalReportURL, name)
 }
 
 // Print display Report.BuildReports to the user.
@@ -127,6 +176,34 @@ func (r Report) CheckError() error {
 	return nil
 }
 
+// String returns a human-readable representation of the BuildStatus.
+func (s BuildStatus) String() string {
+	switch s {
+	case BuildStatusSkipped:
+		return "skipped"
+	case BuildStatusSuccess:
+		return "success"
+	case BuildStatusError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// String returns a human-readable representation of the TestsStatus.
+func (s TestsStatus) String() string {
+	switch s {
+	case TestsStatusSkipped:
+		return "skipped"
+	case TestsStatusPassed:
+		return "passed"
+	case TestsStatusFailed:
+		return "failed"
+	}
+
+	return "unknown"
+}
+
 // WithError returns a BuildReport.
 func (r BuildReport) WithError(err error) BuildReport {
 	r.BuildStatus = BuildStatusError
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/logger"
 	"github.com/radiofrance/dib/pkg/report"
 	"github.com/stretchr/testify/assert"
@@ -226,3 +227,65 @@ func TestReport_WithError(t *testing.T) {
 	actual := buildReport.WithError(err)
 	assert.Equal(t, expected, actual)
 }
+
+func TestRecordSignatures(t *testing.T) {
+	t.Parallel()
+
+	dibReport := &report.Report{
+		Options: report.Options{
+			RootDir: t.TempDir(),
+			Name:    "report",
+			Version: "v1.0.0",
+		},
+		BuildReports: []report.BuildReport{
+			{Image: dag.Image{Name: "registry.example.org/image1", ShortName: "image1"}},
+		},
+	}
+	require.NoError(t, os.MkdirAll(dibReport.GetRootDir(), 0o750))
+
+	signatures := []report.Signature{
+		{
+			Image:     "registry.example.org/image1:alpha-bravo-charlie-delta",
+			Signature: "registry.example.org/image1:sha256-0123456789abcdef.sig",
+		},
+	}
+
+	err := report.RecordSignatures(dibReport, signatures)
+	require.NoError(t, err)
+	assert.Equal(t, signatures, dibReport.Signatures)
+
+	content, err := os.ReadFile(path.Join(dibReport.GetRootDir(), "report.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"signatures": [
+    {
+      "image": "registry.example.org/image1:alpha-bravo-charlie-delta",
+      "signature": "registry.example.org/image1:sha256-0123456789abcdef.sig"
+    }
+  ]`)
+}
+
+func TestRecordSignatures_WithoutBuildReports(t *testing.T) {
+	t.Parallel()
+
+	dibReport := &report.Report{
+		Options: report.Options{
+			RootDir: t.TempDir(),
+			Name:    "report",
+			Version: "v1.0.0",
+		},
+	}
+
+	signatures := []report.Signature{
+		{
+			Image:     "registry.example.org/image1:alpha-bravo-charlie-delta",
+			Signature: "registry.example.org/image1:sha256-0123456789abcdef.sig",
+		},
+	}
+
+	err := report.RecordSignatures(dibReport, signatures)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path.Join(dibReport.GetRootDir(), "report.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"signature": "registry.example.org/image1:sha256-0123456789abcdef.sig"`)
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/graphviz"
 	"github.com/radiofrance/dib/pkg/junit"
+	"github.com/radiofrance/dib/pkg/lint"
 	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/trivy"
 	"github.com/radiofrance/dib/pkg/types"
 )
 
@@ -21,7 +24,7 @@ const (
 	templatesDir = "templates"
 
 	statusSkipped       = 0
-	testSkippedWording  = "Goss tests skipped because the docker image failed to build"
+	testSkippedWording  = "Tests skipped because the docker image failed to build"
 	buildSkippedWording = "Build skipped because a parent image failed to build"
 )
 
@@ -45,13 +48,16 @@ func Init(
 	return &Report{
 		BuildReports: []BuildReport{},
 		Options: Options{
-			RootDir:        rootDir,
-			Name:           generationDate.Format("20060102150405"),
-			GenerationDate: generationDate,
-			Version:        fmt.Sprintf("v%s", version),
-			BuildOpts:      buildOpts,
-			WithGraph:      !disableGenerateGraph,
-			WithGoss:       isTestRunnerEnabled(types.TestRunnerGoss, testRunners),
+			RootDir:           rootDir,
+			Name:              generationDate.Format("20060102150405"),
+			GenerationDate:    generationDate,
+			Version:           fmt.Sprintf("v%s", version),
+			BuildOpts:         buildOpts,
+			WithGraph:         !disableGenerateGraph,
+			WithGoss:          isTestRunnerEnabled(types.TestRunnerGoss, testRunners),
+			WithTrivy:         isTestRunnerEnabled(types.TestRunnerTrivy, testRunners),
+			WithStructureTest: isTestRunnerEnabled(types.TestRunnerStructureTest, testRunners),
+			WithLint:          isTestRunnerEnabled(types.TestRunnerLint, testRunners),
 		},
 	}
 }
@@ -69,9 +75,14 @@ func Generate(dibReport *Report, dag *dag.DAG) error {
 		return fmt.Errorf("unable to create report folder: %w", err)
 	}
 
-	err = graphviz.GenerateGraph(dag, dibReport.GetRootDir())
-	if err != nil {
-		return fmt.Errorf("unable to generate graph: %w", err)
+	if dibReport.Options.WithGraph {
+		err = graphviz.GenerateGraph(dag, dibReport.GetRootDir(), graphStatuses(dibReport.BuildReports))
+		if err != nil {
+			// The graph is not essential to the report, so we skip its page instead of failing.
+			logger.Warnf("Unable to generate graph, it will not be part of the report: %v", err)
+
+			dibReport.Options.WithGraph = false
+		}
 	}
 
 	err = copyAssetsFiles(dibReport)
@@ -84,6 +95,11 @@ func Generate(dibReport *Report, dag *dag.DAG) error {
 		return fmt.Errorf("unable to render report templates: %w", err)
 	}
 
+	err = writeJSONReport(dibReport)
+	if err != nil {
+		return fmt.Errorf("unable to write JSON report: %w", err)
+	}
+
 	logger.Infof("Generated HTML report: \"%s\"", dibReport.GetURL())
 
 	return nil
@@ -130,6 +146,12 @@ func renderTemplates(dibReport *Report, dag *dag.DAG) error {
 		return err
 	}
 
+	// Generate timeline.html
+	err = dibReport.renderTemplate("timeline", dibReport.Options, timelineData(dibReport.BuildReports))
+	if err != nil {
+		return err
+	}
+
 	// Generate debug.html
 	err = dibReport.renderTemplate("debug", dibReport.Options, dag.ListImage())
 	if err != nil {
@@ -138,17 +160,42 @@ func renderTemplates(dibReport *Report, dag *dag.DAG) error {
 
 	// Generate graph.html
 	if dibReport.Options.WithGraph {
-		err := dibReport.renderTemplate("graph", dibReport.Options, nil)
+		err := dibReport.renderTemplate("graph", dibReport.Options, graphviz.AllNodeStatuses())
 		if err != nil {
 			return err
 		}
 	}
 
 	// Generate test.html
-	if dibReport.Options.WithGoss {
-		gossLogsData := parseGossLogs(dibReport)
+	if dibReport.Options.WithTests() {
+		testData := map[string]any{}
+
+		if dibReport.Options.WithGoss {
+			testData["Goss"] = junitLogs{
+				ID:     "image",
+				Suites: parseJunitLogs(dibReport, gossJunitReportPath),
+			}
+		}
+
+		if dibReport.Options.WithLint {
+			testData["Lint"] = junitLogs{
+				ID:     "lint",
+				Suites: parseJunitLogs(dibReport, lint.JunitReportPath),
+			}
+		}
+
+		if dibReport.Options.WithStructureTest {
+			testData["StructureTest"] = junitLogs{
+				ID:     "structure-test",
+				Suites: parseJunitLogs(dibReport, structuretest.JunitReportPath),
+			}
+		}
+
+		if dibReport.Options.WithTrivy {
+			testData["Trivy"] = parseTrivyReports(dibReport)
+		}
 
-		err := dibReport.renderTemplate("test", dibReport.Options, gossLogsData)
+		err := dibReport.renderTemplate("test", dibReport.Options, testData)
 		if err != nil {
 			return err
 		}
@@ -157,6 +204,33 @@ func renderTemplates(dibReport *Report, dag *dag.DAG) error {
 	return nil
 }
 
+// graphStatuses returns the status of each image processed during the build, used to colour the graph.
+func graphStatuses(buildReports []BuildReport) map[string]graphviz.NodeStatus {
+	statuses := make(map[string]graphviz.NodeStatus, len(buildReports))
+
+	for _, buildReport := range buildReports {
+		var status graphviz.NodeStatus
+
+		switch {
+		case buildReport.BuildStatus == BuildStatusError:
+			status = graphviz.NodeStatusBuildFailed
+		case buildReport.TestsStatus == TestsStatusFailed:
+			status = graphviz.NodeStatusTestsFailed
+		case buildReport.BuildStatus == BuildStatusSuccess:
+			status = graphviz.NodeStatusBuilt
+		case buildReport.TestsStatus == TestsStatusSkipped:
+			status = graphviz.NodeStatusBuildSkipped
+		default:
+			// The image did not need to be rebuilt, but its tests were run.
+			status = graphviz.NodeStatusUpToDate
+		}
+
+		statuses[buildReport.Image.Name] = status
+	}
+
+	return statuses
+}
+
 // parseBuildLogs iterate over built Dockerfiles and read their respective build logs file.
 // Then, it put in a map that will be used later in Go template.
 func parseBuildLogs(dibReport *Report) map[string]string {
@@ -180,33 +254,73 @@ func parseBuildLogs(dibReport *Report) map[string]string {
 	return buildLogsData
 }
 
-// parseGossLogs iterate over each Goss tests (in junit format) and read their respective logs file.
+// junitLogs holds the parsed junit reports of a test runner, rendered as an accordion on the test page.
+type junitLogs struct {
+	// ID prefixes the HTML identifiers of the accordion items.
+	ID     string
+	Suites map[string]any
+}
+
+// gossJunitReportPath returns the path of the junit report written by the goss test runner.
+func gossJunitReportPath(junitDir, imageName string) string {
+	return fmt.Sprintf("%s/junit-%s.xml", junitDir, strings.ReplaceAll(imageName, "/", "_"))
+}
+
+// parseJunitLogs iterate over each tests (in junit format) and read their respective logs file,
+// located using the given reportPath function.
 // Then, it put in a map that will be used later in Go template.
-func parseGossLogs(dibReport *Report) map[string]any {
-	gossTestsLogsData := make(map[string]any)
+func parseJunitLogs(dibReport *Report, reportPath func(junitDir, imageName string) string) map[string]any {
+	testsLogsData := make(map[string]any)
 
 	for _, buildReport := range dibReport.BuildReports {
 		if buildReport.TestsStatus == statusSkipped {
-			gossTestsLogsData[buildReport.Image.ShortName] = testSkippedWording
+			testsLogsData[buildReport.Image.ShortName] = testSkippedWording
+			continue
+		}
+
+		rawTestLogs, err := os.ReadFile(reportPath(dibReport.GetJunitReportDir(), buildReport.Image.ShortName))
+		if err != nil {
+			testsLogsData[buildReport.Image.ShortName] = err.Error()
 			continue
 		}
 
-		gossTestLogsFile := fmt.Sprintf("%s/junit-%s.xml", dibReport.GetJunitReportDir(), strings.ReplaceAll(buildReport.Image.ShortName, "/", "_")) //nolint:lll
+		parsedTestLogs, err := junit.ParseRawLogs(rawTestLogs)
+		if err != nil {
+			testsLogsData[buildReport.Image.ShortName] = err.Error()
+			continue
+		}
+
+		testsLogsData[buildReport.Image.ShortName] = parsedTestLogs
+	}
+
+	return testsLogsData
+}
+
+// parseTrivyReports iterate over each trivy report (in JSON format) and count the vulnerabilities found.
+// Then, it put in a map that will be used later in Go template.
+func parseTrivyReports(dibReport *Report) map[string]any {
+	trivyData := make(map[string]any)
+
+	for _, buildReport := range dibReport.BuildReports {
+		if buildReport.TestsStatus == statusSkipped {
+			trivyData[buildReport.Image.ShortName] = testSkippedWording
+			continue
+		}
 
-		rawGossTestLogs, err := os.ReadFile(gossTestLogsFile) //nolint:gosec
+		rawReport, err := os.ReadFile(trivy.JSONReportPath(dibReport.GetJunitReportDir(), buildReport.Image.ShortName))
 		if err != nil {
-			gossTestsLogsData[buildReport.Image.ShortName] = err.Error()
+			trivyData[buildReport.Image.ShortName] = err.Error()
 			continue
 		}
 
-		parsedDgossTestLogs, err := junit.ParseRawLogs(rawGossTestLogs)
+		trivyReport, err := trivy.ParseReport(rawReport)
 		if err != nil {
-			gossTestsLogsData[buildReport.Image.ShortName] = err.Error()
+			trivyData[buildReport.Image.ShortName] = err.Error()
 			continue
 		}
 
-		gossTestsLogsData[buildReport.Image.ShortName] = parsedDgossTestLogs
+		trivyData[buildReport.Image.ShortName] = trivyReport.Counts()
 	}
 
-	return gossTestsLogsData
+	return trivyData
 }
@@ -1,6 +1,8 @@
 package report_test
 
 import (
+	"os"
+	"path"
 	"regexp"
 	"testing"
 	"time"
@@ -10,9 +12,13 @@ import (
 	"github.com/radiofrance/dib/pkg/dag"
 	"github.com/radiofrance/dib/pkg/dockerfile"
 	"github.com/radiofrance/dib/pkg/goss"
+	"github.com/radiofrance/dib/pkg/lint"
 	"github.com/radiofrance/dib/pkg/report"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/trivy"
 	"github.com/radiofrance/dib/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var reportNameRegex = regexp.MustCompile(`[0-9]{14}`)
@@ -170,6 +176,65 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+func TestGenerate_TestPage(t *testing.T) {
+	t.Parallel()
+
+	dibReport := &report.Report{
+		Options: report.Options{
+			RootDir:           t.TempDir(),
+			Name:              "report",
+			Version:           "v1.0.0",
+			WithGoss:          true,
+			WithTrivy:         true,
+			WithStructureTest: true,
+			WithLint:          true,
+		},
+		BuildReports: []report.BuildReport{
+			{
+				Image:       dag.Image{Name: "image1", ShortName: "image1", Dockerfile: &testDockerfile},
+				BuildStatus: report.BuildStatusSuccess,
+				TestsStatus: report.TestsStatusFailed,
+			},
+		},
+	}
+
+	junitDir := dibReport.GetJunitReportDir()
+	require.NoError(t, os.MkdirAll(junitDir, 0o750))
+	require.NoError(t, os.WriteFile(path.Join(junitDir, "junit-image1.xml"),
+		[]byte(`<testsuite name="goss" tests="1" failures="0"><testcase name="File: /etc/passwd"/></testsuite>`),
+		0o600))
+	require.NoError(t, os.WriteFile(structuretest.JunitReportPath(junitDir, "image1"),
+		[]byte(`<testsuite name="structure-test" tests="1" failures="1">`+
+			`<testcase name="Command Test: whoami"><failure>Expected output not found</failure></testcase>`+
+			`</testsuite>`),
+		0o600))
+	require.NoError(t, os.WriteFile(lint.JunitReportPath(junitDir, "image1"),
+		[]byte(`<testsuite name="lint" tests="1" failures="0">`+
+			`<testcase name="DL3006: Always tag the version of an image explicitly"></testcase></testsuite>`),
+		0o600))
+	require.NoError(t, os.WriteFile(trivy.JSONReportPath(junitDir, "image1"),
+		[]byte(`{"Results": [{"Target": "alpine", "Vulnerabilities": [
+			{"VulnerabilityID": "CVE-1", "Severity": "CRITICAL"},
+			{"VulnerabilityID": "CVE-2", "Severity": "HIGH"},
+			{"VulnerabilityID": "CVE-3", "Severity": "HIGH"}
+		]}]}`), 0o600))
+
+	graph := &dag.DAG{}
+	graph.AddNode(newTestNode(false, false, false))
+
+	require.NoError(t, report.Generate(dibReport, graph))
+
+	content, err := os.ReadFile(path.Join(dibReport.GetRootDir(), "test.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "File: /etc/passwd")
+	assert.Contains(t, string(content), `id="collapse-image-image1"`)
+	assert.Contains(t, string(content), "Expected output not found")
+	assert.Contains(t, string(content), `id="collapse-structure-test-image1"`)
+	assert.Contains(t, string(content), "DL3006: Always tag the version of an image explicitly")
+	assert.Regexp(t, `(?s)id="trivy-image1">.*<td class="text-danger">1</td>\s*<td class="text-warning">2</td>`,
+		string(content))
+}
+
 func newTestNode(needsRebuild, needsTests, rebuildFailed bool) *dag.Node {
 	return dag.NewNode(&dag.Image{
 		Name:          "image1",
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"time"
+)
+
+// timelineRow holds the data needed to draw the timeline of an image in the report Gantt chart.
+type timelineRow struct {
+	ShortName string
+	Duration  time.Duration
+	Segments  []timelineSegment
+}
+
+// timelineSegment is a step of an image processing, positioned relatively to the whole build run.
+// Offset and Width are percentages of the run duration.
+type timelineSegment struct {
+	Step     string
+	Offset   float64
+	Width    float64
+	Duration time.Duration
+}
+
+// timelineData computes the position of each step of every build report on a common timeline,
+// starting at the earliest start time and ending at the latest end time.
+func timelineData(buildReports []BuildReport) []timelineRow {
+	var runStart, runEnd time.Time
+
+	for _, buildReport := range buildReports {
+		timings := buildReport.Timings
+		if timings.StartTime.IsZero() {
+			continue
+		}
+
+		if runStart.IsZero() || timings.StartTime.Before(runStart) {
+			runStart = timings.StartTime
+		}
+
+		if timings.EndTime.After(runEnd) {
+			runEnd = timings.EndTime
+		}
+	}
+
+	runDuration := runEnd.Sub(runStart)
+	if runDuration <= 0 {
+		return nil
+	}
+
+	percent := func(d time.Duration) float64 {
+		return float64(d) / float64(runDuration) * 100
+	}
+
+	rows := make([]timelineRow, 0, len(buildReports))
+
+	for _, buildReport := range buildReports {
+		timings := buildReport.Timings
+		if timings.StartTime.IsZero() {
+			continue
+		}
+
+		row := timelineRow{
+			ShortName: buildReport.Image.ShortName,
+			Duration:  timings.Duration(),
+		}
+
+		var pushDuration time.Duration
+		if timings.PushDuration != nil {
+			pushDuration = *timings.PushDuration
+		}
+
+		cursor := timings.StartTime.Sub(runStart)
+		for _, step := range []struct {
+			name     string
+			duration time.Duration
+		}{
+			{"queue", timings.QueueDuration},
+			{"build", timings.BuildDuration},
+			{"push", pushDuration},
+			{"test", timings.TestDuration},
+		} {
+			if step.duration <= 0 {
+				continue
+			}
+
+			row.Segments = append(row.Segments, timelineSegment{
+				Step:     step.name,
+				Offset:   percent(cursor),
+				Width:    percent(step.duration),
+				Duration: step.duration,
+			})
+			cursor += step.duration
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_timelineData(t *testing.T) {
+	t.Parallel()
+
+	runStart := time.Date(2022, 8, 23, 18, 30, 0, 0, time.UTC)
+
+	buildReports := []BuildReport{
+		{
+			Image: dag.Image{ShortName: "parent"},
+			Timings: Timings{
+				StartTime:     runStart,
+				EndTime:       runStart.Add(40 * time.Second),
+				QueueDuration: 10 * time.Second,
+				BuildDuration: 20 * time.Second,
+				TestDuration:  10 * time.Second,
+			},
+		},
+		{
+			Image: dag.Image{ShortName: "child"},
+			Timings: Timings{
+				StartTime:     runStart.Add(40 * time.Second),
+				EndTime:       runStart.Add(100 * time.Second),
+				BuildDuration: 50 * time.Second,
+				PushDuration:  new(10 * time.Second),
+			},
+		},
+		{
+			Image: dag.Image{ShortName: "without-timings"},
+		},
+	}
+
+	expected := []timelineRow{
+		{
+			ShortName: "parent",
+			Duration:  40 * time.Second,
+			Segments: []timelineSegment{
+				{Step: "queue", Offset: 0, Width: 10, Duration: 10 * time.Second},
+				{Step: "build", Offset: 10, Width: 20, Duration: 20 * time.Second},
+				{Step: "test", Offset: 30, Width: 10, Duration: 10 * time.Second},
+			},
+		},
+		{
+			ShortName: "child",
+			Duration:  60 * time.Second,
+			Segments: []timelineSegment{
+				{Step: "build", Offset: 40, Width: 50, Duration: 50 * time.Second},
+				{Step: "push", Offset: 90, Width: 10, Duration: 10 * time.Second},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, timelineData(buildReports))
+}
+
+func Test_timelineData_NoTimings(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, timelineData([]BuildReport{{Image: dag.Image{ShortName: "image"}}}))
+	assert.Nil(t, timelineData(nil))
+}
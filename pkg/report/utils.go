@@ -14,6 +14,7 @@ import (
 const (
 	BuildReportDir = "builds"
 	JunitReportDir = "junit"
+	SBOMReportDir  = "sbom"
 )
 
 var (
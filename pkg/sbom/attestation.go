@@ -0,0 +1,94 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// annotationReferenceType marks the manifests of an image index holding the BuildKit attestations.
+	annotationReferenceType  = "vnd.docker.reference.type"
+	referenceTypeAttestation = "attestation-manifest"
+	// annotationPredicateType holds the type of the in-toto statement stored in an attestation layer.
+	annotationPredicateType = "in-toto.io/predicate-type"
+	predicateTypeSPDX       = "https://spdx.dev/Document"
+)
+
+// statement is an in-toto statement, as stored by BuildKit in the attestation layers.
+type statement struct {
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// attestation returns the SPDX document of the SBOM attestation BuildKit stored in the image index.
+// When the image is built for several platforms, the SBOM of the first one is returned.
+func (m *Manager) attestation(imageRef string) ([]byte, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	index, err := remote.Index(ref, m.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get image index of %s: %w", imageRef, err)
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range indexManifest.Manifests {
+		if desc.Annotations[annotationReferenceType] != referenceTypeAttestation {
+			continue
+		}
+
+		img, err := index.Image(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := img.Manifest()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, layerDesc := range manifest.Layers {
+			if layerDesc.Annotations[annotationPredicateType] != predicateTypeSPDX {
+				continue
+			}
+
+			layer, err := img.LayerByDigest(layerDesc.Digest)
+			if err != nil {
+				return nil, err
+			}
+
+			reader, err := layer.Uncompressed()
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := io.ReadAll(reader)
+			_ = reader.Close()
+
+			if err != nil {
+				return nil, err
+			}
+
+			var stmt statement
+
+			err = json.Unmarshal(content, &stmt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SBOM attestation: %w", err)
+			}
+
+			return stmt.Predicate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w in the attestations of %s", ErrNoSBOM, imageRef)
+}
@@ -0,0 +1,264 @@
+package sbom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/radiofrance/dib/pkg/logger"
+)
+
+const (
+	// GeneratorBuildkit asks BuildKit for its SBOM attestation during the build.
+	GeneratorBuildkit = "buildkit"
+	// GeneratorSyft generates the SBOM after the build, by scanning the pushed image with syft.
+	GeneratorSyft = "syft"
+	// SyftBinary is the name of the syft binary, required by the syft generator.
+	SyftBinary = "syft"
+	// MediaType is the media type of the SBOM documents, which are in SPDX JSON format.
+	// It is also the artifact type of the referrers holding them.
+	MediaType = "application/spdx+json"
+)
+
+var ErrNoSBOM = errors.New("no SBOM found")
+
+// Config holds the configuration for the SBOM generation.
+type Config struct {
+	// Enabled generates an SBOM for every built image, and attaches it to the image in the registry.
+	Enabled bool `mapstructure:"enabled"`
+	// Generator is either "buildkit" (default) or "syft".
+	Generator string `mapstructure:"generator"`
+}
+
+// Validate returns an error if the generator is unknown.
+func (c Config) Validate() error {
+	switch c.Generator {
+	case "", GeneratorBuildkit, GeneratorSyft:
+		return nil
+	default:
+		return fmt.Errorf("invalid sbom generator %q (available: %s, %s)", c.Generator,
+			GeneratorBuildkit, GeneratorSyft)
+	}
+}
+
+// ShellExecutor runs the syft binary.
+type ShellExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements types.SBOMProvider, using the credentials from the docker config file to access the registry.
+type Manager struct {
+	Config
+
+	shell  ShellExecutor
+	dryRun bool
+	opts   []remote.Option
+	// withSBOM holds the digests of the images known to carry an SBOM, so they are only checked once.
+	withSBOM sync.Map
+}
+
+// NewManager creates a new instance of Manager.
+func NewManager(ctx context.Context, config Config, shell ShellExecutor, dryRun bool, opts ...remote.Option) *Manager {
+	if config.Generator == "" {
+		config.Generator = GeneratorBuildkit
+	}
+
+	return &Manager{
+		Config: config,
+		shell:  shell,
+		dryRun: dryRun,
+		opts: append([]remote.Option{
+			remote.WithContext(ctx),
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		}, opts...),
+	}
+}
+
+// ReportPath returns the path of the SBOM of the given image in the reports directory.
+func ReportPath(sbomDir, imageName string) string {
+	return path.Join(sbomDir, strings.ReplaceAll(imageName, "/", "_")+".spdx.json")
+}
+
+// Attach generates the SBOM of the image, writes it to the file, and attaches it to the image as an OCI referrer.
+func (m *Manager) Attach(_ context.Context, imageRef, filename string) error {
+	if m.dryRun {
+		logger.Infof("[DRY-RUN] Attaching SBOM to image \"%s\"", imageRef)
+		return nil
+	}
+
+	err := os.MkdirAll(path.Dir(filename), 0o750)
+	if err != nil {
+		return err
+	}
+
+	err = m.generate(imageRef, filename)
+	if err != nil {
+		return fmt.Errorf("cannot generate SBOM of %s: %w", imageRef, err)
+	}
+
+	return m.attachFile(imageRef, filename)
+}
+
+// Ensure makes sure the image the ref points to carries an SBOM. When it does not, for instance because the tag
+// now points to a copy of the image, the SBOM is generated and attached again.
+func (m *Manager) Ensure(_ context.Context, imageRef string) error {
+	if m.dryRun {
+		return nil
+	}
+
+	subject, err := m.subject(imageRef)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m.withSBOM.Load(subject.Digest.String()); ok {
+		return nil
+	}
+
+	found, err := m.hasSBOM(imageRef, subject)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		m.withSBOM.Store(subject.Digest.String(), struct{}{})
+		return nil
+	}
+
+	logger.Infof("Image \"%s\" has no SBOM attached, attaching one", imageRef)
+
+	dir, err := os.MkdirTemp("", "dib-sbom-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	filename := path.Join(dir, "sbom.spdx.json")
+
+	err = m.generate(imageRef, filename)
+	if err != nil {
+		return fmt.Errorf("cannot generate SBOM of %s (rebuild it with --force-rebuild): %w", imageRef, err)
+	}
+
+	return m.attachFile(imageRef, filename)
+}
+
+// generate writes the SBOM of the pushed image to the file, using the configured generator.
+func (m *Manager) generate(imageRef, filename string) error {
+	if m.Generator == GeneratorSyft {
+		_, err := m.shell.Execute(SyftBinary, "scan", "registry:"+imageRef, "--output", "spdx-json="+filename)
+		return err
+	}
+
+	document, err := m.attestation(imageRef)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, document, 0o644) //nolint:gosec
+}
+
+// attachFile pushes the SBOM file as an artifact referring to the image the ref points to.
+func (m *Manager) attachFile(imageRef, filename string) error {
+	subject, err := m.subject(imageRef)
+	if err != nil {
+		return err
+	}
+
+	document, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	artifact, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(document, MediaType),
+		Annotations: map[string]string{"org.opencontainers.image.title": path.Base(filename)},
+	})
+	if err != nil {
+		return err
+	}
+
+	artifact = mutate.MediaType(artifact, ggcrtypes.OCIManifestSchema1)
+	artifact = mutate.ConfigMediaType(artifact, MediaType)
+
+	artifact, ok := mutate.Subject(artifact, *subject).(v1.Image)
+	if !ok {
+		return errors.New("cannot set the subject of the SBOM artifact")
+	}
+
+	digest, err := artifact.Digest()
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	err = remote.Write(ref.Context().Digest(digest.String()), artifact, m.opts...)
+	if err != nil {
+		return fmt.Errorf("cannot attach SBOM to %s: %w", imageRef, err)
+	}
+
+	logger.Debugf("Attached SBOM %s to image %s@%s", digest, ref.Context(), subject.Digest)
+	m.withSBOM.Store(subject.Digest.String(), struct{}{})
+
+	return nil
+}
+
+// subject returns the descriptor of the manifest the image ref points to.
+func (m *Manager) subject(imageRef string) (*v1.Descriptor, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	desc, err := remote.Head(ref, m.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get digest of %q: %w", imageRef, err)
+	}
+
+	return desc, nil
+}
+
+// hasSBOM returns true if an SBOM artifact refers to the subject.
+func (m *Manager) hasSBOM(imageRef string, subject *v1.Descriptor) (bool, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return false, fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	referrers, err := remote.Referrers(ref.Context().Digest(subject.Digest.String()), m.opts...)
+	if err != nil {
+		return false, fmt.Errorf("cannot list referrers of %s: %w", imageRef, err)
+	}
+
+	manifest, err := referrers.IndexManifest()
+	if err != nil {
+		return false, err
+	}
+
+	for _, desc := range manifest.Manifests {
+		if desc.ArtifactType == MediaType {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
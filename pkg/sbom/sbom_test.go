@@ -0,0 +1,193 @@
+package sbom_test
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/sbom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spdxDocument = `{"spdxVersion":"SPDX-2.3","name":"image"}`
+
+// fakeSyft writes an SPDX document to the file given in the --output argument.
+type fakeSyft struct {
+	calls int
+}
+
+func (f *fakeSyft) Execute(_ string, args ...string) (string, error) {
+	f.calls++
+
+	for _, arg := range args {
+		if filename, ok := strings.CutPrefix(arg, "spdx-json="); ok {
+			return "", os.WriteFile(filename, []byte(spdxDocument), 0o600)
+		}
+	}
+
+	return "", nil
+}
+
+func sbomReferrers(t *testing.T, imageRef string, digest v1.Hash) []v1.Descriptor {
+	t.Helper()
+
+	ref, err := name.ParseReference(imageRef)
+	require.NoError(t, err)
+
+	index, err := remote.Referrers(ref.Context().Digest(digest.String()))
+	require.NoError(t, err)
+
+	manifest, err := index.IndexManifest()
+	require.NoError(t, err)
+
+	var referrers []v1.Descriptor
+
+	for _, desc := range manifest.Manifests {
+		if desc.ArtifactType == sbom.MediaType {
+			referrers = append(referrers, desc)
+		}
+	}
+
+	return referrers
+}
+
+func TestManager_Attach_Syft(t *testing.T) {
+	t.Parallel()
+
+	imageRef := mock.NewRegistryServer(t) + "/image:dev-hash"
+	digest := mock.PushRandomImage(t, imageRef)
+	filename := sbom.ReportPath(path.Join(t.TempDir(), "sbom"), "team/image")
+
+	shell := &fakeSyft{}
+	manager := sbom.NewManager(t.Context(), sbom.Config{Enabled: true, Generator: sbom.GeneratorSyft}, shell, false)
+
+	require.NoError(t, manager.Attach(t.Context(), imageRef, filename))
+
+	assert.True(t, strings.HasSuffix(filename, "/sbom/team_image.spdx.json"))
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, spdxDocument, string(content))
+	assert.Len(t, sbomReferrers(t, imageRef, digest), 1)
+
+	// The image now carries an SBOM, so it is not generated again.
+	require.NoError(t, manager.Ensure(t.Context(), imageRef))
+	assert.Equal(t, 1, shell.calls)
+}
+
+func TestManager_Ensure(t *testing.T) {
+	t.Parallel()
+
+	host := mock.NewRegistryServer(t)
+	imageRef := host + "/image:hash"
+	digest := mock.PushRandomImage(t, imageRef)
+
+	shell := &fakeSyft{}
+	manager := sbom.NewManager(t.Context(), sbom.Config{Enabled: true, Generator: sbom.GeneratorSyft}, shell, false)
+
+	require.NoError(t, manager.Ensure(t.Context(), imageRef))
+	assert.Equal(t, 1, shell.calls)
+	assert.Len(t, sbomReferrers(t, imageRef, digest), 1)
+
+	// A new manager finds the SBOM attached in the registry.
+	other := sbom.NewManager(t.Context(), sbom.Config{Enabled: true, Generator: sbom.GeneratorSyft}, shell, false)
+	require.NoError(t, other.Ensure(t.Context(), imageRef))
+	assert.Equal(t, 1, shell.calls)
+}
+
+func TestManager_Ensure_BuildkitWithoutAttestation(t *testing.T) {
+	t.Parallel()
+
+	imageRef := mock.NewRegistryServer(t) + "/image:hash"
+	mock.PushRandomImage(t, imageRef)
+
+	manager := sbom.NewManager(t.Context(), sbom.Config{Enabled: true}, &fakeSyft{}, false)
+
+	err := manager.Ensure(t.Context(), imageRef)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--force-rebuild")
+}
+
+func TestManager_Attach_Buildkit(t *testing.T) {
+	t.Parallel()
+
+	imageRef := mock.NewRegistryServer(t) + "/image:dev-hash"
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	imgDigest, err := img.Digest()
+	require.NoError(t, err)
+
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://spdx.dev/Document",
+		"predicate":     json.RawMessage(spdxDocument),
+	})
+	require.NoError(t, err)
+
+	attestation, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(statement, "application/vnd.in-toto+json"),
+		Annotations: map[string]string{"in-toto.io/predicate-type": "https://spdx.dev/Document"},
+	})
+	require.NoError(t, err)
+
+	index := mutate.AppendManifests(mutate.IndexMediaType(empty.Index, ggcrtypes.OCIImageIndex),
+		mutate.IndexAddendum{Add: img},
+		mutate.IndexAddendum{Add: attestation, Descriptor: v1.Descriptor{
+			Annotations: map[string]string{
+				"vnd.docker.reference.type":   "attestation-manifest",
+				"vnd.docker.reference.digest": imgDigest.String(),
+			},
+		}},
+	)
+
+	ref, err := name.ParseReference(imageRef)
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, index))
+
+	indexDigest, err := index.Digest()
+	require.NoError(t, err)
+
+	filename := sbom.ReportPath(t.TempDir(), "image")
+	manager := sbom.NewManager(t.Context(), sbom.Config{Enabled: true}, &fakeSyft{}, false)
+
+	require.NoError(t, manager.Attach(t.Context(), imageRef, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, spdxDocument, string(content))
+	assert.Len(t, sbomReferrers(t, imageRef, indexDigest), 1)
+}
+
+func TestManager_DryRun(t *testing.T) {
+	t.Parallel()
+
+	shell := &fakeSyft{}
+	manager := sbom.NewManager(t.Context(), sbom.Config{Enabled: true, Generator: sbom.GeneratorSyft}, shell, true)
+
+	require.NoError(t, manager.Attach(t.Context(), "registry.example.org/image:dev-hash", "sbom.spdx.json"))
+	require.NoError(t, manager.Ensure(t.Context(), "registry.example.org/image:hash"))
+	assert.Equal(t, 0, shell.calls)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, sbom.Config{}.Validate())
+	require.NoError(t, sbom.Config{Generator: sbom.GeneratorBuildkit}.Validate())
+	require.NoError(t, sbom.Config{Generator: sbom.GeneratorSyft}.Validate())
+	require.EqualError(t, sbom.Config{Generator: "trivy"}.Validate(),
+		`invalid sbom generator "trivy" (available: buildkit, syft)`)
+}
@@ -0,0 +1,241 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// PEM block types of the private keys generated by "cosign generate-key-pair".
+	pemTypeEncryptedSigstore = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	pemTypeEncryptedCosign   = "ENCRYPTED COSIGN PRIVATE KEY"
+	// PEM block types of the unencrypted private keys.
+	pemTypePrivateKey   = "PRIVATE KEY"
+	pemTypeECPrivateKey = "EC PRIVATE KEY"
+	pemTypePublicKey    = "PUBLIC KEY"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signer signs the payloads of the image signatures. It is implemented for cosign key files (see LoadKey),
+// and for KMS plugins (see PluginSigner).
+type Signer interface {
+	// PublicKey returns the public key verifying the signatures.
+	PublicKey() (crypto.PublicKey, error)
+	// SignPayload returns the signature of the payload.
+	SignPayload(payload []byte) ([]byte, error)
+}
+
+// encryptedKey is the content of the private keys encrypted by cosign.
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// keySigner signs payloads with a private key loaded from a file.
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// LoadKey loads an ECDSA private key from a file. The file is either a key generated by
+// "cosign generate-key-pair", encrypted with the password, or an unencrypted PEM private key.
+func LoadKey(filename, password string) (Signer, error) {
+	content, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("cannot read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("invalid signing key %s: no PEM block found", filename)
+	}
+
+	der := block.Bytes
+
+	switch block.Type {
+	case pemTypeEncryptedSigstore, pemTypeEncryptedCosign:
+		der, err = decrypt(block.Bytes, password)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt signing key %s: %w", filename, err)
+		}
+	case pemTypeECPrivateKey:
+		key, err := x509.ParseECPrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %s: %w", filename, err)
+		}
+
+		return &keySigner{key: key}, nil
+	case pemTypePrivateKey:
+	default:
+		return nil, fmt.Errorf("invalid signing key %s: unsupported PEM block %q", filename, block.Type)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", filename, err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid signing key %s: unsupported key type %T (only ECDSA keys are supported)",
+			filename, key)
+	}
+
+	return &keySigner{key: ecdsaKey}, nil
+}
+
+// decrypt decrypts the private key encrypted by cosign, with scrypt and nacl/secretbox.
+func decrypt(content []byte, password string) ([]byte, error) {
+	var encrypted encryptedKey
+
+	err := json.Unmarshal(content, &encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypted.KDF.Name != "scrypt" || encrypted.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported encryption %s/%s", encrypted.KDF.Name, encrypted.Cipher.Name)
+	}
+
+	params := encrypted.KDF.Params
+
+	derived, err := scrypt.Key([]byte(password), encrypted.KDF.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		secretKey [32]byte
+		nonce     [24]byte
+	)
+
+	copy(secretKey[:], derived)
+	copy(nonce[:], encrypted.Cipher.Nonce)
+
+	der, ok := secretbox.Open(nil, encrypted.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, errors.New("wrong password")
+	}
+
+	return der, nil
+}
+
+func (s *keySigner) PublicKey() (crypto.PublicKey, error) {
+	return s.key.Public(), nil
+}
+
+func (s *keySigner) SignPayload(payload []byte) ([]byte, error) {
+	digest := sha256.Sum256(payload)
+
+	return ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+}
+
+// LoadPublicKey loads a PEM public key from a file, such as the cosign.pub file generated by
+// "cosign generate-key-pair".
+func LoadPublicKey(filename string) (crypto.PublicKey, error) {
+	content, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("cannot read public key: %w", err)
+	}
+
+	key, err := parsePublicKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %w", filename, err)
+	}
+
+	return key, nil
+}
+
+func parsePublicKey(content []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != pemTypePublicKey {
+		return nil, errors.New("no PEM public key found")
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// VerifyPayload checks the signature of the payload was made by the private key of the public key.
+func VerifyPayload(publicKey crypto.PublicKey, payload, signature []byte) error {
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported public key type %T (only ECDSA keys are supported)", publicKey)
+	}
+
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// ShellExecutor runs the KMS plugin binary.
+type ShellExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// PluginSigner delegates the signature to a KMS plugin: an executable which prints the PEM public key when called
+// with "public-key", and the base64-encoded signature of the payload when called with "sign <base64 payload>".
+type PluginSigner struct {
+	shell  ShellExecutor
+	binary string
+}
+
+// NewPluginSigner creates a new instance of PluginSigner.
+func NewPluginSigner(shell ShellExecutor, binary string) *PluginSigner {
+	return &PluginSigner{shell: shell, binary: binary}
+}
+
+func (s *PluginSigner) PublicKey() (crypto.PublicKey, error) {
+	output, err := s.shell.Execute(s.binary, "public-key")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get public key from KMS plugin %s: %w", s.binary, err)
+	}
+
+	key, err := parsePublicKey([]byte(output))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key from KMS plugin %s: %w", s.binary, err)
+	}
+
+	return key, nil
+}
+
+func (s *PluginSigner) SignPayload(payload []byte) ([]byte, error) {
+	output, err := s.shell.Execute(s.binary, "sign", base64.StdEncoding.EncodeToString(payload))
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign with KMS plugin %s: %w", s.binary, err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(output))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from KMS plugin %s: %w", s.binary, err)
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,232 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/radiofrance/dib/pkg/logger"
+)
+
+const (
+	// PasswordEnv is the environment variable holding the password of the encrypted key, as with cosign.
+	PasswordEnv = "COSIGN_PASSWORD"
+	// SignatureMediaType is the media type of the signature layers, holding the signed payload.
+	SignatureMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// SignatureAnnotation is the annotation of the signature layers, holding the base64-encoded signature.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// signatureType is the type of the signed payloads.
+	signatureType = "cosign container image signature"
+)
+
+// Config holds the configuration for the image signing.
+type Config struct {
+	// Enabled signs the final digest of every rebuilt or retagged image.
+	Enabled bool `mapstructure:"enabled"`
+	// Key is the path to the cosign private key. Its password is read from the COSIGN_PASSWORD env variable.
+	Key string `mapstructure:"key"`
+	// KMSPlugin is the KMS plugin executable signing the images, used instead of the key.
+	KMSPlugin string `mapstructure:"kms_plugin"`
+	// Verify configures the verification of the signatures of the base images, before each build.
+	Verify VerifyConfig `mapstructure:"verify"`
+}
+
+// Validate returns an error unless exactly one of the key and the KMS plugin is set.
+func (c Config) Validate() error {
+	if (c.Key == "") == (c.KMSPlugin == "") {
+		return errors.New("image signing requires either a key or a KMS plugin")
+	}
+
+	return nil
+}
+
+// payload is the "simple signing" payload cosign signs, binding the repository to the manifest digest.
+type payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// Manager implements types.ImageSigner, using the credentials from the docker config file to access the registry.
+// Signatures are stored like cosign does, in the image tagged "sha256-<digest>.sig" in the same repository.
+type Manager struct {
+	signer Signer
+	dryRun bool
+	opts   []remote.Option
+}
+
+// NewManager creates a new instance of Manager.
+func NewManager(ctx context.Context, signer Signer, dryRun bool, opts ...remote.Option) *Manager {
+	return &Manager{
+		signer: signer,
+		dryRun: dryRun,
+		opts: append([]remote.Option{
+			remote.WithContext(ctx),
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		}, opts...),
+	}
+}
+
+// SignatureRef returns the ref of the image holding the signatures of the manifest digest.
+func SignatureRef(repository name.Repository, digest v1.Hash) name.Tag {
+	return repository.Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+}
+
+// Sign signs the manifest the image ref points to, and returns the ref of the signature.
+// When the manifest is already signed with the same key, the existing signature is kept.
+func (m *Manager) Sign(_ context.Context, imageRef string) (string, error) {
+	if m.dryRun {
+		logger.Infof("[DRY-RUN] Signing image \"%s\"", imageRef)
+		return "", nil
+	}
+
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return "", fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	desc, err := remote.Head(ref, m.opts...)
+	if err != nil {
+		return "", fmt.Errorf("cannot get digest of %q: %w", imageRef, err)
+	}
+
+	sigRef := SignatureRef(ref.Context(), desc.Digest)
+
+	signatures, err := m.signatures(sigRef)
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, err := m.signer.PublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := hasSignature(signatures, publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	if signed {
+		logger.Debugf("Image %s@%s is already signed", ref.Context(), desc.Digest)
+		return sigRef.String(), nil
+	}
+
+	content, err := json.Marshal(newPayload(ref.Context(), desc.Digest))
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := m.signer.SignPayload(content)
+	if err != nil {
+		return "", fmt.Errorf("cannot sign %s: %w", imageRef, err)
+	}
+
+	signatures, err = mutate.Append(signatures, mutate.Addendum{
+		Layer:       static.NewLayer(content, SignatureMediaType),
+		Annotations: map[string]string{SignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = remote.Write(sigRef, signatures, m.opts...)
+	if err != nil {
+		return "", fmt.Errorf("cannot push signature of %s: %w", imageRef, err)
+	}
+
+	logger.Debugf("Signed image %s@%s", ref.Context(), desc.Digest)
+
+	return sigRef.String(), nil
+}
+
+func newPayload(repository name.Repository, digest v1.Hash) payload {
+	var p payload
+
+	p.Critical.Identity.DockerReference = repository.Name()
+	p.Critical.Image.DockerManifestDigest = digest.String()
+	p.Critical.Type = signatureType
+
+	return p
+}
+
+// signatures returns the image holding the existing signatures, or an empty one when the image is not signed yet.
+func (m *Manager) signatures(sigRef name.Tag) (v1.Image, error) {
+	img, err := remote.Image(sigRef, m.opts...)
+	if err == nil {
+		return img, nil
+	}
+
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		img = mutate.MediaType(empty.Image, ggcrtypes.OCIManifestSchema1)
+		return mutate.ConfigMediaType(img, ggcrtypes.OCIConfigJSON), nil
+	}
+
+	return nil, fmt.Errorf("cannot get signatures %s: %w", sigRef, err)
+}
+
+// hasSignature returns true if one of the signatures was made by the private key of the public key.
+func hasSignature(signatures v1.Image, publicKey crypto.PublicKey) (bool, error) {
+	manifest, err := signatures.Manifest()
+	if err != nil {
+		return false, err
+	}
+
+	for _, layerDesc := range manifest.Layers {
+		signature, err := base64.StdEncoding.DecodeString(layerDesc.Annotations[SignatureAnnotation])
+		if err != nil || layerDesc.MediaType != SignatureMediaType {
+			continue
+		}
+
+		layer, err := signatures.LayerByDigest(layerDesc.Digest)
+		if err != nil {
+			return false, err
+		}
+
+		content, err := readLayer(layer)
+		if err != nil {
+			return false, err
+		}
+
+		if VerifyPayload(publicKey, content, signature) == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func readLayer(layer v1.Layer) ([]byte, error) {
+	reader, err := layer.Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	return io.ReadAll(reader)
+}
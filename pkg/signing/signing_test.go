@@ -0,0 +1,272 @@
+package signing_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// writeKey writes a new ECDSA private key to a file, encrypted like cosign does when a password is given.
+func writeKey(t *testing.T, password string) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+	if password != "" {
+		salt := make([]byte, 32)
+		_, err = rand.Read(salt)
+		require.NoError(t, err)
+
+		var (
+			secretKey [32]byte
+			nonce     [24]byte
+		)
+
+		derived, err := scrypt.Key([]byte(password), salt, 1024, 8, 1, 32)
+		require.NoError(t, err)
+		copy(secretKey[:], derived)
+
+		_, err = rand.Read(nonce[:])
+		require.NoError(t, err)
+
+		encrypted, err := json.Marshal(map[string]any{
+			"kdf": map[string]any{
+				"name":   "scrypt",
+				"params": map[string]int{"N": 1024, "r": 8, "p": 1},
+				"salt":   salt,
+			},
+			"cipher":     map[string]any{"name": "nacl/secretbox", "nonce": nonce[:]},
+			"ciphertext": secretbox.Seal(nil, der, &nonce, &secretKey),
+		})
+		require.NoError(t, err)
+
+		block = &pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: encrypted}
+	}
+
+	filename := path.Join(t.TempDir(), "cosign.key")
+	require.NoError(t, os.WriteFile(filename, pem.EncodeToMemory(block), 0o600))
+
+	return filename
+}
+
+// signatureLayers returns the payloads and signatures stored in the signature image of the digest.
+func signatureLayers(t *testing.T, repository string, digest v1.Hash) (payloads, signatures [][]byte) {
+	t.Helper()
+
+	repo, err := name.NewRepository(repository)
+	require.NoError(t, err)
+
+	img, err := remote.Image(signing.SignatureRef(repo, digest))
+	require.NoError(t, err)
+
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+
+	for _, desc := range manifest.Layers {
+		assert.Equal(t, signing.SignatureMediaType, string(desc.MediaType))
+
+		layer, err := img.LayerByDigest(desc.Digest)
+		require.NoError(t, err)
+
+		reader, err := layer.Uncompressed()
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		signature, err := base64.StdEncoding.DecodeString(desc.Annotations[signing.SignatureAnnotation])
+		require.NoError(t, err)
+
+		payloads = append(payloads, content)
+		signatures = append(signatures, signature)
+	}
+
+	return payloads, signatures
+}
+
+func TestManager_Sign(t *testing.T) {
+	t.Parallel()
+
+	registryHost := mock.NewRegistryServer(t)
+	imageRef := registryHost + "/image:tag"
+	digest := mock.PushRandomImage(t, imageRef)
+
+	signer, err := signing.LoadKey(writeKey(t, "s3cr3t"), "s3cr3t")
+	require.NoError(t, err)
+
+	manager := signing.NewManager(t.Context(), signer, false)
+
+	signatureRef, err := manager.Sign(t.Context(), imageRef)
+	require.NoError(t, err)
+	assert.Equal(t, registryHost+"/image:sha256-"+digest.Hex+".sig", signatureRef)
+
+	payloads, signatures := signatureLayers(t, registryHost+"/image", digest)
+	require.Len(t, payloads, 1)
+	assert.JSONEq(t, `{
+		"critical": {
+			"identity": {"docker-reference": "`+registryHost+`/image"},
+			"image": {"docker-manifest-digest": "`+digest.String()+`"},
+			"type": "cosign container image signature"
+		},
+		"optional": null
+	}`, string(payloads[0]))
+
+	publicKey, err := signer.PublicKey()
+	require.NoError(t, err)
+	require.NoError(t, signing.VerifyPayload(publicKey, payloads[0], signatures[0]))
+
+	// Signing again with the same key keeps the existing signature.
+	_, err = manager.Sign(t.Context(), imageRef)
+	require.NoError(t, err)
+
+	payloads, _ = signatureLayers(t, registryHost+"/image", digest)
+	assert.Len(t, payloads, 1)
+
+	// Signing with another key adds a signature.
+	otherSigner, err := signing.LoadKey(writeKey(t, ""), "")
+	require.NoError(t, err)
+
+	_, err = signing.NewManager(t.Context(), otherSigner, false).Sign(t.Context(), imageRef)
+	require.NoError(t, err)
+
+	payloads, _ = signatureLayers(t, registryHost+"/image", digest)
+	assert.Len(t, payloads, 2)
+}
+
+func TestManager_Sign_DryRun(t *testing.T) {
+	t.Parallel()
+
+	signer, err := signing.LoadKey(writeKey(t, ""), "")
+	require.NoError(t, err)
+
+	signatureRef, err := signing.NewManager(t.Context(), signer, true).Sign(t.Context(), "registry.invalid/image:tag")
+	require.NoError(t, err)
+	assert.Empty(t, signatureRef)
+}
+
+func TestLoadKey_WrongPassword(t *testing.T) {
+	t.Parallel()
+
+	_, err := signing.LoadKey(writeKey(t, "s3cr3t"), "wrong")
+	require.ErrorContains(t, err, "wrong password")
+}
+
+// fakeKMSPlugin signs the payloads with a key, like a KMS plugin would.
+type fakeKMSPlugin struct {
+	key *ecdsa.PrivateKey
+}
+
+func (f *fakeKMSPlugin) Execute(_ string, args ...string) (string, error) {
+	if args[0] == "public-key" {
+		der, err := x509.MarshalPKIXPublicKey(f.key.Public())
+		if err != nil {
+			return "", err
+		}
+
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(args[1])
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(payload)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, f.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signature) + "\n", nil
+}
+
+func TestPluginSigner(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer := signing.NewPluginSigner(&fakeKMSPlugin{key: key}, "dib-kms-plugin")
+
+	publicKey, err := signer.PublicKey()
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(publicKey))
+
+	signature, err := signer.SignPayload([]byte("payload"))
+	require.NoError(t, err)
+	require.NoError(t, signing.VerifyPayload(publicKey, []byte("payload"), signature))
+	require.ErrorIs(t, signing.VerifyPayload(publicKey, []byte("other payload"), signature),
+		signing.ErrInvalidSignature)
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+
+	filename := path.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	publicKey, err := signing.LoadPublicKey(filename)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(publicKey))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  signing.Config
+		wantErr bool
+	}{
+		{name: "key", config: signing.Config{Key: "cosign.key"}},
+		{name: "KMS plugin", config: signing.Config{KMSPlugin: "dib-kms-plugin"}},
+		{name: "none", config: signing.Config{}, wantErr: true},
+		{name: "both", config: signing.Config{Key: "cosign.key", KMSPlugin: "dib-kms-plugin"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.config.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+var ErrNoValidSignature = errors.New("no valid signature found")
+
+// VerifyConfig holds the configuration for the verification of the base images signatures.
+type VerifyConfig struct {
+	// Enabled refuses to build an image when one of its base images has no valid signature.
+	Enabled bool `mapstructure:"enabled"`
+	// PublicKeys are the paths to the public keys the signatures are verified with.
+	PublicKeys []string `mapstructure:"public_keys"`
+	// Ignore lists the names of the external base images whose signatures are not verified (e.g. "debian").
+	Ignore []string `mapstructure:"ignore"`
+}
+
+// Validate returns an error if no public key is configured.
+func (c VerifyConfig) Validate() error {
+	if len(c.PublicKeys) == 0 {
+		return errors.New("signature verification requires at least one public key")
+	}
+
+	return nil
+}
+
+// Verifier implements types.SignatureVerifier, using the credentials from the docker config file to access the
+// registries. Signatures are looked up like cosign does, in the image tagged "sha256-<digest>.sig".
+type Verifier struct {
+	publicKeys []crypto.PublicKey
+	opts       []remote.Option
+	// verified holds the digests of the images known to be signed, so they are only verified once.
+	verified sync.Map
+}
+
+// NewVerifier creates a new instance of Verifier.
+func NewVerifier(ctx context.Context, publicKeys []crypto.PublicKey, opts ...remote.Option) *Verifier {
+	return &Verifier{
+		publicKeys: publicKeys,
+		opts: append([]remote.Option{
+			remote.WithContext(ctx),
+			remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		}, opts...),
+	}
+}
+
+// LoadPublicKeys loads the PEM public keys from the files.
+func LoadPublicKeys(filenames []string) ([]crypto.PublicKey, error) {
+	publicKeys := make([]crypto.PublicKey, 0, len(filenames))
+
+	for _, filename := range filenames {
+		publicKey, err := LoadPublicKey(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return publicKeys, nil
+}
+
+// Verify returns an error unless the manifest the image ref points to is signed by one of the public keys.
+func (v *Verifier) Verify(_ context.Context, imageRef string) error {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return fmt.Errorf("invalid image ref %q: %w", imageRef, err)
+	}
+
+	digest, err := v.digest(ref)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := v.verified.Load(ref.Context().String() + "@" + digest.String()); ok {
+		return nil
+	}
+
+	signatures, err := remote.Image(SignatureRef(ref.Context(), digest), v.opts...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w for %s@%s: the image is not signed", ErrNoValidSignature, ref.Context(), digest)
+		}
+
+		return fmt.Errorf("cannot get signatures of %s: %w", imageRef, err)
+	}
+
+	valid, err := v.hasValidSignature(signatures, digest)
+	if err != nil {
+		return fmt.Errorf("cannot verify signatures of %s: %w", imageRef, err)
+	}
+
+	if !valid {
+		return fmt.Errorf("%w for %s@%s with the configured public keys", ErrNoValidSignature, ref.Context(), digest)
+	}
+
+	v.verified.Store(ref.Context().String()+"@"+digest.String(), struct{}{})
+
+	return nil
+}
+
+// digest returns the digest of the manifest the ref points to.
+func (v *Verifier) digest(ref name.Reference) (v1.Hash, error) {
+	if digestRef, ok := ref.(name.Digest); ok {
+		return v1.NewHash(digestRef.DigestStr())
+	}
+
+	desc, err := remote.Head(ref, v.opts...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("cannot get digest of %q: %w", ref, err)
+	}
+
+	return desc.Digest, nil
+}
+
+// hasValidSignature returns true if one of the signatures is a signature of the digest by one of the public keys.
+func (v *Verifier) hasValidSignature(signatures v1.Image, digest v1.Hash) (bool, error) {
+	manifest, err := signatures.Manifest()
+	if err != nil {
+		return false, err
+	}
+
+	for _, layerDesc := range manifest.Layers {
+		signature, err := base64.StdEncoding.DecodeString(layerDesc.Annotations[SignatureAnnotation])
+		if err != nil || layerDesc.MediaType != SignatureMediaType {
+			continue
+		}
+
+		layer, err := signatures.LayerByDigest(layerDesc.Digest)
+		if err != nil {
+			return false, err
+		}
+
+		content, err := readLayer(layer)
+		if err != nil {
+			return false, err
+		}
+
+		var signed payload
+
+		// The payload must be about the digest, so a signature cannot be replayed on another image.
+		err = json.Unmarshal(content, &signed)
+		if err != nil || signed.Critical.Image.DockerManifestDigest != digest.String() {
+			continue
+		}
+
+		for _, publicKey := range v.publicKeys {
+			if VerifyPayload(publicKey, content, signature) == nil {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
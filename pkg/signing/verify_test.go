@@ -0,0 +1,81 @@
+package signing_test
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	t.Parallel()
+
+	registryHost := mock.NewRegistryServer(t)
+	signedRef := registryHost + "/signed:tag"
+	signedDigest := mock.PushRandomImage(t, signedRef)
+	unsignedRef := registryHost + "/unsigned:tag"
+	mock.PushRandomImage(t, unsignedRef)
+
+	signer, err := signing.LoadKey(writeKey(t, ""), "")
+	require.NoError(t, err)
+
+	_, err = signing.NewManager(t.Context(), signer, false).Sign(t.Context(), signedRef)
+	require.NoError(t, err)
+
+	publicKey, err := signer.PublicKey()
+	require.NoError(t, err)
+
+	otherSigner, err := signing.LoadKey(writeKey(t, ""), "")
+	require.NoError(t, err)
+
+	otherPublicKey, err := otherSigner.PublicKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		imageRef   string
+		publicKeys []crypto.PublicKey
+		wantErr    string
+	}{
+		{
+			name:       "signed image",
+			imageRef:   signedRef,
+			publicKeys: []crypto.PublicKey{otherPublicKey, publicKey},
+		},
+		{
+			name:       "signed image by digest",
+			imageRef:   registryHost + "/signed@" + signedDigest.String(),
+			publicKeys: []crypto.PublicKey{publicKey},
+		},
+		{
+			name:       "signed with another key",
+			imageRef:   signedRef,
+			publicKeys: []crypto.PublicKey{otherPublicKey},
+			wantErr:    "with the configured public keys",
+		},
+		{
+			name:       "unsigned image",
+			imageRef:   unsignedRef,
+			publicKeys: []crypto.PublicKey{publicKey},
+			wantErr:    "the image is not signed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := signing.NewVerifier(t.Context(), test.publicKeys).Verify(t.Context(), test.imageRef)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, signing.ErrNoValidSignature)
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package structuretest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	osExec "os/exec"
+	"path"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/exec"
+	"github.com/radiofrance/dib/pkg/goss"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+// ContainerdExecutor executes container-structure-test tests using containerd via ctr.
+// As container-structure-test has no containerd driver, the binary is mounted in a container running
+// the image to test, and executed there with the "host" driver. Metadata tests are not supported.
+type ContainerdExecutor struct{}
+
+// NewContainerdExecutor creates a new instance of ContainerdExecutor.
+func NewContainerdExecutor() *ContainerdExecutor {
+	return &ContainerdExecutor{}
+}
+
+// Execute container-structure-test tests on the given image using ctr. structure-test.yaml file is expected to be
+// present in the given path.
+func (e ContainerdExecutor) Execute(
+	_ context.Context,
+	output io.Writer,
+	opts types.RunTestOptions,
+	args ...string,
+) error {
+	binary, err := osExec.LookPath(Binary)
+	if err != nil {
+		return err
+	}
+
+	shell := exec.NewShellExecutor(opts.DockerContextPath, os.Environ())
+
+	ctrArgs := []string{
+		"run",
+		"--cgroup", "user.slice:foo:bar",
+		"--runc-systemd-cgroup",
+		"--rm",
+		"--mount", fmt.Sprintf("type=bind,src=%s,dst=/usr/local/bin/%s,options=rbind:ro", binary, Binary),
+		"--mount", fmt.Sprintf("type=bind,src=%s,dst=/structure-test,options=rbind:ro", opts.DockerContextPath),
+		opts.ImageReference,
+		"structure-test-" + strings.ReplaceAll(opts.ImageName, "/", "_"),
+		Binary, "test", "--driver", "host", "--config", path.Join("/structure-test", configFilename),
+	}
+
+	return goss.ExecuteCtr(shell, output, opts.BuildkitHost, append(ctrArgs, args...)...)
+}
@@ -0,0 +1,40 @@
+package structuretest
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+
+	"github.com/radiofrance/dib/pkg/exec"
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+// ShellExecutor runs the container-structure-test binary.
+type ShellExecutor interface {
+	ExecuteWithWriter(writer io.Writer, name string, args ...string) error
+}
+
+// DockerExecutor executes container-structure-test tests against the images of the local docker daemon.
+type DockerExecutor struct {
+	Shell ShellExecutor
+}
+
+// NewDockerExecutor creates a new instance of DockerExecutor.
+func NewDockerExecutor() *DockerExecutor {
+	return &DockerExecutor{
+		Shell: exec.NewShellExecutor("", os.Environ()),
+	}
+}
+
+// Execute container-structure-test tests on the given image. structure-test.yaml file is expected to be present
+// in the given path.
+func (e DockerExecutor) Execute(_ context.Context, output io.Writer, opts types.RunTestOptions, args ...string) error {
+	cstArgs := []string{
+		"test",
+		"--image", opts.ImageReference,
+		"--config", path.Join(opts.DockerContextPath, configFilename),
+	}
+
+	return e.Shell.ExecuteWithWriter(output, Binary, append(cstArgs, args...)...)
+}
@@ -0,0 +1,34 @@
+package structuretest_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DockerExecutor_Execute(t *testing.T) {
+	t.Parallel()
+
+	shell := mock.NewShellExecutor([]mock.ExecutorResult{{Output: "<testsuites></testsuites>"}})
+	executor := structuretest.DockerExecutor{Shell: shell}
+
+	writer := mock.NewWriter()
+	err := executor.Execute(t.Context(), writer, types.RunTestOptions{
+		ImageName:         "image",
+		ImageReference:    "registry.org/image:tag",
+		DockerContextPath: "/path/to/context",
+	}, "--output", "junit")
+	require.NoError(t, err)
+
+	assert.Equal(t, "<testsuites></testsuites>", writer.GetString())
+	require.Len(t, shell.Executed, 1)
+	assert.Equal(t, "container-structure-test", shell.Executed[0].Command)
+	assert.Equal(t, []string{
+		"test", "--image", "registry.org/image:tag", "--config", "/path/to/context/structure-test.yaml",
+		"--output", "junit",
+	}, shell.Executed[0].Args)
+}
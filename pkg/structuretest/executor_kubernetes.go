@@ -0,0 +1,53 @@
+package structuretest
+
+import (
+	"context"
+	"io"
+	"path"
+
+	k8sutils "github.com/radiofrance/dib/pkg/kubernetes"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// KubernetesExecutor will run container-structure-test tests in a Kubernetes cluster.
+// The binary is copied in the pod running the image to test, and executed there with the "host" driver.
+type KubernetesExecutor struct {
+	clientSet  kubernetes.Interface
+	restConfig rest.Config
+	PodConfig  k8sutils.PodConfig // The default pod configuration used to run the tests.
+}
+
+// NewKubernetesExecutor creates a new instance of KubernetesExecutor.
+func NewKubernetesExecutor(restConfig rest.Config, clientSet kubernetes.Interface, config k8sutils.PodConfig,
+) *KubernetesExecutor {
+	return &KubernetesExecutor{
+		clientSet:  clientSet,
+		restConfig: restConfig,
+		PodConfig:  config,
+	}
+}
+
+// Execute the container-structure-test tests using a Kubernetes Pod.
+func (e KubernetesExecutor) Execute(ctx context.Context, output io.Writer, opts types.RunTestOptions,
+	args ...string,
+) error {
+	logger.Infof("Testing image %s with container-structure-test kubernetes executor", opts.ImageName)
+
+	remoteConfigFile := path.Join("/structure-test", configFilename)
+	cmd := []string{path.Join("/structure-test", Binary), "test", "--driver", "host", "--config", remoteConfigFile}
+
+	return k8sutils.RunTestPod(ctx, e.clientSet, e.restConfig, e.PodConfig, k8sutils.TestPod{
+		Tool:           types.TestRunnerStructureTest,
+		ImageName:      opts.ImageName,
+		ImageReference: opts.ImageReference,
+		SetupCommand:   []string{"cp", path.Join("/", Binary), "/shared"},
+		MountPath:      "/structure-test",
+		Files: map[string]string{
+			path.Join(opts.DockerContextPath, configFilename): remoteConfigFile,
+		},
+		Command: append(cmd, args...),
+	}, output)
+}
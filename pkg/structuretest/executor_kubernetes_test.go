@@ -0,0 +1,87 @@
+package structuretest_test
+
+import (
+	"testing"
+	"time"
+
+	k8sutils "github.com/radiofrance/dib/pkg/kubernetes"
+	"github.com/radiofrance/dib/pkg/mock"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stest "k8s.io/client-go/testing"
+)
+
+func Test_KubernetesExecutor_Execute_CreatesValidPod(t *testing.T) {
+	t.Parallel()
+
+	clientSet := fake.NewClientset()
+	watcher := watch.NewFake()
+	clientSet.PrependWatchReactor("pods", k8stest.DefaultWatchReactor(watcher, nil))
+
+	executor := structuretest.NewKubernetesExecutor(rest.Config{}, clientSet, k8sutils.PodConfig{
+		Namespace: "structure-test-ns",
+		Image:     "my-structure-test-image:tag",
+	})
+
+	labelSelector := "app.kubernetes.io/name=structure-test,app.kubernetes.io/component=structure-test-pod"
+
+	go func() {
+		// Wait for the Pod to be created before running assertions
+		<-time.After(1 * time.Second)
+
+		pods, err := clientSet.CoreV1().Pods("structure-test-ns").List(t.Context(), metav1.ListOptions{
+			LabelSelector: labelSelector,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, pods.Items, 1)
+		pod := pods.Items[0]
+
+		initContainer := pod.Spec.InitContainers[0]
+		assert.Equal(t, "my-structure-test-image:tag", initContainer.Image)
+		assert.Equal(t, []string{"cp", "/container-structure-test", "/shared"}, initContainer.Command)
+
+		container := pod.Spec.Containers[0]
+		assert.Equal(t, "registry.org/image:tag", container.Image)
+		assert.Equal(t, []corev1.VolumeMount{
+			{Name: "shared", MountPath: "/structure-test"},
+		}, container.VolumeMounts)
+
+		simulatePodFailure(t, watcher)
+	}()
+
+	writer := mock.NewWriter()
+	err := executor.Execute(t.Context(), writer, types.RunTestOptions{
+		ImageName:         "image",
+		ImageReference:    "registry.org/image:tag",
+		DockerContextPath: "/path/to/context",
+	}, "--output", "junit")
+	require.ErrorContains(t, err, "error watching structure-test pod")
+
+	// Check the pod has been deleted
+	pods, err := clientSet.CoreV1().Pods("structure-test-ns").List(t.Context(), metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items)
+}
+
+// simulatePodFailure simulates the lifecycle of a pod failing before the tests could be run.
+func simulatePodFailure(t *testing.T, watcher *watch.FakeWatcher) {
+	t.Helper()
+
+	watcher.Action(watch.Added, &corev1.Pod{
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	})
+
+	<-time.After(1 * time.Second)
+	watcher.Action(watch.Modified, &corev1.Pod{
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	})
+}
@@ -0,0 +1,213 @@
+package structuretest
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/radiofrance/dib/pkg/goss"
+	"github.com/radiofrance/dib/pkg/junit"
+	"github.com/radiofrance/dib/pkg/kubernetes"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/radiofrance/kubecli"
+)
+
+const (
+	// Binary is the name of the container-structure-test binary.
+	Binary         = "container-structure-test"
+	configFilename = "structure-test.yaml"
+)
+
+// Executor is an interface for executing container-structure-test tests.
+type Executor interface {
+	Execute(ctx context.Context, output io.Writer, opts types.RunTestOptions, args ...string) error
+}
+
+// TestRunner implements types.TestRunner.
+type TestRunner struct {
+	Executor
+	TestRunnerOptions
+}
+
+// TestRunnerOptions are the configuration options for TestRunner.
+type TestRunnerOptions struct {
+	WorkingDirectory string
+}
+
+// Config holds the configuration for the container-structure-test test runner.
+type Config struct {
+	Executor struct {
+		Kubernetes struct {
+			Enabled           bool     `mapstructure:"enabled"`
+			Namespace         string   `mapstructure:"namespace"`
+			Image             string   `mapstructure:"image"`
+			ImagePullSecrets  []string `mapstructure:"image_pull_secrets"`
+			ContainerOverride string   `mapstructure:"container_override"`
+			PodOverride       string   `mapstructure:"pod_override"`
+		} `mapstructure:"kubernetes"`
+	} `mapstructure:"executor"`
+}
+
+// NewTestRunner creates a new instance of TestRunner.
+func NewTestRunner(executor Executor, opts TestRunnerOptions) *TestRunner {
+	return &TestRunner{executor, opts}
+}
+
+// Name returns the name of the test runner.
+func (r *TestRunner) Name() string {
+	return types.TestRunnerStructureTest
+}
+
+// IsConfigured returns true if a structure-test.yaml file is found at the target context path.
+func (r *TestRunner) IsConfigured(opts types.RunTestOptions) bool {
+	_, err := os.Stat(path.Join(opts.DockerContextPath, configFilename))
+	return err == nil
+}
+
+// RunTest executes container-structure-test tests on the given image.
+// The structure-test.yaml file is expected to be present in the given path.
+func (r *TestRunner) RunTest(ctx context.Context, opts types.RunTestOptions) error {
+	err := os.MkdirAll(opts.ReportJunitDir, 0o750)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(path.Join(opts.DockerContextPath, configFilename))
+	if err != nil {
+		return fmt.Errorf("cannot run structure tests: %w", err)
+	}
+
+	var stdout bytes.Buffer
+
+	testError := r.Execute(ctx, &stdout, opts, "--output", "junit")
+
+	err = r.exportJunitReport(opts, stdout.Bytes())
+	if err != nil {
+		return fmt.Errorf("structure tests failed, could not export junit report: %w", errors.Join(testError, err))
+	}
+
+	if testError != nil {
+		return fmt.Errorf("structure tests failed: %w", testError)
+	}
+
+	return nil
+}
+
+// JunitReportPath returns the path of the JUnit report of the given image.
+func JunitReportPath(junitDir, imageName string) string {
+	return path.Join(junitDir, fmt.Sprintf("junit-structure-test-%s.xml", strings.ReplaceAll(imageName, "/", "_")))
+}
+
+// exportJunitReport converts the JUnit report printed by container-structure-test, which groups the tests
+// in a "testsuites" element, into a single test suite, as generated by the other test runners.
+func (r *TestRunner) exportJunitReport(opts types.RunTestOptions, stdout []byte) error {
+	// Logs may be printed before the report itself.
+	start := bytes.Index(stdout, []byte("<testsuites"))
+	if start < 0 {
+		return errors.New("no junit report found in container-structure-test output")
+	}
+
+	testSuites, err := junit.ParseRawTestsuites(stdout[start:])
+	if err != nil {
+		return fmt.Errorf("invalid container-structure-test junit report: %w", err)
+	}
+
+	testSuite := junit.Testsuite{
+		Name:      types.TestRunnerStructureTest,
+		Errors:    "0",
+		Skipped:   "0",
+		Time:      testSuites.Time,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	failures := 0
+
+	for _, suite := range testSuites.Testsuites {
+		for _, testCase := range suite.TestCases {
+			testCase.ClassName = "structure-test-" + opts.ImageName
+			testCase.File = strings.ReplaceAll(opts.DockerContextPath, r.WorkingDirectory+"/", "")
+
+			if testCase.Failure != "" {
+				failures++
+			}
+
+			testSuite.TestCases = append(testSuite.TestCases, testCase)
+		}
+	}
+
+	testSuite.Tests = strconv.Itoa(len(testSuite.TestCases))
+	testSuite.Failures = strconv.Itoa(failures)
+
+	data, err := xml.MarshalIndent(testSuite, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	junitFilename := JunitReportPath(opts.ReportJunitDir, opts.ImageName)
+
+	err = os.WriteFile(junitFilename, append([]byte(xml.Header), data...), 0o644)
+	if err != nil {
+		return fmt.Errorf("could not write junit report to file %s: %w", junitFilename, err)
+	}
+
+	return nil
+}
+
+// CreateTestRunner creates the test runner, with the executor matching the configuration and the build backend.
+func CreateTestRunner(
+	config Config,
+	localOnly bool,
+	buildkitHost,
+	workingDir string,
+	backend string,
+) (*TestRunner, error) {
+	runnerOpts := TestRunnerOptions{
+		WorkingDirectory: workingDir,
+	}
+
+	if config.Executor.Kubernetes.Enabled && !localOnly {
+		executor, err := createKubernetesExecutor(config)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewTestRunner(executor, runnerOpts), nil
+	}
+
+	// Choose executor based on backend
+	if backend == types.BackendDocker {
+		return NewTestRunner(NewDockerExecutor(), runnerOpts), nil
+	}
+
+	// Use ContainerdExecutor if BuildKit is using containerd as its worker
+	if goss.DetectBuildkitContainerdWorker(buildkitHost) {
+		return NewTestRunner(NewContainerdExecutor(), runnerOpts), nil
+	}
+
+	return nil, fmt.Errorf("BuildKit is not using containerd as it's default worker")
+}
+
+func createKubernetesExecutor(cfg Config) (*KubernetesExecutor, error) {
+	k8sClient, err := kubecli.New("")
+	if err != nil {
+		return nil, fmt.Errorf("could not get kube client from context: %w", err)
+	}
+
+	executor := NewKubernetesExecutor(*k8sClient.Config, k8sClient.ClientSet, kubernetes.PodConfig{
+		Namespace:         cfg.Executor.Kubernetes.Namespace,
+		Image:             cfg.Executor.Kubernetes.Image,
+		ImagePullSecrets:  cfg.Executor.Kubernetes.ImagePullSecrets,
+		PodOverride:       cfg.Executor.Kubernetes.PodOverride,
+		ContainerOverride: cfg.Executor.Kubernetes.ContainerOverride,
+	})
+
+	return executor, nil
+}
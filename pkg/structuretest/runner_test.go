@@ -0,0 +1,180 @@
+package structuretest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/junit"
+	"github.com/radiofrance/dib/pkg/logger"
+	"github.com/radiofrance/dib/pkg/structuretest"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	lvl := "fatal"
+	logger.SetLevel(&lvl)
+	os.Exit(m.Run())
+}
+
+type fakeExecutor struct {
+	Error        error
+	Output       string
+	RecordedOpts types.RunTestOptions
+	RecordedArgs []string
+}
+
+func (e *fakeExecutor) Execute(_ context.Context, output io.Writer, opts types.RunTestOptions, args ...string) error {
+	e.RecordedOpts = opts
+	e.RecordedArgs = args
+
+	_, err := output.Write([]byte(e.Output))
+	if err != nil {
+		return err
+	}
+
+	return e.Error
+}
+
+// newContextDir creates a build context, containing a structure-test.yaml file, in the given working directory.
+func newContextDir(t *testing.T, workingDir string) string {
+	t.Helper()
+
+	contextPath := path.Join(workingDir, "docker", "image")
+	require.NoError(t, os.MkdirAll(contextPath, 0o750))
+	require.NoError(t, os.WriteFile(path.Join(contextPath, "structure-test.yaml"),
+		[]byte("schemaVersion: 2.0.0\n"), 0o600))
+
+	return contextPath
+}
+
+func Test_TestRunner_IsConfigured(t *testing.T) {
+	t.Parallel()
+
+	runner := structuretest.NewTestRunner(&fakeExecutor{}, structuretest.TestRunnerOptions{})
+
+	assert.Equal(t, types.TestRunnerStructureTest, runner.Name())
+	assert.True(t, runner.IsConfigured(types.RunTestOptions{DockerContextPath: newContextDir(t, t.TempDir())}))
+	assert.False(t, runner.IsConfigured(types.RunTestOptions{DockerContextPath: t.TempDir()}))
+}
+
+func Test_TestRunner_RunTest_Junit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		output        string
+		executorError error
+		expectedError string
+		expectedSuite junit.Testsuite
+	}{
+		{
+			name: "tests succeed",
+			output: "Pulling image...\n" +
+				`<testsuites failures="0" tests="1" time="0.5"><testsuite>` +
+				`<testcase name="Command Test: whoami" time="0.5"></testcase></testsuite></testsuites>`,
+			expectedSuite: junit.Testsuite{
+				Name:     "structure-test",
+				Tests:    "1",
+				Failures: "0",
+				Time:     "0.5",
+				TestCases: []junit.TestCase{
+					{ClassName: "structure-test-image", File: "docker/image", Name: "Command Test: whoami", Time: "0.5"},
+				},
+			},
+		},
+		{
+			name: "tests fail",
+			output: `<testsuites failures="1" tests="2" time="1"><testsuite>` +
+				`<testcase name="Command Test: whoami" time="0.5"></testcase>` +
+				`<testcase name="File Existence Test: /app" time="0.5"><failure>File /app should exist</failure></testcase>` +
+				`</testsuite></testsuites>`,
+			executorError: errors.New("exit status 1"),
+			expectedError: "structure tests failed: exit status 1",
+			expectedSuite: junit.Testsuite{
+				Name:     "structure-test",
+				Tests:    "2",
+				Failures: "1",
+				Time:     "1",
+				TestCases: []junit.TestCase{
+					{ClassName: "structure-test-image", File: "docker/image", Name: "Command Test: whoami", Time: "0.5"},
+					{
+						ClassName: "structure-test-image", File: "docker/image", Name: "File Existence Test: /app",
+						Time: "0.5", Failure: "File /app should exist",
+					},
+				},
+			},
+		},
+		{
+			name:          "no report",
+			output:        "Error: image not found",
+			executorError: errors.New("exit status 1"),
+			expectedError: "structure tests failed, could not export junit report: exit status 1\n" +
+				"no junit report found in container-structure-test output",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			workingDir := t.TempDir()
+			executor := &fakeExecutor{Output: test.output, Error: test.executorError}
+			runner := structuretest.NewTestRunner(executor, structuretest.TestRunnerOptions{
+				WorkingDirectory: workingDir,
+			})
+
+			opts := types.RunTestOptions{
+				ImageName:         "image",
+				ImageReference:    "gcr.io/project/image:tag",
+				DockerContextPath: newContextDir(t, workingDir),
+				ReportJunitDir:    path.Join(workingDir, "junit"),
+			}
+
+			err := runner.RunTest(t.Context(), opts)
+			assert.Equal(t, opts, executor.RecordedOpts)
+			assert.Equal(t, []string{"--output", "junit"}, executor.RecordedArgs)
+
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			rawJunit, err := os.ReadFile(structuretest.JunitReportPath(opts.ReportJunitDir, "image"))
+			if test.expectedSuite.Name == "" {
+				require.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+
+			require.NoError(t, err)
+
+			suite, err := junit.ParseRawLogs(rawJunit)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSuite.Name, suite.Name)
+			assert.Equal(t, test.expectedSuite.Tests, suite.Tests)
+			assert.Equal(t, test.expectedSuite.Failures, suite.Failures)
+			assert.Equal(t, test.expectedSuite.Time, suite.Time)
+			require.Len(t, suite.TestCases, len(test.expectedSuite.TestCases))
+
+			for i, testCase := range suite.TestCases {
+				testCase.XMLName = test.expectedSuite.TestCases[i].XMLName
+				assert.Equal(t, test.expectedSuite.TestCases[i], testCase)
+			}
+		})
+	}
+}
+
+func Test_CreateTestRunner(t *testing.T) {
+	t.Parallel()
+
+	runner, err := structuretest.CreateTestRunner(structuretest.Config{}, true, "", "", types.BackendDocker)
+	require.NoError(t, err)
+	assert.Equal(t, "*structuretest.DockerExecutor", fmt.Sprintf("%T", runner.Executor))
+}
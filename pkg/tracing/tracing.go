@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "github.com/radiofrance/dib"
+	serviceName = "dib"
+)
+
+// Attribute keys set on the spans created by dib.
+const (
+	AttributeImageName      = attribute.Key("dib.image.name")
+	AttributeImageShortName = attribute.Key("dib.image.short_name")
+	AttributeImageHash      = attribute.Key("dib.image.hash")
+	AttributeImageRef       = attribute.Key("dib.image.ref")
+	AttributeBackend        = attribute.Key("dib.backend")
+)
+
+// Config holds the configuration for exporting traces.
+type Config struct {
+	// Enabled turns on the export of OTLP traces.
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint is the URL of the OTLP/HTTP collector (e.g. "http://localhost:4318").
+	// When empty, the standard OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string `mapstructure:"endpoint"`
+	// Headers are additional HTTP headers sent to the collector, for authentication purposes.
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+// ShutdownFunc flushes the pending spans and releases the resources held by the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup configures the global tracer provider to export spans to an OTLP collector.
+// When tracing is disabled, spans are still created but dropped, so the instrumented code
+// does not need to care about whether tracing is enabled.
+func Setup(ctx context.Context, cfg Config, version string) (ShutdownFunc, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start creates a span as a child of the span found in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, on the span, then ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// ImageAttributes returns the attributes describing the given image.
+func ImageAttributes(img *dag.Image) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttributeImageName.String(img.Name),
+		AttributeImageShortName.String(img.ShortName),
+		AttributeImageHash.String(img.Hash),
+	}
+}
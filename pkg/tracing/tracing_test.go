@@ -0,0 +1,115 @@
+package tracing_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/dag"
+	"github.com/radiofrance/dib/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeCollector is a stand-in for an OTLP/HTTP collector, which records the spans it receives.
+type fakeCollector struct {
+	mu      sync.Mutex
+	headers http.Header
+	spans   []*tracepb.Span
+}
+
+func (c *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := &collectortrace.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headers = r.Header.Clone()
+
+	for _, resourceSpans := range req.GetResourceSpans() {
+		for _, scopeSpans := range resourceSpans.GetScopeSpans() {
+			c.spans = append(c.spans, scopeSpans.GetSpans()...)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestSetup_ExportsSpans(t *testing.T) {
+	collector := &fakeCollector{}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	shutdown, err := tracing.Setup(t.Context(), tracing.Config{
+		Enabled:  true,
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	}, "1.0.0")
+	require.NoError(t, err)
+
+	ctx, root := tracing.Start(t.Context(), "dib build", tracing.AttributeBackend.String("docker"))
+	_, child := tracing.Start(ctx, "build", tracing.ImageAttributes(&dag.Image{
+		Name:      "registry/image",
+		ShortName: "image",
+		Hash:      "image-hash",
+	})...)
+	tracing.End(child, errors.New("build failed"))
+	tracing.End(root, nil)
+
+	require.NoError(t, shutdown(t.Context()))
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	assert.Equal(t, "Bearer token", collector.headers.Get("Authorization"))
+	require.Len(t, collector.spans, 2)
+
+	spans := map[string]*tracepb.Span{}
+	for _, span := range collector.spans {
+		spans[span.GetName()] = span
+	}
+
+	require.Contains(t, spans, "dib build")
+	require.Contains(t, spans, "build")
+	assert.Equal(t, spans["dib build"].GetSpanId(), spans["build"].GetParentSpanId())
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, spans["build"].GetStatus().GetCode())
+
+	attributes := map[string]string{}
+	for _, attr := range spans["build"].GetAttributes() {
+		attributes[attr.GetKey()] = attr.GetValue().GetStringValue()
+	}
+
+	assert.Equal(t, map[string]string{
+		"dib.image.name":       "registry/image",
+		"dib.image.short_name": "image",
+		"dib.image.hash":       "image-hash",
+	}, attributes)
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	t.Parallel()
+
+	shutdown, err := tracing.Setup(t.Context(), tracing.Config{}, "1.0.0")
+	require.NoError(t, err)
+
+	_, span := tracing.Start(t.Context(), "noop")
+	tracing.End(span, nil)
+
+	assert.NoError(t, shutdown(t.Context()))
+}
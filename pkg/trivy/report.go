@@ -0,0 +1,172 @@
+package trivy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/junit"
+)
+
+// Severities supported by trivy, from the most to the least severe.
+const (
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+	SeverityMedium   = "MEDIUM"
+	SeverityLow      = "LOW"
+	SeverityUnknown  = "UNKNOWN"
+)
+
+// Report is the subset of the trivy JSON report used by dib.
+type Report struct {
+	ArtifactName string   `json:"ArtifactName"`
+	Results      []Result `json:"Results"`
+}
+
+// Result holds the vulnerabilities found in a target of the image (OS packages, language-specific packages...).
+type Result struct {
+	Target          string          `json:"Target"`
+	Class           string          `json:"Class"`
+	Type            string          `json:"Type"`
+	Vulnerabilities []Vulnerability `json:"Vulnerabilities"`
+}
+
+// Vulnerability is a vulnerability found in a package.
+type Vulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	Severity         string `json:"Severity"`
+	Title            string `json:"Title"`
+}
+
+// VulnerabilityCounts holds the number of vulnerabilities found, by severity.
+type VulnerabilityCounts struct {
+	Critical int
+	High     int
+	Medium   int
+	Low      int
+	Unknown  int
+}
+
+// ParseReport parses a trivy report in JSON format.
+func ParseReport(data []byte) (Report, error) {
+	var report Report
+
+	err := json.Unmarshal(data, &report)
+	if err != nil {
+		return report, fmt.Errorf("invalid trivy report: %w", err)
+	}
+
+	return report, nil
+}
+
+// Counts returns the number of vulnerabilities found in the image, by severity.
+func (r Report) Counts() VulnerabilityCounts {
+	var counts VulnerabilityCounts
+
+	for _, result := range r.Results {
+		for _, vuln := range result.Vulnerabilities {
+			switch vuln.Severity {
+			case SeverityCritical:
+				counts.Critical++
+			case SeverityHigh:
+				counts.High++
+			case SeverityMedium:
+				counts.Medium++
+			case SeverityLow:
+				counts.Low++
+			default:
+				counts.Unknown++
+			}
+		}
+	}
+
+	return counts
+}
+
+// Total returns the total number of vulnerabilities.
+func (c VulnerabilityCounts) Total() int {
+	return c.Critical + c.High + c.Medium + c.Low + c.Unknown
+}
+
+// String returns a human-readable summary of the counts, e.g. "2 CRITICAL, 1 HIGH".
+func (c VulnerabilityCounts) String() string {
+	var parts []string
+
+	for _, count := range []struct {
+		severity string
+		value    int
+	}{
+		{SeverityCritical, c.Critical},
+		{SeverityHigh, c.High},
+		{SeverityMedium, c.Medium},
+		{SeverityLow, c.Low},
+		{SeverityUnknown, c.Unknown},
+	} {
+		if count.value > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.value, count.severity))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "no vulnerabilities"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Testsuite converts the report to a JUnit test suite, with a test case for each scanned target.
+// Test cases fail when vulnerabilities are found in the target.
+func (r Report) Testsuite(name, file string) junit.Testsuite {
+	suite := junit.Testsuite{
+		Name:    name,
+		Errors:  "0",
+		Skipped: "0",
+		Time:    "0",
+	}
+
+	failures := 0
+
+	for _, result := range r.Results {
+		testCase := junit.TestCase{
+			ClassName: name,
+			File:      file,
+			Name:      result.Target,
+			Time:      "0",
+		}
+
+		if len(result.Vulnerabilities) == 0 {
+			testCase.SystemOut = "No vulnerabilities found"
+		} else {
+			failures++
+
+			var failure strings.Builder
+			for _, vuln := range result.Vulnerabilities {
+				fmt.Fprintf(&failure, "%s (%s) %s %s", vuln.VulnerabilityID, vuln.Severity, vuln.PkgName,
+					vuln.InstalledVersion)
+
+				if vuln.FixedVersion != "" {
+					fmt.Fprintf(&failure, ", fixed in %s", vuln.FixedVersion)
+				}
+
+				if vuln.Title != "" {
+					fmt.Fprintf(&failure, ": %s", vuln.Title)
+				}
+
+				failure.WriteString("\n")
+			}
+
+			testCase.Failure = failure.String()
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suite.Tests = strconv.Itoa(len(r.Results))
+	suite.Failures = strconv.Itoa(failures)
+
+	return suite
+}
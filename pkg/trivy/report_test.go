@@ -0,0 +1,25 @@
+package trivy_test
+
+import (
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/trivy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReport(t *testing.T) {
+	t.Parallel()
+
+	report, err := trivy.ParseReport([]byte(reportWithVulnerabilities))
+	require.NoError(t, err)
+
+	counts := report.Counts()
+	assert.Equal(t, trivy.VulnerabilityCounts{Critical: 1, High: 1}, counts)
+	assert.Equal(t, 2, counts.Total())
+	assert.Equal(t, "1 CRITICAL, 1 HIGH", counts.String())
+	assert.Equal(t, "no vulnerabilities", trivy.VulnerabilityCounts{}.String())
+
+	_, err = trivy.ParseReport([]byte("not json"))
+	require.ErrorContains(t, err, "invalid trivy report")
+}
@@ -0,0 +1,192 @@
+package trivy
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/radiofrance/dib/pkg/types"
+)
+
+const (
+	trivyBinary = "trivy"
+	// configFilename is the trivy configuration file that may be placed in the build context
+	// to override the scan settings of an image, such as the severities.
+	configFilename = "trivy.yaml"
+	// DefaultSeverity is the list of severities reported when not configured.
+	DefaultSeverity = "HIGH,CRITICAL"
+)
+
+// ignoreFilenames are the trivy ignore files looked up in the build context, by order of preference.
+var ignoreFilenames = []string{".trivyignore.yaml", ".trivyignore"}
+
+var ErrVulnerabilitiesFound = errors.New("vulnerabilities found")
+
+// Executor runs the trivy binary.
+type Executor interface {
+	ExecuteWithWriter(writer io.Writer, name string, args ...string) error
+}
+
+// Config holds the configuration for the Trivy test runner.
+type Config struct {
+	// Severity is the comma-separated list of severities making the tests fail. Defaults to "HIGH,CRITICAL".
+	Severity string `mapstructure:"severity"`
+	// IgnoreUnfixed ignores the vulnerabilities without a fix available.
+	IgnoreUnfixed bool `mapstructure:"ignore_unfixed"`
+}
+
+// TestRunner implements types.TestRunner, scanning images for vulnerabilities with trivy.
+type TestRunner struct {
+	Executor
+	Config
+
+	WorkingDirectory string
+}
+
+// NewTestRunner creates a new instance of TestRunner.
+func NewTestRunner(executor Executor, config Config, workingDir string) *TestRunner {
+	if config.Severity == "" {
+		config.Severity = DefaultSeverity
+	}
+
+	return &TestRunner{executor, config, workingDir}
+}
+
+// Name returns the name of the test runner.
+func (r *TestRunner) Name() string {
+	return types.TestRunnerTrivy
+}
+
+// IsConfigured always returns true, as every image can be scanned.
+func (r *TestRunner) IsConfigured(_ types.RunTestOptions) bool {
+	return true
+}
+
+// RunTest scans the image for vulnerabilities. The JSON, SARIF and JUnit reports are written in the
+// junit reports directory. The test fails if any vulnerability with the configured severities is found.
+func (r *TestRunner) RunTest(_ context.Context, opts types.RunTestOptions) error {
+	err := os.MkdirAll(opts.ReportJunitDir, 0o750)
+	if err != nil {
+		return err
+	}
+
+	jsonReport := JSONReportPath(opts.ReportJunitDir, opts.ImageName)
+
+	args := []string{"image", "--quiet", "--format", "json", "--output", jsonReport, "--exit-code", "0"}
+	args = append(args, r.scanArgs(opts.DockerContextPath)...)
+	args = append(args, opts.ImageReference)
+
+	var output bytes.Buffer
+
+	err = r.ExecuteWithWriter(&output, trivyBinary, args...)
+	if err != nil {
+		return fmt.Errorf("trivy scan failed: %w: %s", err, output.String())
+	}
+
+	rawReport, err := os.ReadFile(jsonReport) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("cannot read trivy report: %w", err)
+	}
+
+	report, err := ParseReport(rawReport)
+	if err != nil {
+		return err
+	}
+
+	err = r.exportSarifReport(opts, jsonReport)
+	if err != nil {
+		return err
+	}
+
+	err = r.exportJunitReport(opts, report)
+	if err != nil {
+		return err
+	}
+
+	counts := report.Counts()
+	if counts.Total() > 0 {
+		return fmt.Errorf("%w: %s", ErrVulnerabilitiesFound, counts)
+	}
+
+	return nil
+}
+
+// JSONReportPath returns the path of the trivy JSON report of the image.
+func JSONReportPath(reportDir, imageName string) string {
+	return path.Join(reportDir, fmt.Sprintf("trivy-%s.json", strings.ReplaceAll(imageName, "/", "_")))
+}
+
+// JunitReportPath returns the path of the trivy JUnit report of the image.
+func JunitReportPath(junitDir, imageName string) string {
+	return path.Join(junitDir, fmt.Sprintf("junit-trivy-%s.xml", strings.ReplaceAll(imageName, "/", "_")))
+}
+
+// scanArgs returns the trivy arguments to scan an image. When a trivy.yaml file is found in the build context,
+// it takes precedence over the runner configuration.
+func (r *TestRunner) scanArgs(contextPath string) []string {
+	var args []string
+
+	configFile := path.Join(contextPath, configFilename)
+	if _, err := os.Stat(configFile); err == nil {
+		args = append(args, "--config", configFile)
+	} else {
+		args = append(args, "--severity", r.Severity)
+		if r.IgnoreUnfixed {
+			args = append(args, "--ignore-unfixed")
+		}
+	}
+
+	for _, filename := range ignoreFilenames {
+		ignoreFile := path.Join(contextPath, filename)
+		if _, err := os.Stat(ignoreFile); err == nil {
+			args = append(args, "--ignorefile", ignoreFile)
+			break
+		}
+	}
+
+	return args
+}
+
+// exportSarifReport converts the JSON report to SARIF, to be uploaded to code scanning tools.
+func (r *TestRunner) exportSarifReport(opts types.RunTestOptions, jsonReport string) error {
+	sarifReport := path.Join(opts.ReportJunitDir,
+		fmt.Sprintf("trivy-%s.sarif", strings.ReplaceAll(opts.ImageName, "/", "_")))
+
+	var output bytes.Buffer
+
+	err := r.ExecuteWithWriter(&output, trivyBinary, "convert", "--format", "sarif", "--output", sarifReport,
+		jsonReport)
+	if err != nil {
+		return fmt.Errorf("cannot convert trivy report to SARIF: %w: %s", err, output.String())
+	}
+
+	return nil
+}
+
+// exportJunitReport writes the report in junit format.
+func (r *TestRunner) exportJunitReport(opts types.RunTestOptions, report Report) error {
+	suite := report.Testsuite(
+		fmt.Sprintf("trivy-%s", opts.ImageName),
+		strings.ReplaceAll(opts.DockerContextPath, r.WorkingDirectory+"/", ""),
+	)
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal junit report: %w", err)
+	}
+
+	junitFilename := JunitReportPath(opts.ReportJunitDir, opts.ImageName)
+
+	err = os.WriteFile(junitFilename, append([]byte(xml.Header), data...), 0o644) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("could not write junit report to file %s: %w", junitFilename, err)
+	}
+
+	return nil
+}
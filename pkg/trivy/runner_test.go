@@ -0,0 +1,170 @@
+package trivy_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/radiofrance/dib/pkg/junit"
+	"github.com/radiofrance/dib/pkg/trivy"
+	"github.com/radiofrance/dib/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reportWithVulnerabilities = `{
+  "ArtifactName": "gcr.io/project/image:tag",
+  "Results": [
+    {
+      "Target": "gcr.io/project/image:tag (alpine 3.17.0)",
+      "Class": "os-pkgs",
+      "Type": "alpine",
+      "Vulnerabilities": [
+        {
+          "VulnerabilityID": "CVE-2023-0001",
+          "PkgName": "openssl",
+          "InstalledVersion": "3.0.7-r0",
+          "FixedVersion": "3.0.8-r0",
+          "Severity": "CRITICAL",
+          "Title": "openssl: remote code execution"
+        },
+        {
+          "VulnerabilityID": "CVE-2023-0002",
+          "PkgName": "busybox",
+          "InstalledVersion": "1.35.0-r29",
+          "Severity": "HIGH"
+        }
+      ]
+    },
+    {
+      "Target": "app/go.mod",
+      "Class": "lang-pkgs",
+      "Type": "gomod"
+    }
+  ]
+}`
+
+// fakeExecutor writes the given JSON report where trivy would, and records the executed commands.
+type fakeExecutor struct {
+	Report   string
+	Error    error
+	Executed [][]string
+}
+
+func (e *fakeExecutor) ExecuteWithWriter(_ io.Writer, _ string, args ...string) error {
+	e.Executed = append(e.Executed, args)
+
+	if e.Error != nil {
+		return e.Error
+	}
+
+	output := args[slices.Index(args, "--output")+1]
+	if args[0] == "image" {
+		return os.WriteFile(output, []byte(e.Report), 0o600)
+	}
+
+	return os.WriteFile(output, []byte("{}"), 0o600)
+}
+
+func TestTestRunner_RunTest(t *testing.T) {
+	t.Parallel()
+
+	reportDir := t.TempDir()
+	executor := &fakeExecutor{Report: reportWithVulnerabilities}
+	runner := trivy.NewTestRunner(executor, trivy.Config{IgnoreUnfixed: true}, "/workdir")
+
+	opts := types.RunTestOptions{
+		ImageName:         "image",
+		ImageReference:    "gcr.io/project/image:tag",
+		DockerContextPath: t.TempDir(),
+		ReportJunitDir:    reportDir,
+	}
+
+	assert.Equal(t, types.TestRunnerTrivy, runner.Name())
+	assert.True(t, runner.IsConfigured(opts))
+
+	err := runner.RunTest(t.Context(), opts)
+	require.ErrorIs(t, err, trivy.ErrVulnerabilitiesFound)
+	assert.EqualError(t, err, "vulnerabilities found: 1 CRITICAL, 1 HIGH")
+
+	require.Len(t, executor.Executed, 2)
+	assert.Equal(t, []string{
+		"image", "--quiet", "--format", "json", "--output", path.Join(reportDir, "trivy-image.json"),
+		"--exit-code", "0", "--severity", "HIGH,CRITICAL", "--ignore-unfixed", "gcr.io/project/image:tag",
+	}, executor.Executed[0])
+	assert.Equal(t, []string{
+		"convert", "--format", "sarif", "--output", path.Join(reportDir, "trivy-image.sarif"),
+		path.Join(reportDir, "trivy-image.json"),
+	}, executor.Executed[1])
+
+	rawJunit, err := os.ReadFile(path.Join(reportDir, "junit-trivy-image.xml"))
+	require.NoError(t, err)
+
+	suite, err := junit.ParseRawLogs(rawJunit)
+	require.NoError(t, err)
+	assert.Equal(t, "trivy-image", suite.Name)
+	assert.Equal(t, "2", suite.Tests)
+	assert.Equal(t, "1", suite.Failures)
+	require.Len(t, suite.TestCases, 2)
+	assert.Contains(t, suite.TestCases[0].Failure,
+		"CVE-2023-0001 (CRITICAL) openssl 3.0.7-r0, fixed in 3.0.8-r0: openssl: remote code execution")
+	assert.Equal(t, "No vulnerabilities found", suite.TestCases[1].SystemOut)
+}
+
+func TestTestRunner_RunTest_NoVulnerabilities(t *testing.T) {
+	t.Parallel()
+
+	executor := &fakeExecutor{Report: `{"Results": [{"Target": "debian"}]}`}
+	runner := trivy.NewTestRunner(executor, trivy.Config{Severity: "CRITICAL"}, "/workdir")
+
+	err := runner.RunTest(t.Context(), types.RunTestOptions{
+		ImageName:         "image",
+		ImageReference:    "gcr.io/project/image:tag",
+		DockerContextPath: t.TempDir(),
+		ReportJunitDir:    t.TempDir(),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, executor.Executed[0], "CRITICAL")
+}
+
+func TestTestRunner_RunTest_ContextFiles(t *testing.T) {
+	t.Parallel()
+
+	contextPath := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(contextPath, "trivy.yaml"), []byte("severity: [CRITICAL]"), 0o600))
+	require.NoError(t, os.WriteFile(path.Join(contextPath, ".trivyignore"), []byte("CVE-2023-0002"), 0o600))
+
+	executor := &fakeExecutor{Report: `{}`}
+	runner := trivy.NewTestRunner(executor, trivy.Config{IgnoreUnfixed: true}, "/workdir")
+
+	err := runner.RunTest(t.Context(), types.RunTestOptions{
+		ImageName:         "image",
+		ImageReference:    "gcr.io/project/image:tag",
+		DockerContextPath: contextPath,
+		ReportJunitDir:    t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	args := executor.Executed[0]
+	assert.Contains(t, args, path.Join(contextPath, "trivy.yaml"))
+	assert.Contains(t, args, path.Join(contextPath, ".trivyignore"))
+	assert.NotContains(t, args, "--severity", "the trivy.yaml file takes precedence over the runner configuration")
+	assert.NotContains(t, args, "--ignore-unfixed")
+}
+
+func TestTestRunner_RunTest_ScanError(t *testing.T) {
+	t.Parallel()
+
+	executor := &fakeExecutor{Error: errors.New("exit status 1")}
+	runner := trivy.NewTestRunner(executor, trivy.Config{}, "/workdir")
+
+	err := runner.RunTest(t.Context(), types.RunTestOptions{
+		ImageName:      "image",
+		ImageReference: "gcr.io/project/image:tag",
+		ReportJunitDir: t.TempDir(),
+	})
+	require.ErrorContains(t, err, "trivy scan failed: exit status 1")
+}
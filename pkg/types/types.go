@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"io"
+	"time"
 )
 
 const (
@@ -12,6 +13,12 @@ const (
 	BuildKitBackend = "buildkit"
 	// TestRunnerGoss use Goss for testing Docker images.
 	TestRunnerGoss = "goss"
+	// TestRunnerTrivy use Trivy for scanning Docker images for vulnerabilities.
+	TestRunnerTrivy = "trivy"
+	// TestRunnerStructureTest use container-structure-test for testing Docker images.
+	TestRunnerStructureTest = "structure-test"
+	// TestRunnerLint use the built-in linter for checking Dockerfiles.
+	TestRunnerLint = "lint"
 )
 
 // ImageBuilder is the interface for building oci images.
@@ -45,6 +52,24 @@ type ImageBuilderOpts struct {
 	Progress string
 	// Compression set the compression type (uncompressed, gzip, estargz, zstd)
 	Compression string
+	// AttestSBOM asks the builder to attach an SBOM attestation to the image, for builders supporting it.
+	AttestSBOM bool
+	// Provenance, when not nil, asks the builder to attach a SLSA provenance attestation to the image,
+	// for builders supporting it.
+	Provenance *ProvenanceOpts
+	// OnPushed, when not nil, is called with the time spent pushing the images,
+	// by builders that push them in a separate step.
+	OnPushed func(duration time.Duration) `json:"-"`
+}
+
+// ProvenanceOpts is the set of options of the provenance attestation of an image.
+type ProvenanceOpts struct {
+	// Mode is the provenance mode, "min" or "max".
+	Mode string
+	// BuilderID identifies the build in the provenance, e.g. the URL of the CI job.
+	BuilderID string
+	// Metadata is a key/value set of build options recorded in the provenance, e.g. "vcs:revision".
+	Metadata map[string]string
 }
 
 // ImageTagger is an abstraction for tagging docker images.
@@ -52,6 +77,26 @@ type ImageTagger interface {
 	Tag(from, to string) error
 }
 
+// ImageSigner signs images in the registry.
+type ImageSigner interface {
+	// Sign signs the image the ref points to, and returns the ref of the signature.
+	Sign(ctx context.Context, imageRef string) (string, error)
+}
+
+// SignatureVerifier verifies the signatures of images in the registry.
+type SignatureVerifier interface {
+	// Verify returns an error unless the image the ref points to carries a valid signature.
+	Verify(ctx context.Context, imageRef string) error
+}
+
+// SBOMProvider generates the SBOM of images, and attaches it to them in the registry as an OCI referrer.
+type SBOMProvider interface {
+	// Attach generates the SBOM of the freshly built image, writes it to the file, and attaches it to the image.
+	Attach(ctx context.Context, imageRef, filename string) error
+	// Ensure makes sure the image the ref points to carries an SBOM, attaching one if it is missing.
+	Ensure(ctx context.Context, imageRef string) error
+}
+
 // TestRunner is an interface for dealing with docker tests, such as goss.
 type TestRunner interface {
 	Name() string
@@ -62,10 +107,18 @@ type TestRunner interface {
 	RunTest(ctx context.Context, opts RunTestOptions) error
 }
 
+// PreBuildTestRunner is a TestRunner checking the build context rather than the built image, such as a linter.
+// It runs before the image is built, so an image failing its tests is not built at all.
+type PreBuildTestRunner interface {
+	TestRunner
+	RunBeforeBuild() bool
+}
+
 type RunTestOptions struct {
 	ImageName         string
 	ImageReference    string
 	DockerContextPath string
+	DockerfilePath    string
 	BuildkitHost      string
 	ReportJunitDir    string
 }
@@ -74,3 +127,60 @@ type RunTestOptions struct {
 type DockerRegistry interface {
 	RefExists(imageRef string) (bool, error)
 }
+
+// TagLister is implemented by the registries able to list the tags of a repository, so the existence of all the
+// refs of a repository is checked with a single request.
+type TagLister interface {
+	// Tags returns all the tags of the repository. A repository which does not exist has no tags.
+	Tags(repository string) ([]string, error)
+}
+
+// UpstreamRegistry is an interface for querying the registries hosting the external base images.
+type UpstreamRegistry interface {
+	// Digest returns the digest of the manifest the image ref points to.
+	Digest(imageRef string) (string, error)
+	// Tags returns all the tags of the repository.
+	Tags(repository string) ([]string, error)
+}
+
+// ImageCopier copies images between registries.
+type ImageCopier interface {
+	// Copy copies the manifest the source ref points to, along with its blobs, to the destination ref.
+	Copy(srcRef, destRef string) error
+}
+
+// ImageMirrorer checks which images are missing from the extra registries, and copies them there.
+type ImageMirrorer interface {
+	DockerRegistry
+	ImageCopier
+}
+
+// ArchivedImage is an image stored in an ImageArchive.
+type ArchivedImage struct {
+	// Repository is the path of the repository, without the registry host (e.g. "library/debian").
+	Repository string
+	// Tag is the tag of the image. It is empty for images pinned by digest only.
+	Tag string
+	// Digest is the digest of the manifest of the image.
+	Digest string
+}
+
+// ImageArchive stores images outside of any registry, to move them to sites having no access to the registries.
+type ImageArchive interface {
+	// Add copies the image the ref points to into the archive, stored with the repository and tag of the archived
+	// image. It returns the archived image, along with its digest.
+	Add(imageRef string, image ArchivedImage) (ArchivedImage, error)
+	// Images returns all the images stored in the archive.
+	Images() ([]ArchivedImage, error)
+	// Push pushes an image of the archive to the destination ref.
+	Push(image ArchivedImage, destRef string) error
+}
+
+// CleanableRegistry is an interface for removing the stale tags of the dib-managed repositories.
+type CleanableRegistry interface {
+	UpstreamRegistry
+	// Created returns the creation date of the image the ref points to.
+	Created(imageRef string) (time.Time, error)
+	// Delete deletes the manifest the digest ref points to, along with all its tags.
+	Delete(imageRef string) error
+}